@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/primevprotocol/primev-contracts/pkg/fees"
+	"github.com/primevprotocol/primev-contracts/pkg/terms"
+)
+
+func printTermsReport(r *terms.Report) {
+	fmt.Printf("Minimal stake:               %v wei\n", r.Terms.MinimalStake)
+	fmt.Printf("Minimal subscription period: %v blocks\n", r.Terms.MinimalSubscriptionPeriod)
+	if r.PricePerBlock != nil {
+		fmt.Printf("Nominal price:               %s wei per block\n", r.PricePerBlock.FloatString(2))
+	}
+	for _, s := range r.Samples {
+		paid := "-"
+		if s.PricePaid != nil {
+			paid = s.PricePaid.FloatString(2)
+		}
+		fmt.Printf("  deposit %v wei: %v blocks, %s wei per block, %s blocks (%s wei) lost to rounding\n",
+			s.Deposit, s.Blocks, paid, s.LostBlocks.FloatString(2), s.LostWei.FloatString(0))
+	}
+	for _, issue := range r.Issues {
+		fmt.Println(issue)
+	}
+}
+
+func runUpdateBuilder(args []string) error {
+	fs := newFlagSet("update-builder")
+	var chain chainFlags
+	var keys keyFlags
+	chain.register(fs)
+	keys.register(fs)
+	minimalStake := fs.String("minimal-stake", "", "minimal stake in wei")
+	period := fs.String("minimal-subscription-period", "", "minimal subscription period in blocks")
+	fs.Parse(args)
+
+	stake, err := parseWei("minimal stake", *minimalStake)
+	if err != nil {
+		return err
+	}
+	blocks, err := parseWei("minimal subscription period", *period)
+	if err != nil {
+		return err
+	}
+	proposed := terms.Terms{MinimalStake: stake, MinimalSubscriptionPeriod: blocks}
+	report := terms.Validate(proposed)
+	printTermsReport(report)
+	if report.HasErrors() {
+		return &terms.InvalidTermsError{Report: report}
+	}
+
+	client, contract, err := chain.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	strategy, err := chain.strategy(client)
+	if err != nil {
+		return err
+	}
+	transactor, err := fees.NewTransactor(contract, client, fees.Policy{Strategy: strategy, GasMargin: chain.margin})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), keys.timeout)
+	defer cancel()
+	opts, err := keys.transactOpts(ctx, client, &chain)
+	if err != nil {
+		return err
+	}
+	if err := confirm("Updating builder terms.", "yes"); err != nil {
+		return err
+	}
+	tx, _, err := terms.UpdateBuilder(transactor, opts, proposed)
+	if err != nil {
+		return err
+	}
+	return waitMined(ctx, client, tx)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/primevprotocol/primev-contracts/pkg/directory"
+	"github.com/primevprotocol/primev-contracts/pkg/events"
+)
+
+func runBuilders(args []string) error {
+	fs := newFlagSet("builders")
+	var chain chainFlags
+	chain.registerNode(fs)
+	builderFlag := fs.String("builder", "", "show a single builder with its terms history")
+	sortBy := fs.String("sort", "price", "order of the list: price, eligible, deposits or changed")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	fromBlock := fs.Uint64("from-block", 0, "block the contract was deployed at, for event replay")
+	step := fs.Uint64("step", events.DefaultStep, "blocks per log query")
+	fs.Parse(args)
+
+	less, ok := builderOrders[*sortBy]
+	if !ok {
+		return fmt.Errorf("invalid sort order %q", *sortBy)
+	}
+	client, contract, err := chain.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	dir, err := directory.New(contract, client, directory.Config{FromBlock: *fromBlock, Step: *step})
+	if err != nil {
+		return err
+	}
+	if err := dir.Sync(context.Background()); err != nil {
+		return err
+	}
+
+	if *builderFlag != "" {
+		builder, err := parseAddress("builder", *builderFlag)
+		if err != nil {
+			return err
+		}
+		e, ok := dir.Builder(builder)
+		if !ok {
+			return fmt.Errorf("builder %s never set terms", builder)
+		}
+		if *asJSON {
+			return printJSON(e)
+		}
+		printBuilderEntry(e)
+		for _, t := range e.History {
+			fmt.Printf("  block %d: minimal stake %v wei, minimal subscription period %v blocks, %s wei per block (tx %s)\n",
+				t.Block, t.MinimalStake.ToInt(), t.MinimalSubscriptionPeriod.ToInt(), formatPrice(t), t.TxHash)
+		}
+		return nil
+	}
+
+	entries := dir.Builders()
+	sort.SliceStable(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+	if *asJSON {
+		return printJSON(entries)
+	}
+	fmt.Printf("%d builders at block %d\n", len(entries), dir.Block())
+	for _, e := range entries {
+		printBuilderEntry(e)
+	}
+	return nil
+}
+
+var builderOrders = map[string]func(a, b directory.Entry) bool{
+	"price": func(a, b directory.Entry) bool {
+		if a.Current.PricePerBlock == nil || b.Current.PricePerBlock == nil {
+			return b.Current.PricePerBlock == nil && a.Current.PricePerBlock != nil
+		}
+		return a.Current.PricePerBlock.ToInt().Cmp(b.Current.PricePerBlock.ToInt()) < 0
+	},
+	"eligible": func(a, b directory.Entry) bool { return a.Eligible > b.Eligible },
+	"deposits": func(a, b directory.Entry) bool {
+		return a.TotalDeposits.ToInt().Cmp(b.TotalDeposits.ToInt()) > 0
+	},
+	"changed": func(a, b directory.Entry) bool { return a.LastChange > b.LastChange },
+}
+
+func printBuilderEntry(e directory.Entry) {
+	fmt.Printf("%s: minimal stake %v wei / %v blocks = %s wei per block, %d eligible, %d active, %d subscribers, %v wei deposited, %d changes, last at block %d\n",
+		e.Builder, e.Current.MinimalStake.ToInt(), e.Current.MinimalSubscriptionPeriod.ToInt(), formatPrice(e.Current),
+		e.Eligible, e.Active, e.Subscribers, e.TotalDeposits.ToInt(), len(e.History), e.LastChange)
+}
+
+func formatPrice(t directory.Terms) string {
+	if t.PricePerBlock == nil {
+		return "-"
+	}
+	return t.PricePerBlock.ToInt().String()
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
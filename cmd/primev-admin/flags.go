@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/primevprotocol/primev-contracts/pkg/fees"
+)
+
+// chainFlags are shared by commands that talk to a node.
+type chainFlags struct {
+	rpc      string
+	contract string
+	tipCap   string
+	feeCap   string
+	margin   uint64
+}
+
+func (f *chainFlags) register(fs *flag.FlagSet) {
+	f.registerNode(fs)
+	fs.StringVar(&f.tipCap, "tip-cap", "", "fixed priority fee per gas in wei (fee history percentile if unset)")
+	fs.StringVar(&f.feeCap, "fee-cap", "", "fixed maximum fee per gas in wei (fee history percentile if unset)")
+	fs.Uint64Var(&f.margin, "gas-margin", 20, "percent added on top of the simulated gas usage")
+}
+
+// registerNode registers only the node flags, for commands that send no
+// transactions.
+func (f *chainFlags) registerNode(fs *flag.FlagSet) {
+	fs.StringVar(&f.rpc, "rpc", "http://localhost:8545", "node RPC endpoint")
+	fs.StringVar(&f.contract, "contract", "", "BuilderStaking contract address")
+}
+
+func (f *chainFlags) dial() (*ethclient.Client, common.Address, error) {
+	contract, err := parseAddress("contract", f.contract)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	client, err := ethclient.Dial(f.rpc)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	return client, contract, nil
+}
+
+func (f *chainFlags) strategy(client *ethclient.Client) (fees.Strategy, error) {
+	if f.tipCap == "" && f.feeCap == "" {
+		return &fees.Percentile{Backend: client}, nil
+	}
+	tip, ok := math.ParseBig256(f.tipCap)
+	if !ok {
+		return nil, fmt.Errorf("invalid tip cap %q", f.tipCap)
+	}
+	feeCap, ok := math.ParseBig256(f.feeCap)
+	if !ok {
+		return nil, fmt.Errorf("invalid fee cap %q", f.feeCap)
+	}
+	return &fees.Fixed{TipCap: tip, FeeCap: feeCap}, nil
+}
+
+func parseAddress(name, s string) (common.Address, error) {
+	if !common.IsHexAddress(s) {
+		return common.Address{}, fmt.Errorf("invalid %s address %q", name, s)
+	}
+	return common.HexToAddress(s), nil
+}
+
+func parseHash(name, s string) (common.Hash, error) {
+	b, err := hexutil.Decode(s)
+	if err != nil || len(b) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("invalid %s %q", name, s)
+	}
+	return common.BytesToHash(b), nil
+}
+
+func parseWei(name, s string) (*big.Int, error) {
+	if s == "" {
+		return new(big.Int), nil
+	}
+	v, ok := math.ParseBig256(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s %q", name, s)
+	}
+	return v, nil
+}
+
+func loadKey(keyfile, passwordFile string) (*keystore.Key, error) {
+	if keyfile == "" {
+		return nil, errors.New("no keystore file given")
+	}
+	keyjson, err := os.ReadFile(keyfile)
+	if err != nil {
+		return nil, err
+	}
+	var password string
+	if passwordFile != "" {
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return nil, err
+		}
+		password = strings.TrimRight(string(data), "\r\n")
+	}
+	return keystore.DecryptKey(keyjson, password)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/primevprotocol/primev-contracts/pkg/events"
+	"github.com/primevprotocol/primev-contracts/pkg/history"
+)
+
+func runHistory(args []string) error {
+	fs := newFlagSet("history")
+	var chain chainFlags
+	chain.registerNode(fs)
+	builderFlag := fs.String("builder", "", "builder address")
+	commitmentFlag := fs.String("commitment", "", "commitment hash")
+	block := fs.String("block", "", "block to query the state after")
+	tx := fs.String("tx", "", "bundle transaction; queries the state its block was built on")
+	fromBlock := fs.Uint64("from-block", 0, "block the contract was deployed at, for event replay")
+	step := fs.Uint64("step", events.DefaultStep, "blocks per log query")
+	noArchive := fs.Bool("no-archive", false, "replay from events instead of reading archive state")
+	fs.Parse(args)
+
+	builder, err := parseAddress("builder", *builderFlag)
+	if err != nil {
+		return err
+	}
+	commitment, err := parseHash("commitment", *commitmentFlag)
+	if err != nil {
+		return err
+	}
+	if (*block == "") == (*tx == "") {
+		return errors.New("exactly one of -block and -tx is required")
+	}
+	client, contract, err := chain.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	h, err := history.New(contract, client, history.Config{FromBlock: *fromBlock, Step: *step, NoArchive: *noArchive})
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var e history.Eligibility
+	if *tx != "" {
+		hash, err := parseHash("transaction", *tx)
+		if err != nil {
+			return err
+		}
+		e, err = h.EligibleAtInclusion(ctx, builder, commitment, hash)
+		if err != nil {
+			return err
+		}
+	} else {
+		n, err := strconv.ParseUint(*block, 0, 64)
+		if err != nil {
+			return fmt.Errorf("invalid block %q", *block)
+		}
+		if e, err = h.Eligible(ctx, builder, commitment, n); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("after block %d\n", e.Block)
+	fmt.Printf("builder %s: minimal stake %v wei, minimal subscription period %v blocks (%s)\n",
+		builder, e.Terms.MinimalStake, e.Terms.MinimalSubscriptionPeriod, e.Terms.Source)
+	fmt.Printf("commitment %s: stake %v wei, subscription end %v (%s)\n",
+		commitment, e.Stake.Stake, e.Stake.SubscriptionEnd, e.Stake.Source)
+	fmt.Printf("has minimal stake %v, subscription active %v\n", e.HasMinimalStake, e.Active)
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/primevprotocol/primev-contracts/pkg/events"
+	"github.com/primevprotocol/primev-contracts/pkg/impact"
+	"github.com/primevprotocol/primev-contracts/pkg/terms"
+)
+
+func printImpactReport(r *impact.Report) {
+	fmt.Printf("builder %s at block %d\n", r.Builder, r.Block)
+	fmt.Printf("  minimal stake %v -> %v wei, minimal subscription period %v -> %v blocks\n",
+		r.Current.MinimalStake, r.Proposed.MinimalStake, r.Current.MinimalSubscriptionPeriod, r.Proposed.MinimalSubscriptionPeriod)
+	fmt.Printf("  %d of %d eligible subscribers lose eligibility, forfeiting %v blocks (%s wei)\n",
+		len(r.Losing), r.Eligible, r.ForfeitedBlocks, r.ForfeitedWei.FloatString(0))
+	for _, l := range r.Losing {
+		fmt.Printf("  commitment %s: stake %v wei, %v blocks left (%s wei), %v wei short%s\n",
+			l.Commitment, l.Stake, l.RemainingBlocks, l.ForfeitedWei.FloatString(0), l.Shortfall, depositedWith(r, l.Subscriber))
+	}
+	if len(r.Losing) > 0 && r.RestoreDeposit != nil && r.RestoreDeposit.Sign() > 0 {
+		fmt.Printf("  regaining eligibility takes a deposit of at least %v wei\n", r.RestoreDeposit)
+	}
+	if len(r.Gaining) > 0 {
+		fmt.Printf("  %d active subscribers become eligible\n", len(r.Gaining))
+	}
+	for _, s := range r.Gaining {
+		fmt.Printf("  commitment %s: stake %v wei until block %v%s\n", s.Commitment, s.Stake, s.SubscriptionEnd, depositedWith(r, s))
+	}
+}
+
+// depositedWith notes subscribers whose stake was deposited with another
+// builder, which hasMinimalStake counts all the same.
+func depositedWith(r *impact.Report, s impact.Subscriber) string {
+	if s.Builder == r.Builder {
+		return ""
+	}
+	return fmt.Sprintf(", deposited with %s", s.Builder)
+}
+
+func runTermImpact(args []string) error {
+	fs := newFlagSet("term-impact")
+	var chain chainFlags
+	chain.registerNode(fs)
+	builderFlag := fs.String("builder", "", "builder address (comma separated with -watch, all if empty)")
+	minimalStake := fs.String("minimal-stake", "", "proposed minimal stake in wei")
+	period := fs.String("minimal-subscription-period", "", "proposed minimal subscription period in blocks")
+	commitmentsFlag := fs.String("commitments", "", "comma separated commitments to report, all if empty")
+	watch := fs.Bool("watch", false, "report the impact of BuilderUpdated events as they appear")
+	fromBlock := fs.Uint64("from-block", 0, "block the contract was deployed at, for event replay")
+	step := fs.Uint64("step", events.DefaultStep, "blocks per log query")
+	fs.Parse(args)
+
+	cfg := impact.Config{FromBlock: *fromBlock, Step: *step, OnChange: printImpactReport}
+	for _, s := range splitList(*commitmentsFlag) {
+		c, err := parseHash("commitment", s)
+		if err != nil {
+			return err
+		}
+		cfg.Commitments = append(cfg.Commitments, c)
+	}
+	for _, s := range splitList(*builderFlag) {
+		b, err := parseAddress("builder", s)
+		if err != nil {
+			return err
+		}
+		cfg.Builders = append(cfg.Builders, b)
+	}
+	var proposed terms.Terms
+	if !*watch {
+		if len(cfg.Builders) != 1 {
+			return errors.New("-builder is required")
+		}
+		stake, err := parseWei("minimal stake", *minimalStake)
+		if err != nil {
+			return err
+		}
+		blocks, err := parseWei("minimal subscription period", *period)
+		if err != nil {
+			return err
+		}
+		proposed = terms.Terms{MinimalStake: stake, MinimalSubscriptionPeriod: blocks}
+	}
+
+	client, contract, err := chain.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	tracker, err := impact.New(contract, client, cfg)
+	if err != nil {
+		return err
+	}
+	if *watch {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := tracker.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+		return nil
+	}
+	report, err := tracker.Preflight(context.Background(), cfg.Builders[0], proposed)
+	if err != nil {
+		return err
+	}
+	printImpactReport(report)
+	return nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
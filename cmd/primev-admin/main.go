@@ -0,0 +1,63 @@
+// Command primev-admin performs BuilderStaking owner and builder operations,
+// including an air-gapped export, sign and broadcast workflow and Safe
+// multisig proposals.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"export", "export an unsigned transaction for offline signing", runExport},
+	{"sign", "sign an exported transaction with a keystore file (offline)", runSign},
+	{"broadcast", "broadcast a signed transaction and wait for the receipt", runBroadcast},
+	{"transfer-ownership", "transfer ownership after checking the new owner accepts ETH", runTransferOwnership},
+	{"renounce-ownership", "renounce ownership, requires -burn-fees and typed confirmation", runRenounceOwnership},
+	{"update-builder", "validate and set builder terms", runUpdateBuilder},
+	{"term-impact", "list subscribers that proposed or new builder terms disqualify", runTermImpact},
+	{"status", "show builder and commitment state read at a single block", runStatus},
+	{"builders", "list builders with their terms history, prices and subscribers", runBuilders},
+	{"history", "show a commitment's stake and eligibility at a past block or bundle", runHistory},
+	{"vesting-report", "compare vesting time with subscription time per builder", runVestingReport},
+	{"reconcile-deposits", "check the 80/20 split of past deposits against traces and time locks", runReconcileDeposits},
+	{"safe-propose", "create a Safe transaction bundle for a BuilderStaking call", runSafePropose},
+	{"safe-sign", "add an owner signature to a Safe bundle (offline)", runSafeSign},
+	{"safe-exec", "assemble execTransaction calldata from a signed Safe bundle", runSafeExec},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage:\n  primev-admin <command> [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-20s %s\n", c.name, c.usage)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "primev-admin %s: %v\n", c.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	usage()
+	os.Exit(2)
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet("primev-admin "+name, flag.ExitOnError)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/primevprotocol/primev-contracts/pkg/offline"
+)
+
+func runExport(args []string) error {
+	fs := newFlagSet("export")
+	var chain chainFlags
+	chain.register(fs)
+	from := fs.String("from", "", "account that will sign the transaction")
+	value := fs.String("value", "", "wei sent along with the call")
+	out := fs.String("out", "unsigned-tx.json", "output file")
+	burnFees := fs.Bool("burn-fees", false, "acknowledge that renounceOwnership sends the owner's share of every deposit to the zero address")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: primev-admin export [flags] <method> [args...]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return errors.New("no method given")
+	}
+
+	sender, err := parseAddress("from", *from)
+	if err != nil {
+		return err
+	}
+	wei, err := parseWei("value", *value)
+	if err != nil {
+		return err
+	}
+	params, err := offline.ParseArgs(fs.Arg(0), fs.Args()[1:])
+	if err != nil {
+		return err
+	}
+	client, contract, err := chain.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := guardOwnership(context.Background(), client, contract, sender, fs.Arg(0), params, *burnFees); err != nil {
+		return err
+	}
+	strategy, err := chain.strategy(client)
+	if err != nil {
+		return err
+	}
+
+	tx, err := offline.Export(client, contract, &offline.ExportOpts{
+		From:     sender,
+		Value:    wei,
+		Strategy: strategy,
+		Margin:   chain.margin,
+	}, fs.Arg(0), params...)
+	if err != nil {
+		return err
+	}
+	if err := offline.WriteJSON(*out, tx); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %s nonce %d to %s\n", tx.Intent.Method, tx.Nonce, *out)
+	return nil
+}
+
+func runSign(args []string) error {
+	fs := newFlagSet("sign")
+	in := fs.String("in", "unsigned-tx.json", "unsigned transaction file")
+	out := fs.String("out", "signed-tx.json", "output file")
+	keyfile := fs.String("keystore", "", "keystore file of the signing account")
+	passwordFile := fs.String("password-file", "", "file containing the keystore password")
+	fs.Parse(args)
+
+	var tx offline.UnsignedTx
+	if err := offline.ReadJSON(*in, &tx); err != nil {
+		return err
+	}
+	if err := tx.Verify(); err != nil {
+		return err
+	}
+	summary, _ := json.MarshalIndent(tx, "", "  ")
+	fmt.Fprintf(os.Stderr, "Signing:\n%s\n", summary)
+
+	key, err := loadKey(*keyfile, *passwordFile)
+	if err != nil {
+		return err
+	}
+	signed, err := offline.Sign(&tx, key.PrivateKey)
+	if err != nil {
+		return err
+	}
+	if err := offline.WriteJSON(*out, signed); err != nil {
+		return err
+	}
+	fmt.Printf("Signed %s as %s to %s\n", signed.Intent.Method, signed.Hash, *out)
+	return nil
+}
+
+func runBroadcast(args []string) error {
+	fs := newFlagSet("broadcast")
+	rpc := fs.String("rpc", "http://localhost:8545", "node RPC endpoint")
+	in := fs.String("in", "signed-tx.json", "signed transaction file")
+	timeout := fs.Duration("timeout", 5*time.Minute, "how long to wait for the receipt")
+	fs.Parse(args)
+
+	var signed offline.SignedTx
+	if err := offline.ReadJSON(*in, &signed); err != nil {
+		return err
+	}
+	client, err := ethclient.Dial(*rpc)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	receipt, err := offline.Broadcast(ctx, client, &signed)
+	if receipt != nil {
+		fmt.Printf("Transaction %s mined in block %v, status %d, gas used %d\n",
+			receipt.TxHash, receipt.BlockNumber, receipt.Status, receipt.GasUsed)
+	}
+	return err
+}
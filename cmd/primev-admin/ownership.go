@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/primevprotocol/primev-contracts/pkg/admin"
+)
+
+// keyFlags are shared by commands that sign and send a transaction online.
+type keyFlags struct {
+	keyfile      string
+	passwordFile string
+	timeout      time.Duration
+}
+
+func (f *keyFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.keyfile, "keystore", "", "keystore file of the sending account")
+	fs.StringVar(&f.passwordFile, "password-file", "", "file containing the keystore password")
+	fs.DurationVar(&f.timeout, "timeout", 5*time.Minute, "how long to wait for the receipt")
+}
+
+func (f *keyFlags) transactOpts(ctx context.Context, client *ethclient.Client, chain *chainFlags) (*bind.TransactOpts, error) {
+	key, err := loadKey(f.keyfile, f.passwordFile)
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := bind.NewKeyedTransactorWithChainID(key.PrivateKey, chainID)
+	if err != nil {
+		return nil, err
+	}
+	opts.Context = ctx
+	if chain.tipCap != "" || chain.feeCap != "" {
+		strategy, err := chain.strategy(client)
+		if err != nil {
+			return nil, err
+		}
+		fees, err := strategy.Fees(ctx)
+		if err != nil {
+			return nil, err
+		}
+		opts.GasTipCap, opts.GasFeeCap = fees.TipCap, fees.FeeCap
+	}
+	return opts, nil
+}
+
+// confirm asks the operator to type expected before a destructive action.
+func confirm(prompt, expected string) error {
+	fmt.Fprintf(os.Stderr, "%s\nType %q to continue: ", prompt, expected)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return err
+	}
+	if strings.TrimSpace(line) != expected {
+		return errors.New("confirmation does not match, aborting")
+	}
+	return nil
+}
+
+func waitMined(ctx context.Context, client *ethclient.Client, tx *types.Transaction) error {
+	fmt.Printf("Sent transaction %s\n", tx.Hash())
+	receipt, err := bind.WaitMined(ctx, client, tx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Mined in block %v, status %d, gas used %d\n", receipt.BlockNumber, receipt.Status, receipt.GasUsed)
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return errors.New("transaction reverted")
+	}
+	return nil
+}
+
+func runTransferOwnership(args []string) error {
+	fs := newFlagSet("transfer-ownership")
+	var chain chainFlags
+	var keys keyFlags
+	chain.register(fs)
+	keys.register(fs)
+	newOwner := fs.String("new-owner", "", "address of the new owner")
+	fs.Parse(args)
+
+	owner, err := parseAddress("new owner", *newOwner)
+	if err != nil {
+		return err
+	}
+	client, contract, err := chain.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), keys.timeout)
+	defer cancel()
+	opts, err := keys.transactOpts(ctx, client, &chain)
+	if err != nil {
+		return err
+	}
+	guarded, err := admin.New(contract, client)
+	if err != nil {
+		return err
+	}
+	if err := confirmOwnership(contract, "transferOwnership", owner); err != nil {
+		return err
+	}
+	tx, err := guarded.TransferOwnership(opts, owner)
+	if err != nil {
+		return err
+	}
+	return waitMined(ctx, client, tx)
+}
+
+func runRenounceOwnership(args []string) error {
+	fs := newFlagSet("renounce-ownership")
+	var chain chainFlags
+	var keys keyFlags
+	chain.register(fs)
+	keys.register(fs)
+	override := fs.Bool("burn-fees", false, "acknowledge that the owner's share of every deposit is sent to the zero address")
+	fs.Parse(args)
+
+	if !*override {
+		return admin.ErrRenounceNotAllowed
+	}
+	client, contract, err := chain.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), keys.timeout)
+	defer cancel()
+	opts, err := keys.transactOpts(ctx, client, &chain)
+	if err != nil {
+		return err
+	}
+	guarded, err := admin.New(contract, client)
+	if err != nil {
+		return err
+	}
+	if err := confirmOwnership(contract, "renounceOwnership"); err != nil {
+		return err
+	}
+	tx, err := guarded.RenounceOwnership(opts, true)
+	if err != nil {
+		return err
+	}
+	return waitMined(ctx, client, tx)
+}
+
+// confirmOwnership asks the operator to confirm a call of method on contract
+// that changes its ownership.
+func confirmOwnership(contract common.Address, method string, params ...interface{}) error {
+	if method == "renounceOwnership" {
+		return confirm(fmt.Sprintf("Renouncing ownership of %s. Deposit fees will be burned forever.", contract), "renounce "+contract.Hex())
+	}
+	owner := params[0].(common.Address)
+	return confirm(fmt.Sprintf("Transferring ownership of %s to %s.", contract, owner), owner.Hex())
+}
+
+// guardOwnership applies the admin checks to a call of method that from will
+// make outside this tool and asks for the same confirmation as the online
+// commands. Methods that do not change ownership pass unchecked.
+func guardOwnership(ctx context.Context, client *ethclient.Client, contract, from common.Address, method string, params []interface{}, burnFees bool) error {
+	if method != "transferOwnership" && method != "renounceOwnership" {
+		return nil
+	}
+	guarded, err := admin.New(contract, client)
+	if err != nil {
+		return err
+	}
+	if err := guarded.Check(ctx, from, method, params, burnFees); err != nil {
+		return err
+	}
+	return confirmOwnership(contract, method, params...)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/primevprotocol/primev-contracts/pkg/events"
+	"github.com/primevprotocol/primev-contracts/pkg/reconcile"
+)
+
+func runReconcileDeposits(args []string) error {
+	fs := newFlagSet("reconcile-deposits")
+	var chain chainFlags
+	chain.registerNode(fs)
+	from := fs.Uint64("from", 0, "first block to reconcile")
+	to := fs.Uint64("to", 0, "last block to reconcile (latest if zero)")
+	step := fs.Uint64("step", events.DefaultStep, "blocks per log query")
+	fs.Parse(args)
+
+	contract, err := parseAddress("contract", chain.contract)
+	if err != nil {
+		return err
+	}
+	rpcClient, err := rpc.Dial(chain.rpc)
+	if err != nil {
+		return err
+	}
+	defer rpcClient.Close()
+	client := ethclient.NewClient(rpcClient)
+	ctx := context.Background()
+	if *to == 0 {
+		if *to, err = client.BlockNumber(ctx); err != nil {
+			return err
+		}
+	}
+	r, err := reconcile.New(contract, client, &reconcile.RPCTracer{Client: rpcClient})
+	if err != nil {
+		return err
+	}
+	deposits, err := r.Run(ctx, events.Range{From: *from, To: *to, Step: *step})
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, d := range deposits {
+		status := "ok"
+		if !d.OK() {
+			status = "MISMATCH"
+			failed++
+		}
+		fmt.Printf("%d %s #%d builder %s value %v locked %v owner %v dust %s wei: %s\n",
+			d.Block, d.TxHash, d.LogIndex, d.Builder, d.Value, d.Locked, d.OwnerReceived, dustString(d), status)
+		for _, issue := range d.Issues {
+			fmt.Printf("    %s\n", issue)
+		}
+	}
+	fmt.Printf("%d deposits, %d with issues\n", len(deposits), failed)
+	if failed > 0 {
+		return errors.New("reconciliation found mismatches")
+	}
+	return nil
+}
+
+func dustString(d *reconcile.Deposit) string {
+	if d.Dust == nil {
+		return "-"
+	}
+	return d.Dust.RatString()
+}
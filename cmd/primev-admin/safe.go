@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/primevprotocol/primev-contracts/pkg/offline"
+	"github.com/primevprotocol/primev-contracts/pkg/safe"
+)
+
+func runSafePropose(args []string) error {
+	fs := newFlagSet("safe-propose")
+	var chain chainFlags
+	chain.registerNode(fs)
+	safeAddr := fs.String("safe", "", "Safe multisig address")
+	out := fs.String("out", "safe-bundle.json", "output bundle file")
+	burnFees := fs.Bool("burn-fees", false, "acknowledge that renounceOwnership sends the owner's share of every deposit to the zero address")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: primev-admin safe-propose [flags] <method> [args...]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return errors.New("no method given")
+	}
+
+	safeAddress, err := parseAddress("safe", *safeAddr)
+	if err != nil {
+		return err
+	}
+	params, err := offline.ParseArgs(fs.Arg(0), fs.Args()[1:])
+	if err != nil {
+		return err
+	}
+	client, contract, err := chain.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	// The Safe is the owner and sends the call.
+	if err := guardOwnership(ctx, client, contract, safeAddress, fs.Arg(0), params, *burnFees); err != nil {
+		return err
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+	multisig := safe.NewSafe(safeAddress, client)
+	nonce, err := multisig.Nonce(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return err
+	}
+	tx, err := safe.NewTransaction(contract, nonce, fs.Arg(0), params...)
+	if err != nil {
+		return err
+	}
+	bundle := safe.NewBundle((*hexutil.Big)(chainID), safeAddress, tx)
+	onchain, err := multisig.TransactionHash(&bind.CallOpts{Context: ctx}, tx)
+	if err != nil {
+		return err
+	}
+	if onchain != bundle.SafeTxHash {
+		return fmt.Errorf("safeTxHash %s does not match the Safe's own hash %s", bundle.SafeTxHash, onchain)
+	}
+	if err := offline.WriteJSON(*out, bundle); err != nil {
+		return err
+	}
+	fmt.Printf("Proposed %s with Safe nonce %v, safeTxHash %s, written to %s\n", fs.Arg(0), nonce, bundle.SafeTxHash, *out)
+	return nil
+}
+
+func runSafeSign(args []string) error {
+	fs := newFlagSet("safe-sign")
+	in := fs.String("in", "safe-bundle.json", "bundle file, updated in place")
+	keyfile := fs.String("keystore", "", "keystore file of the Safe owner")
+	passwordFile := fs.String("password-file", "", "file containing the keystore password")
+	fs.Parse(args)
+
+	var bundle safe.Bundle
+	if err := offline.ReadJSON(*in, &bundle); err != nil {
+		return err
+	}
+	intent, err := offline.DecodeIntent(bundle.Transaction.Data)
+	if err != nil {
+		return err
+	}
+	key, err := loadKey(*keyfile, *passwordFile)
+	if err != nil {
+		return err
+	}
+	if err := bundle.Sign(key.PrivateKey); err != nil {
+		return err
+	}
+	if err := offline.WriteJSON(*in, &bundle); err != nil {
+		return err
+	}
+	fmt.Printf("Signed %s %v as %s, %d signatures collected\n", intent.Method, intent.Args, key.Address, len(bundle.Signatures))
+	return nil
+}
+
+func runSafeExec(args []string) error {
+	fs := newFlagSet("safe-exec")
+	rpc := fs.String("rpc", "http://localhost:8545", "node RPC endpoint")
+	in := fs.String("in", "safe-bundle.json", "signed bundle file")
+	fs.Parse(args)
+
+	var bundle safe.Bundle
+	if err := offline.ReadJSON(*in, &bundle); err != nil {
+		return err
+	}
+	client, err := ethclient.Dial(*rpc)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	multisig := safe.NewSafe(bundle.Safe, client)
+	threshold, err := multisig.Threshold(&bind.CallOpts{})
+	if err != nil {
+		return err
+	}
+	owners, err := multisig.Owners(&bind.CallOpts{})
+	if err != nil {
+		return err
+	}
+	calldata, err := bundle.ExecCalldata(threshold.Uint64(), owners)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("to: %s\ndata: %s\n", bundle.Safe, hexutil.Encode(calldata))
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/primevprotocol/primev-contracts/pkg/snapshot"
+)
+
+func runStatus(args []string) error {
+	fs := newFlagSet("status")
+	var chain chainFlags
+	chain.registerNode(fs)
+	builderFlag := fs.String("builder", "", "builder address")
+	commitmentFlag := fs.String("commitment", "", "commitment hash (optional)")
+	block := fs.String("block", "latest", "block number or latest, safe, finalized")
+	fs.Parse(args)
+
+	builder, err := parseAddress("builder", *builderFlag)
+	if err != nil {
+		return err
+	}
+	client, contract, err := chain.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	snap, err := snapshot.New(context.Background(), contract, client, *block)
+	if err != nil {
+		return err
+	}
+	info, err := snap.Builders(builder)
+	if err != nil {
+		return err
+	}
+	locks, err := snap.TimeLocksCount(builder)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("block %d %s\n", snap.Number, snap.Hash)
+	fmt.Printf("builder %s: minimal stake %v wei, minimal subscription period %v blocks, %v time locks\n",
+		builder, info.MinimalStake, info.MinimalSubscriptionPeriod, locks)
+	if *commitmentFlag != "" {
+		commitment, err := parseHash("commitment", *commitmentFlag)
+		if err != nil {
+			return err
+		}
+		stake, err := snap.Stakes(commitment)
+		if err != nil {
+			return err
+		}
+		eligible, err := snap.HasMinimalStake(builder, commitment)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("commitment %s: stake %v wei, subscription end %v, has minimal stake %v\n",
+			commitment, stake.Stake, stake.SubscriptionEnd, eligible)
+	}
+	return snap.Verify()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+	"github.com/primevprotocol/primev-contracts/pkg/vesting"
+)
+
+func runVestingReport(args []string) error {
+	fs := newFlagSet("vesting-report")
+	var chain chainFlags
+	chain.registerNode(fs)
+	builders := fs.String("builders", "", "comma separated builder addresses")
+	span := fs.Uint64("span", 1000, "number of recent blocks used to measure the block time")
+	multiples := fs.String("multiples", "1,10", "comma separated deposit sizes as multiples of the minimal stake")
+	fs.Parse(args)
+
+	var addresses []common.Address
+	for _, b := range strings.Split(*builders, ",") {
+		address, err := parseAddress("builder", b)
+		if err != nil {
+			return err
+		}
+		addresses = append(addresses, address)
+	}
+	var sizes []int64
+	for _, m := range strings.Split(*multiples, ",") {
+		v, err := strconv.ParseInt(m, 10, 64)
+		if err != nil || v <= 0 {
+			return fmt.Errorf("invalid multiple %q", m)
+		}
+		sizes = append(sizes, v)
+	}
+
+	client, contract, err := chain.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	caller, err := primev.NewBuilderStakingCaller(contract, client)
+	if err != nil {
+		return err
+	}
+	report, err := vesting.UnitsReport(context.Background(), caller, client, addresses, *span, sizes)
+	if err != nil {
+		return err
+	}
+	for _, a := range report {
+		note := ""
+		if a.Outruns {
+			note = "  OUTRUNS SERVICE"
+		}
+		fmt.Printf("%s deposit %v wei: subscription %v blocks = %v, vests in %v (intended %v), %.1fx faster%s\n",
+			a.Builder, a.Deposit, a.SubscriptionBlocks, a.SubscriptionTime, a.VestingTime, a.IntendedVesting, a.Speedup, note)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// Command primev-auditor checks that the BuilderStaking balance covers every
+// builder's remaining locked funds and writes signed audit reports.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/primevprotocol/primev-contracts/pkg/audit"
+	"github.com/primevprotocol/primev-contracts/pkg/events"
+)
+
+func main() {
+	var (
+		endpoint      = flag.String("rpc", "http://localhost:8545", "node RPC endpoint")
+		contract      = flag.String("contract", "", "BuilderStaking contract address")
+		fromBlock     = flag.Uint64("from-block", 0, "block the contract was deployed at")
+		step          = flag.Uint64("step", events.DefaultStep, "blocks per log query")
+		tolerance     = flag.String("tolerance", "0", "drift in wei tolerated before alerting")
+		every         = flag.Uint64("every", 100, "audit every this many blocks")
+		confirmations = flag.Uint64("confirmations", audit.DefaultConfirmations, "audit this many blocks behind the head")
+		once          = flag.Bool("once", false, "audit once and exit, non-zero if the contract is insolvent")
+		keyfile       = flag.String("keystore", "", "keystore file of the key signing reports")
+		passwordFile  = flag.String("password-file", "", "file containing the keystore password")
+		reportDir     = flag.String("report-dir", "reports", "directory signed reports are written to")
+		verbosity     = flag.Int("verbosity", int(log.LvlInfo), "log level (0-5)")
+	)
+	flag.Parse()
+
+	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(*verbosity), log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
+	tol, ok := math.ParseBig256(*tolerance)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "primev-auditor: invalid tolerance %q\n", *tolerance)
+		os.Exit(2)
+	}
+	cfg := audit.Config{FromBlock: *fromBlock, Step: *step, Tolerance: tol, Every: *every, Confirmations: *confirmations}
+	if err := run(*endpoint, *contract, *keyfile, *passwordFile, *reportDir, *once, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "primev-auditor: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(endpoint, contract, keyfile, passwordFile, reportDir string, once bool, cfg audit.Config) error {
+	if !common.IsHexAddress(contract) {
+		return fmt.Errorf("invalid contract address %q", contract)
+	}
+	keyjson, err := os.ReadFile(keyfile)
+	if err != nil {
+		return err
+	}
+	password, err := os.ReadFile(passwordFile)
+	if err != nil {
+		return err
+	}
+	key, err := keystore.DecryptKey(keyjson, strings.TrimRight(string(password), "\r\n"))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(reportDir, 0o755); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	rpcClient, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+	defer rpcClient.Close()
+	client := ethclient.NewClient(rpcClient)
+
+	// Every report replaces latest.json; reports with drift are also kept
+	// under their block number.
+	write := func(r *audit.Report, name string) {
+		signed, err := audit.Sign(r, key.PrivateKey)
+		if err == nil {
+			err = signed.WriteFile(filepath.Join(reportDir, name))
+		}
+		if err != nil {
+			log.Error("Failed to write audit report", "block", r.Block, "err", err)
+		}
+	}
+	cfg.OnReport = func(r *audit.Report) { write(r, "latest.json") }
+	cfg.OnAlert = func(r *audit.Report) { write(r, fmt.Sprintf("drift-%d.json", r.Block)) }
+
+	auditor, err := audit.New(common.HexToAddress(contract), client, rpcClient, cfg)
+	if err != nil {
+		return err
+	}
+	if once {
+		head, err := client.BlockNumber(ctx)
+		if err != nil {
+			return err
+		}
+		if head < cfg.Confirmations {
+			return fmt.Errorf("chain head %d is below %d confirmations", head, cfg.Confirmations)
+		}
+		report, err := auditor.Audit(ctx, head-cfg.Confirmations)
+		if err != nil {
+			return err
+		}
+		if !report.Solvent {
+			return fmt.Errorf("contract is insolvent at block %d, deficit %v wei", report.Block, report.Deficit)
+		}
+		return nil
+	}
+	log.Info("Starting solvency auditor", "contract", contract, "signer", key.Address, "every", cfg.Every)
+	if err := auditor.Run(ctx); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
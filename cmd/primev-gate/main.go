@@ -0,0 +1,118 @@
+// Command primev-gate is a reverse proxy in front of a builder's bundle
+// endpoint that rejects bundles from commitments without an active stake.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/primevprotocol/primev-contracts/pkg/gate"
+	"github.com/primevprotocol/primev-contracts/pkg/proof"
+	"github.com/primevprotocol/primev-contracts/pkg/stakeview"
+)
+
+func main() {
+	var (
+		listen    = flag.String("listen", ":8080", "address to listen on")
+		target    = flag.String("target", "http://localhost:8645", "builder endpoint to forward to")
+		rpc       = flag.String("rpc", "http://localhost:8545", "node RPC endpoint")
+		contract  = flag.String("contract", "", "BuilderStaking contract address")
+		builder   = flag.String("builder", "", "builder address whose terms are enforced")
+		ttl       = flag.Duration("ttl", gate.DefaultTTL, "how long stake reads are cached")
+		header    = flag.String("header", gate.DefaultCommitmentHeader, "request header carrying the commitment with -require-proof=false")
+		signed    = flag.Bool("require-proof", true, "require signed commitment ownership proofs; false trusts the unauthenticated commitment header")
+		window    = flag.Duration("proof-window", proof.DefaultWindow, "accepted clock difference for proof timestamps")
+		methods   = flag.String("methods", strings.Join(gate.DefaultMethods, ","), "comma separated JSON-RPC methods to gate")
+		view      = flag.Bool("view", false, "answer stake checks from an in-memory view of all stakes instead of cached RPC reads")
+		fromBlock = flag.Uint64("from-block", 0, "block the contract was deployed at, for building the view")
+		verbosity = flag.Int("verbosity", int(log.LvlInfo), "log level (0-5)")
+	)
+	flag.Parse()
+
+	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(*verbosity), log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
+	if err := run(*listen, *target, *rpc, *contract, *builder, *header, *methods, *ttl, *signed, *window, *view, *fromBlock); err != nil {
+		fmt.Fprintf(os.Stderr, "primev-gate: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(listen, target, rpc, contract, builder, header, methods string, ttl time.Duration, signed bool, window time.Duration, view bool, fromBlock uint64) error {
+	if !common.IsHexAddress(contract) {
+		return fmt.Errorf("invalid contract address %q", contract)
+	}
+	if !common.IsHexAddress(builder) {
+		return fmt.Errorf("invalid builder address %q", builder)
+	}
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client, err := ethclient.DialContext(ctx, rpc)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	var checker gate.StakeChecker
+	if view {
+		v, err := stakeview.New(common.HexToAddress(contract), client, stakeview.Config{FromBlock: fromBlock})
+		if err != nil {
+			return err
+		}
+		go v.Run(ctx)
+		log.Info("Building stake view", "from", fromBlock)
+		select {
+		case <-v.Ready():
+		case <-ctx.Done():
+			return nil
+		}
+		checker = v.Checker(common.HexToAddress(builder))
+	} else {
+		checker, err = gate.NewChainChecker(common.HexToAddress(contract), client, common.HexToAddress(builder), ttl)
+		if err != nil {
+			return err
+		}
+	}
+	// Anyone can put a staked commitment into the header, so it is only
+	// trusted when proofs are explicitly turned off, e.g. behind a relay
+	// that authenticates searchers itself.
+	var extractor gate.Extractor = gate.ProofExtractor{Verifier: proof.NewVerifier(common.HexToAddress(builder), window, nil)}
+	if !signed {
+		log.Warn("Commitment ownership proofs disabled, trusting the commitment header", "header", header)
+		extractor = gate.HeaderExtractor{Header: header}
+	}
+	proxy := gate.NewProxy(gate.Config{
+		Target:    targetURL,
+		Checker:   checker,
+		Extractor: extractor,
+		Methods:   strings.Split(methods, ","),
+	})
+
+	server := &http.Server{Addr: listen, Handler: proxy, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	log.Info("Starting bundle gate", "listen", listen, "target", target, "builder", builder, "proofs", signed)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
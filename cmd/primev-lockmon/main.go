@@ -0,0 +1,66 @@
+// Command primev-lockmon monitors builders' time lock counts and warns before
+// withdraw exceeds the block gas limit.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/primevprotocol/primev-contracts/pkg/lockmon"
+)
+
+func main() {
+	var (
+		endpoint  = flag.String("rpc", "http://localhost:8545", "node RPC endpoint")
+		contract  = flag.String("contract", "", "BuilderStaking contract address")
+		builders  = flag.String("builders", "", "comma separated builder addresses to monitor")
+		fraction  = flag.Float64("alert-fraction", lockmon.DefaultAlertFraction, "fraction of the block gas limit at which to alert")
+		every     = flag.Uint64("every", 10, "check every this many blocks")
+		verbosity = flag.Int("verbosity", int(log.LvlInfo), "log level (0-5)")
+	)
+	flag.Parse()
+
+	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(*verbosity), log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
+	if err := run(*endpoint, *contract, *builders, lockmon.Config{AlertFraction: *fraction, Every: *every}); err != nil {
+		fmt.Fprintf(os.Stderr, "primev-lockmon: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(endpoint, contract, builders string, cfg lockmon.Config) error {
+	if !common.IsHexAddress(contract) {
+		return fmt.Errorf("invalid contract address %q", contract)
+	}
+	for _, b := range strings.Split(builders, ",") {
+		if !common.IsHexAddress(b) {
+			return fmt.Errorf("invalid builder address %q", b)
+		}
+		cfg.Builders = append(cfg.Builders, common.HexToAddress(b))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	rpcClient, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+	defer rpcClient.Close()
+	monitor, err := lockmon.New(common.HexToAddress(contract), ethclient.NewClient(rpcClient), rpcClient, cfg)
+	if err != nil {
+		return err
+	}
+	if err := monitor.Run(ctx); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
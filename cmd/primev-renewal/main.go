@@ -0,0 +1,134 @@
+// Command primev-renewal keeps searcher subscriptions alive by renewing them
+// shortly before they expire.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/primevprotocol/primev-contracts/pkg/fees"
+	"github.com/primevprotocol/primev-contracts/pkg/renewal"
+)
+
+type config struct {
+	RPC           string                 `json:"rpc"`
+	Contract      common.Address         `json:"contract"`
+	Keystore      string                 `json:"keystore"`
+	PasswordFile  string                 `json:"passwordFile"`
+	StatePath     string                 `json:"statePath"`
+	Threshold     uint64                 `json:"threshold"`
+	Horizon       uint64                 `json:"horizon"`
+	Budget        *math.HexOrDecimal256  `json:"budget"`
+	UrgentWithin  uint64                 `json:"urgentWithin"`
+	UrgentPercent uint64                 `json:"urgentPercent"`
+	GasMargin     uint64                 `json:"gasMargin"`
+	PollInterval  string                 `json:"pollInterval"`
+	Subscriptions []renewal.Subscription `json:"subscriptions"`
+}
+
+func main() {
+	configPath := flag.String("config", "renewal.json", "configuration file")
+	verbosity := flag.Int("verbosity", int(log.LvlInfo), "log level (0-5)")
+	flag.Parse()
+
+	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(*verbosity), log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
+	if err := run(*configPath); err != nil {
+		fmt.Fprintf(os.Stderr, "primev-renewal: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(configPath string) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return err
+	}
+	if cfg.Budget == nil {
+		return fmt.Errorf("no budget configured")
+	}
+	var interval time.Duration
+	if cfg.PollInterval != "" {
+		if interval, err = time.ParseDuration(cfg.PollInterval); err != nil {
+			return err
+		}
+	}
+	if cfg.UrgentPercent == 0 {
+		cfg.UrgentPercent = 150
+	}
+
+	keyjson, err := os.ReadFile(cfg.Keystore)
+	if err != nil {
+		return err
+	}
+	password, err := os.ReadFile(cfg.PasswordFile)
+	if err != nil {
+		return err
+	}
+	key, err := keystore.DecryptKey(keyjson, strings.TrimRight(string(password), "\r\n"))
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client, err := ethclient.DialContext(ctx, cfg.RPC)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+	opts, err := bind.NewKeyedTransactorWithChainID(key.PrivateKey, chainID)
+	if err != nil {
+		return err
+	}
+
+	strategy := &fees.Percentile{Backend: client}
+	transactor, err := fees.NewTransactor(cfg.Contract, client, fees.Policy{
+		Strategy:     strategy,
+		Urgent:       &fees.Urgent{Strategy: strategy, Percent: cfg.UrgentPercent},
+		UrgentWithin: cfg.UrgentWithin,
+		GasMargin:    cfg.GasMargin,
+	})
+	if err != nil {
+		return err
+	}
+	renewer, err := renewal.New(cfg.Contract, client, transactor, opts, renewal.Config{
+		Subscriptions: cfg.Subscriptions,
+		Threshold:     cfg.Threshold,
+		Horizon:       cfg.Horizon,
+		Budget:        (*big.Int)(cfg.Budget),
+		StatePath:     cfg.StatePath,
+		PollInterval:  interval,
+	})
+	if err != nil {
+		return err
+	}
+	log.Info("Starting renewal daemon", "account", opts.From, "subscriptions", len(cfg.Subscriptions),
+		"spent", renewer.State().Spent, "budget", (*big.Int)(cfg.Budget))
+	if err := renewer.Run(ctx); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
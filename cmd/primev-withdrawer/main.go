@@ -0,0 +1,96 @@
+// Command primev-withdrawer withdraws a builder's vested funds whenever they
+// exceed a multiple of the withdrawal gas cost.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/primevprotocol/primev-contracts/pkg/fees"
+	"github.com/primevprotocol/primev-contracts/pkg/withdrawer"
+)
+
+func main() {
+	var (
+		rpc          = flag.String("rpc", "http://localhost:8545", "node RPC endpoint")
+		contract     = flag.String("contract", "", "BuilderStaking contract address")
+		keyfile      = flag.String("keystore", "", "keystore file of the builder account")
+		passwordFile = flag.String("password-file", "", "file containing the keystore password")
+		interval     = flag.Duration("interval", withdrawer.DefaultInterval, "how often to check for vested funds")
+		multiple     = flag.Uint64("gas-multiple", withdrawer.DefaultGasMultiple, "withdraw once the payout exceeds this multiple of the gas cost")
+		export       = flag.String("export", "withdrawals.jsonl", "file withdrawals are appended to")
+		timeout      = flag.Duration("receipt-timeout", withdrawer.DefaultReceiptTimeout, "how long to wait for a withdrawal before replacing it with higher fees")
+		verbosity    = flag.Int("verbosity", int(log.LvlInfo), "log level (0-5)")
+	)
+	flag.Parse()
+
+	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(*verbosity), log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
+	cfg := withdrawer.Config{Interval: *interval, GasMultiple: *multiple, ExportPath: *export, ReceiptTimeout: *timeout}
+	if err := run(*rpc, *contract, *keyfile, *passwordFile, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "primev-withdrawer: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(rpc, contract, keyfile, passwordFile string, cfg withdrawer.Config) error {
+	if !common.IsHexAddress(contract) {
+		return fmt.Errorf("invalid contract address %q", contract)
+	}
+	keyjson, err := os.ReadFile(keyfile)
+	if err != nil {
+		return err
+	}
+	password, err := os.ReadFile(passwordFile)
+	if err != nil {
+		return err
+	}
+	key, err := keystore.DecryptKey(keyjson, strings.TrimRight(string(password), "\r\n"))
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client, err := ethclient.DialContext(ctx, rpc)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+	opts, err := bind.NewKeyedTransactorWithChainID(key.PrivateKey, chainID)
+	if err != nil {
+		return err
+	}
+	address := common.HexToAddress(contract)
+	transactor, err := fees.NewTransactor(address, client, fees.Policy{Strategy: &fees.Percentile{Backend: client}, GasMargin: 20})
+	if err != nil {
+		return err
+	}
+	w, err := withdrawer.New(address, client, transactor, opts, cfg)
+	if err != nil {
+		return err
+	}
+	log.Info("Starting withdrawal bot", "builder", opts.From, "interval", cfg.Interval, "gasMultiple", cfg.GasMultiple)
+	start := time.Now()
+	err = w.Run(ctx)
+	log.Info("Stopped withdrawal bot", "withdrawn", w.Total(), "uptime", time.Since(start).Round(time.Second))
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
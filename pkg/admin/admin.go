@@ -0,0 +1,161 @@
+// Package admin wraps the BuilderStaking ownership calls with checks that
+// prevent the owner from breaking deposits.
+//
+// deposit forwards the owner's share of every payment with a low-level call
+// and reverts when that call fails, so an owner that rejects ETH blocks all
+// deposits and renouncing ownership sends the share to the zero address.
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+var (
+	// ErrZeroOwner is returned when transferring ownership to the zero address.
+	ErrZeroOwner = errors.New("admin: new owner is the zero address")
+	// ErrSameOwner is returned when the new owner is already the owner.
+	ErrSameOwner = errors.New("admin: new owner is already the owner")
+	// ErrRenounceNotAllowed is returned when renouncing without an override.
+	ErrRenounceNotAllowed = errors.New("admin: renouncing ownership sends deposit fees to the zero address, override required")
+)
+
+// probeValue is the amount sent in the simulated transfer to the new owner.
+var probeValue = big.NewInt(1)
+
+// RecipientError reports a new owner that cannot receive ETH from the contract.
+type RecipientError struct {
+	Owner common.Address
+	Err   error
+}
+
+func (e *RecipientError) Error() string {
+	return fmt.Sprintf("admin: %s cannot receive ETH, deposits would fail: %v", e.Owner, e.Err)
+}
+
+func (e *RecipientError) Unwrap() error { return e.Err }
+
+// Backend is the node connection needed by Admin.
+type Backend interface {
+	bind.ContractBackend
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+}
+
+// Admin performs guarded ownership changes on a BuilderStaking contract.
+type Admin struct {
+	contract *primev.BuilderStaking
+	backend  Backend
+	address  common.Address
+}
+
+// New creates an Admin bound to a deployed BuilderStaking contract.
+func New(address common.Address, backend Backend) (*Admin, error) {
+	contract, err := primev.NewBuilderStaking(address, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &Admin{contract: contract, backend: backend, address: address}, nil
+}
+
+// CheckRecipient simulates the contract sending ETH to owner the way deposit
+// does and returns a RecipientError if the transfer would fail. The contract
+// is used as the sender when it holds funds, otherwise from is used. If
+// neither can pay the probe, a zero-value call from the contract still runs
+// owner's code, but cannot detect an owner that only rejects value.
+func (a *Admin) CheckRecipient(ctx context.Context, from, owner common.Address) error {
+	if owner == (common.Address{}) {
+		return ErrZeroOwner
+	}
+	sender, value := a.address, probeValue
+	funded, err := a.funded(ctx, a.address)
+	if err != nil {
+		return err
+	}
+	if !funded {
+		if funded, err = a.funded(ctx, from); err != nil {
+			return err
+		}
+		if funded {
+			sender = from
+		} else {
+			value = new(big.Int)
+		}
+	}
+	_, err = a.backend.CallContract(ctx, ethereum.CallMsg{From: sender, To: &owner, Value: value}, nil)
+	if err != nil {
+		return &RecipientError{Owner: owner, Err: err}
+	}
+	return nil
+}
+
+// funded reports whether account can pay probeValue.
+func (a *Admin) funded(ctx context.Context, account common.Address) (bool, error) {
+	balance, err := a.backend.BalanceAt(ctx, account, nil)
+	if err != nil {
+		return false, err
+	}
+	return balance.Cmp(probeValue) >= 0, nil
+}
+
+// Check applies the guards of TransferOwnership and RenounceOwnership to a
+// call of method with params that from will make by other means, such as an
+// offline signature or a Safe transaction. Other methods are not checked.
+func (a *Admin) Check(ctx context.Context, from common.Address, method string, params []interface{}, override bool) error {
+	switch method {
+	case "transferOwnership":
+		if len(params) != 1 {
+			return fmt.Errorf("admin: transferOwnership takes 1 argument, got %d", len(params))
+		}
+		newOwner, ok := params[0].(common.Address)
+		if !ok {
+			return fmt.Errorf("admin: new owner %v is not an address", params[0])
+		}
+		return a.checkTransfer(ctx, from, newOwner)
+	case "renounceOwnership":
+		if !override {
+			return ErrRenounceNotAllowed
+		}
+	}
+	return nil
+}
+
+// TransferOwnership transfers ownership after checking that the new owner can
+// receive the owner's share of deposits.
+func (a *Admin) TransferOwnership(opts *bind.TransactOpts, newOwner common.Address) (*types.Transaction, error) {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := a.checkTransfer(ctx, opts.From, newOwner); err != nil {
+		return nil, err
+	}
+	return a.contract.TransferOwnership(opts, newOwner)
+}
+
+func (a *Admin) checkTransfer(ctx context.Context, from, newOwner common.Address) error {
+	owner, err := a.contract.Owner(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return err
+	}
+	if owner == newOwner {
+		return ErrSameOwner
+	}
+	return a.CheckRecipient(ctx, from, newOwner)
+}
+
+// RenounceOwnership renounces ownership. It refuses unless override is set,
+// since afterwards the owner's share of every deposit is burned.
+func (a *Admin) RenounceOwnership(opts *bind.TransactOpts, override bool) (*types.Transaction, error) {
+	if !override {
+		return nil, ErrRenounceNotAllowed
+	}
+	return a.contract.RenounceOwnership(opts)
+}
@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	contract = common.HexToAddress("0xc0")
+	sender   = common.HexToAddress("0x5e")
+	eoa      = common.HexToAddress("0xe0")
+
+	// rejecting reverts every call: PUSH1 0 PUSH1 0 REVERT.
+	rejecting     = common.HexToAddress("0xf1")
+	rejectingCode = []byte{0x60, 0x00, 0x60, 0x00, 0xfd}
+	// nonPayable only reverts calls with value, like a contract without a
+	// payable receive function: CALLVALUE PUSH1 5 JUMPI STOP JUMPDEST
+	// PUSH1 0 PUSH1 0 REVERT.
+	nonPayable     = common.HexToAddress("0xf2")
+	nonPayableCode = []byte{0x34, 0x60, 0x05, 0x57, 0x00, 0x5b, 0x60, 0x00, 0x60, 0x00, 0xfd}
+)
+
+func TestCheckRecipient(t *testing.T) {
+	ether := big.NewInt(params.Ether)
+	tests := []struct {
+		name                   string
+		contractFunds, senders *big.Int
+		owner                  common.Address
+		err                    bool
+	}{
+		{name: "eoa", contractFunds: ether, owner: eoa},
+		{name: "rejecting contract", contractFunds: ether, owner: rejecting, err: true},
+		{name: "non-payable contract", contractFunds: ether, owner: nonPayable, err: true},
+		{name: "sender pays the probe", senders: ether, owner: nonPayable, err: true},
+		{name: "zero-value fallback to eoa", owner: eoa},
+		{name: "zero-value fallback to rejecting contract", owner: rejecting, err: true},
+		// Without funds to send, rejecting value cannot be detected.
+		{name: "zero-value fallback to non-payable contract", owner: nonPayable},
+	}
+	for _, tt := range tests {
+		alloc := core.GenesisAlloc{
+			rejecting:  {Code: rejectingCode, Balance: new(big.Int)},
+			nonPayable: {Code: nonPayableCode, Balance: new(big.Int)},
+		}
+		if tt.contractFunds != nil {
+			alloc[contract] = core.GenesisAccount{Balance: tt.contractFunds}
+		}
+		if tt.senders != nil {
+			alloc[sender] = core.GenesisAccount{Balance: tt.senders}
+		}
+		backend := backends.NewSimulatedBackend(alloc, 10_000_000)
+		a, err := New(contract, backend)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = a.CheckRecipient(context.Background(), sender, tt.owner)
+		var recipientErr *RecipientError
+		if tt.err != errors.As(err, &recipientErr) || (!tt.err && err != nil) {
+			t.Errorf("%s: err = %v, want error %v", tt.name, err, tt.err)
+		}
+		backend.Close()
+	}
+}
+
+func TestCheckRefusals(t *testing.T) {
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{}, 10_000_000)
+	defer backend.Close()
+	a, err := New(contract, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.CheckRecipient(context.Background(), sender, common.Address{}); err != ErrZeroOwner {
+		t.Fatalf("err = %v, want ErrZeroOwner", err)
+	}
+	if err := a.Check(context.Background(), sender, "renounceOwnership", nil, false); err != ErrRenounceNotAllowed {
+		t.Fatalf("renounce without override: err = %v", err)
+	}
+}
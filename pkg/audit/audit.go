@@ -0,0 +1,231 @@
+// Package audit checks the solvency invariant of the BuilderStaking contract:
+// its ETH balance must equal the sum of remainingAmount over every builder's
+// time locks.
+//
+// A deficit means builders can no longer withdraw everything they are owed.
+// withdraw computes releasable amounts from initialAmount and repeated calls
+// can release more than vested, so a deficit is possible without any external
+// cause. A surplus usually means ETH was forced into the contract.
+package audit
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/primevprotocol/primev-contracts/pkg/events"
+	"github.com/primevprotocol/primev-contracts/pkg/heads"
+	"github.com/primevprotocol/primev-contracts/pkg/multicall"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// DefaultConfirmations is the distance from the head at which Run audits, so
+// the audited block is unlikely to be reorged.
+const DefaultConfirmations = 12
+
+// Backend is the node connection needed by Auditor.
+type Backend interface {
+	bind.ContractBackend
+	heads.Reader
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// Config configures an Auditor.
+type Config struct {
+	FromBlock     uint64           // Block the builder scan starts at, usually the deployment block
+	Step          uint64           // Blocks per log query, events.DefaultStep if zero
+	Multicall     multicall.Config // Batching of time lock reads
+	Tolerance     *big.Int         // Drift in wei tolerated before alerting, none if nil
+	Every         uint64           // Audit every this many blocks, every block if zero
+	Confirmations uint64           // Audit this many blocks behind the head
+	PollInterval  time.Duration    // Head polling interval when subscriptions are unsupported
+	OnAlert       func(*Report)    // Called for every report with drift beyond Tolerance
+	OnReport      func(*Report)    // Called for every report
+}
+
+// Auditor audits the BuilderStaking contract at pinned blocks. The set of
+// builders is rebuilt from StakeUpdated and Withdrawal events and extended
+// incrementally on every audit.
+type Auditor struct {
+	cfg      Config
+	backend  Backend
+	address  common.Address
+	filterer *primev.BuilderStakingFilterer
+	reader   *multicall.Reader
+
+	chainID  *big.Int
+	builders map[common.Address]struct{}
+	next     uint64 // First block not yet scanned for builders
+}
+
+// New creates an Auditor for the BuilderStaking contract at address. Time
+// locks are read through Multicall3, falling back to JSON-RPC batches through
+// batcher, or one call at a time if batcher is nil.
+func New(address common.Address, backend Backend, batcher multicall.Batcher, cfg Config) (*Auditor, error) {
+	if cfg.Every == 0 {
+		cfg.Every = 1
+	}
+	if cfg.Tolerance == nil {
+		cfg.Tolerance = new(big.Int)
+	}
+	filterer, err := primev.NewBuilderStakingFilterer(address, backend)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := multicall.NewReader(address, backend, batcher, cfg.Multicall)
+	if err != nil {
+		return nil, err
+	}
+	return &Auditor{
+		cfg:      cfg,
+		backend:  backend,
+		address:  address,
+		filterer: filterer,
+		reader:   reader,
+		builders: make(map[common.Address]struct{}),
+		next:     cfg.FromBlock,
+	}, nil
+}
+
+// Run audits every Every blocks, Confirmations behind the head, until ctx is
+// done.
+func (a *Auditor) Run(ctx context.Context) error {
+	return heads.Watch(ctx, a.backend, a.cfg.PollInterval, func(head *types.Header) error {
+		number := head.Number.Uint64()
+		if number%a.cfg.Every != 0 || number < a.cfg.Confirmations {
+			return nil
+		}
+		if _, err := a.Audit(ctx, number-a.cfg.Confirmations); err != nil {
+			log.Warn("Solvency audit failed", "block", number-a.cfg.Confirmations, "err", err)
+		}
+		return nil
+	})
+}
+
+// Audit checks the invariant at block number and returns the report.
+func (a *Auditor) Audit(ctx context.Context, number uint64) (*Report, error) {
+	if a.chainID == nil {
+		chainID, err := a.backend.ChainID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		a.chainID = chainID
+	}
+	block := new(big.Int).SetUint64(number)
+	header, err := a.backend.HeaderByNumber(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.scan(ctx, number); err != nil {
+		return nil, err
+	}
+	builders, err := a.locks(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := a.backend.BalanceAt(ctx, a.address, block)
+	if err != nil {
+		return nil, err
+	}
+
+	locked := new(big.Int)
+	for _, b := range builders {
+		locked.Add(locked, b.Remaining.ToInt())
+	}
+	drift := new(big.Int).Sub(balance, locked)
+	surplus, deficit := new(big.Int), new(big.Int)
+	if drift.Sign() >= 0 {
+		surplus.Set(drift)
+	} else {
+		deficit.Neg(drift)
+	}
+	report := &Report{
+		Contract:  a.address,
+		ChainID:   (*hexutil.Big)(a.chainID),
+		Block:     number,
+		BlockHash: header.Hash(),
+		Timestamp: header.Time,
+		Balance:   (*hexutil.Big)(balance),
+		Locked:    (*hexutil.Big)(locked),
+		Surplus:   (*hexutil.Big)(surplus),
+		Deficit:   (*hexutil.Big)(deficit),
+		Solvent:   deficit.Sign() == 0,
+		Builders:  builders,
+	}
+
+	if new(big.Int).Abs(drift).Cmp(a.cfg.Tolerance) > 0 {
+		if report.Solvent {
+			log.Warn("Contract balance exceeds locked funds", "block", number, "balance", balance, "locked", locked, "surplus", surplus)
+		} else {
+			log.Error("Contract balance below locked funds", "block", number, "balance", balance, "locked", locked, "deficit", deficit)
+		}
+		if a.cfg.OnAlert != nil {
+			a.cfg.OnAlert(report)
+		}
+	} else {
+		log.Info("Solvency audit passed", "block", number, "balance", balance, "locked", locked, "builders", len(builders))
+	}
+	if a.cfg.OnReport != nil {
+		a.cfg.OnReport(report)
+	}
+	return report, nil
+}
+
+// scan adds builders that deposited or withdrew up to block number.
+func (a *Auditor) scan(ctx context.Context, number uint64) error {
+	if number < a.next {
+		return nil
+	}
+	rng := events.Range{From: a.next, To: number, Step: a.cfg.Step}
+	found := make(map[common.Address]struct{})
+	err := events.StakeUpdated(ctx, a.filterer, rng, func(e *primev.BuilderStakingStakeUpdated) error {
+		found[e.Builder] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = events.Withdrawal(ctx, a.filterer, rng, func(e *primev.BuilderStakingWithdrawal) error {
+		found[e.Builder] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for builder := range found {
+		a.builders[builder] = struct{}{}
+	}
+	a.next = number + 1
+	return nil
+}
+
+// locks sums the remaining amounts of every builder's time locks at block.
+func (a *Auditor) locks(ctx context.Context, block *big.Int) ([]BuilderLocks, error) {
+	builders := make([]common.Address, 0, len(a.builders))
+	for builder := range a.builders {
+		builders = append(builders, builder)
+	}
+	sort.Slice(builders, func(i, j int) bool {
+		return builders[i].Hex() < builders[j].Hex()
+	})
+	locks, err := a.reader.TimeLocks(&bind.CallOpts{Context: ctx, BlockNumber: block}, builders)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]BuilderLocks, len(builders))
+	for i, builder := range builders {
+		remaining := new(big.Int)
+		for _, lock := range locks[i] {
+			remaining.Add(remaining, lock.RemainingAmount)
+		}
+		result[i] = BuilderLocks{Builder: builder, Locks: uint64(len(locks[i])), Remaining: (*hexutil.Big)(remaining)}
+	}
+	return result, nil
+}
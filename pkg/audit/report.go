@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrBadSignature is returned by Verify if the signature does not match the
+// report and signer.
+var ErrBadSignature = errors.New("audit: signature does not match report")
+
+// BuilderLocks is the audited state of one builder's time locks.
+type BuilderLocks struct {
+	Builder   common.Address `json:"builder"`
+	Locks     uint64         `json:"locks"`
+	Remaining *hexutil.Big   `json:"remaining"`
+}
+
+// Report is the result of one solvency audit.
+type Report struct {
+	Contract  common.Address `json:"contract"`
+	ChainID   *hexutil.Big   `json:"chainId"`
+	Block     uint64         `json:"block"`
+	BlockHash common.Hash    `json:"blockHash"`
+	Timestamp uint64         `json:"timestamp"`
+	Balance   *hexutil.Big   `json:"balance"` // Contract ETH balance
+	Locked    *hexutil.Big   `json:"locked"`  // Sum of remainingAmount over all time locks
+	Surplus   *hexutil.Big   `json:"surplus"` // Balance above Locked
+	Deficit   *hexutil.Big   `json:"deficit"` // Locked above Balance
+	Solvent   bool           `json:"solvent"`
+	Builders  []BuilderLocks `json:"builders"`
+}
+
+// SignedReport is a Report signed by the auditor's key. The signature is an
+// EIP-191 personal signature over the JSON encoding of the report, so it can
+// be checked with any wallet tooling.
+type SignedReport struct {
+	Report    *Report        `json:"report"`
+	Signer    common.Address `json:"signer"`
+	Signature hexutil.Bytes  `json:"signature"`
+}
+
+// Sign signs r with key.
+func Sign(r *Report, key *ecdsa.PrivateKey) (*SignedReport, error) {
+	digest, err := r.digest()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(digest, key)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return &SignedReport{
+		Report:    r,
+		Signer:    crypto.PubkeyToAddress(key.PublicKey),
+		Signature: sig,
+	}, nil
+}
+
+// Verify checks that the report was signed by Signer.
+func (s *SignedReport) Verify() error {
+	if s.Report == nil || len(s.Signature) != crypto.SignatureLength {
+		return ErrBadSignature
+	}
+	digest, err := s.Report.digest()
+	if err != nil {
+		return err
+	}
+	sig := make([]byte, len(s.Signature))
+	copy(sig, s.Signature)
+	sig[crypto.RecoveryIDOffset] -= 27
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil || crypto.PubkeyToAddress(*pub) != s.Signer {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// WriteFile writes the signed report as indented JSON.
+func (s *SignedReport) WriteFile(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func (r *Report) digest() ([]byte, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return accounts.TextHash(data), nil
+}
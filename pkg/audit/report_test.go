@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func testReport() *Report {
+	return &Report{
+		Contract:  common.HexToAddress("0xc"),
+		ChainID:   (*hexutil.Big)(big.NewInt(1)),
+		Block:     100,
+		BlockHash: common.HexToHash("0x64"),
+		Timestamp: 1200,
+		Balance:   (*hexutil.Big)(big.NewInt(150)),
+		Locked:    (*hexutil.Big)(big.NewInt(100)),
+		Surplus:   (*hexutil.Big)(big.NewInt(50)),
+		Deficit:   (*hexutil.Big)(new(big.Int)),
+		Solvent:   true,
+		Builders:  []BuilderLocks{{Builder: common.HexToAddress("0xb"), Locks: 2, Remaining: (*hexutil.Big)(big.NewInt(100))}},
+	}
+}
+
+func TestSignRoundTrip(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signed, err := Sign(testReport(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.Signer != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("signer %s, want key address", signed.Signer)
+	}
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := signed.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var read SignedReport
+	if err := json.Unmarshal(data, &read); err != nil {
+		t.Fatal(err)
+	}
+	if err := read.Verify(); err != nil {
+		t.Errorf("verify after reading back: %v", err)
+	}
+
+	// The signature is a plain personal_sign over the compact report JSON.
+	payload, err := json.Marshal(read.Report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := append([]byte(nil), read.Signature...)
+	if sig[crypto.RecoveryIDOffset] != 27 && sig[crypto.RecoveryIDOffset] != 28 {
+		t.Errorf("v = %d, want 27 or 28", sig[crypto.RecoveryIDOffset])
+	}
+	sig[crypto.RecoveryIDOffset] -= 27
+	pub, err := crypto.SigToPub(accounts.TextHash(payload), sig)
+	if err != nil || crypto.PubkeyToAddress(*pub) != signed.Signer {
+		t.Errorf("personal signature recovers %v (%v), want %s", pub, err, signed.Signer)
+	}
+}
+
+func TestVerifyTampered(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	tests := []struct {
+		name   string
+		tamper func(s *SignedReport)
+	}{
+		{"balance", func(s *SignedReport) { s.Report.Balance = (*hexutil.Big)(big.NewInt(151)) }},
+		{"solvent", func(s *SignedReport) { s.Report.Solvent = false }},
+		{"builder", func(s *SignedReport) { s.Report.Builders[0].Locks = 3 }},
+		{"signer", func(s *SignedReport) { s.Signer = crypto.PubkeyToAddress(other.PublicKey) }},
+		{"signature", func(s *SignedReport) { s.Signature[0] ^= 1 }},
+		{"recovery id", func(s *SignedReport) { s.Signature[crypto.RecoveryIDOffset] ^= 1 }},
+		{"short signature", func(s *SignedReport) { s.Signature = s.Signature[:64] }},
+		{"missing report", func(s *SignedReport) { s.Report = nil }},
+	}
+	for _, tt := range tests {
+		signed, err := Sign(testReport(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt.tamper(signed)
+		if err := signed.Verify(); err != ErrBadSignature {
+			t.Errorf("%s: verify %v, want %v", tt.name, err, ErrBadSignature)
+		}
+	}
+}
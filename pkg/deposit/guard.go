@@ -0,0 +1,269 @@
+// Package deposit protects deposits against builders changing their terms
+// between the moment a searcher decides to deposit and the moment the deposit
+// is included.
+package deposit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/primevprotocol/primev-contracts/pkg/events"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+	"github.com/primevprotocol/primev-contracts/pkg/stakemath"
+	"github.com/primevprotocol/primev-contracts/pkg/terms"
+)
+
+var (
+	// ErrSubscriptionEndTooLow is returned when the deposit would not extend the
+	// subscription to the pinned minimum end block.
+	ErrSubscriptionEndTooLow = errors.New("deposit: subscription end below expected minimum")
+	// ErrNoStakeUpdated is returned when an included deposit emitted no
+	// matching StakeUpdated event.
+	ErrNoStakeUpdated = errors.New("deposit: no StakeUpdated event in receipt")
+	// ErrInvalidExpectation is returned when the pinned terms cannot price a
+	// deposit.
+	ErrInvalidExpectation = errors.New("deposit: invalid expectation")
+)
+
+// TermsChangedError is returned when the builder terms differ from the
+// pinned ones.
+type TermsChangedError struct {
+	Expected terms.Terms
+	Actual   terms.Terms
+}
+
+func (e *TermsChangedError) Error() string {
+	return fmt.Sprintf("deposit: builder terms changed: expected stake %v period %v, got stake %v period %v",
+		e.Expected.MinimalStake, e.Expected.MinimalSubscriptionPeriod,
+		e.Actual.MinimalStake, e.Actual.MinimalSubscriptionPeriod)
+}
+
+// OutcomeError is returned by Verify when the included deposit produced a
+// different subscriptionEnd than the pinned terms imply.
+type OutcomeError struct {
+	Expected *big.Int
+	Actual   *big.Int
+}
+
+func (e *OutcomeError) Error() string {
+	return fmt.Sprintf("deposit: subscription end %v differs from %v expected under pinned terms", e.Actual, e.Expected)
+}
+
+// Expectation pins what a deposit is supposed to buy.
+type Expectation struct {
+	Terms              terms.Terms // Builder terms the deposit was priced with
+	MinSubscriptionEnd *big.Int    // Lowest acceptable subscriptionEnd after the deposit
+}
+
+// validate rejects expectations whose terms every deposit would revert on.
+func (exp *Expectation) validate() error {
+	if exp == nil {
+		return fmt.Errorf("%w: none given", ErrInvalidExpectation)
+	}
+	r := terms.Validate(exp.Terms)
+	if !r.HasErrors() {
+		return nil
+	}
+	var msgs []string
+	for _, issue := range r.Issues {
+		if issue.Severity == terms.Error {
+			msgs = append(msgs, issue.Message)
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidExpectation, strings.Join(msgs, "; "))
+}
+
+// Sender sends deposit transactions. It is implemented by the generated
+// BuilderStakingTransactor and by fees.Transactor.
+type Sender interface {
+	Deposit(opts *bind.TransactOpts, builder common.Address, commitment [32]byte) (*types.Transaction, error)
+}
+
+// Backend is the node connection needed by Guard.
+type Backend interface {
+	bind.ContractBackend
+	bind.PendingContractCaller
+	bind.DeployBackend
+}
+
+// Guard sends deposits only if the builder terms match the pinned ones and
+// verifies the outcome after inclusion.
+//
+// The contract offers no atomic protection, so a term change in the same block
+// can still slip through; Verify detects that case after the fact.
+type Guard struct {
+	contract *primev.BuilderStaking
+	backend  Backend
+	sender   Sender
+	address  common.Address
+	abi      *abi.ABI
+}
+
+// NewGuard creates a Guard for the BuilderStaking contract at address. If
+// sender is nil the generated transactor is used.
+func NewGuard(address common.Address, backend Backend, sender Sender) (*Guard, error) {
+	contract, err := primev.NewBuilderStaking(address, backend)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := primev.BuilderStakingMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	if sender == nil {
+		sender = &contract.BuilderStakingTransactor
+	}
+	return &Guard{contract: contract, backend: backend, sender: sender, address: address, abi: parsed}, nil
+}
+
+// Preview checks the pending state against exp and returns the
+// subscriptionEnd the deposit of value wei is expected to produce.
+func (g *Guard) Preview(ctx context.Context, from common.Address, value *big.Int, builder common.Address, commitment [32]byte, exp *Expectation) (*big.Int, error) {
+	if err := exp.validate(); err != nil {
+		return nil, err
+	}
+	pending := &bind.CallOpts{Context: ctx, Pending: true}
+	info, err := g.contract.Builders(pending, builder)
+	if err != nil {
+		return nil, err
+	}
+	actual := terms.Terms{MinimalStake: info.MinimalStake, MinimalSubscriptionPeriod: info.MinimalSubscriptionPeriod}
+	if actual.MinimalStake.Cmp(exp.Terms.MinimalStake) != 0 ||
+		actual.MinimalSubscriptionPeriod.Cmp(exp.Terms.MinimalSubscriptionPeriod) != 0 {
+		return nil, &TermsChangedError{Expected: exp.Terms, Actual: actual}
+	}
+
+	stake, err := g.contract.Stakes(pending, commitment)
+	if err != nil {
+		return nil, err
+	}
+	head, err := g.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	block := new(big.Int).Add(head.Number, common.Big1)
+	end := subscriptionEnd(stake.SubscriptionEnd, block, actual, value)
+	if exp.MinSubscriptionEnd != nil && end.Cmp(exp.MinSubscriptionEnd) < 0 {
+		return nil, fmt.Errorf("%w: expected at least %v, deposit gives %v", ErrSubscriptionEndTooLow, exp.MinSubscriptionEnd, end)
+	}
+
+	input, err := g.abi.Pack("deposit", builder, commitment)
+	if err != nil {
+		return nil, err
+	}
+	_, err = g.backend.PendingCallContract(ctx, ethereum.CallMsg{From: from, To: &g.address, Value: value, Data: input})
+	if err != nil {
+		return nil, fmt.Errorf("deposit: simulation against pending block failed: %w", err)
+	}
+	return end, nil
+}
+
+// Deposit previews the deposit of opts.Value against the pending block and
+// sends it only if it matches exp.
+func (g *Guard) Deposit(opts *bind.TransactOpts, builder common.Address, commitment [32]byte, exp *Expectation) (*types.Transaction, error) {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	value := opts.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	if _, err := g.Preview(ctx, opts.From, value, builder, commitment, exp); err != nil {
+		return nil, err
+	}
+	return g.sender.Deposit(opts, builder, commitment)
+}
+
+// Verify waits for tx to be mined and checks its StakeUpdated event against
+// exp. The event is returned together with any mismatch error.
+func (g *Guard) Verify(ctx context.Context, tx *types.Transaction, builder common.Address, commitment [32]byte, exp *Expectation) (*primev.BuilderStakingStakeUpdated, error) {
+	receipt, err := bind.WaitMined(ctx, g.backend, tx)
+	if err != nil {
+		return nil, err
+	}
+	return g.VerifyReceipt(ctx, receipt, tx.Value(), builder, commitment, exp)
+}
+
+// VerifyReceipt is Verify for a deposit of value wei that is already mined.
+func (g *Guard) VerifyReceipt(ctx context.Context, receipt *types.Receipt, value *big.Int, builder common.Address, commitment [32]byte, exp *Expectation) (*primev.BuilderStakingStakeUpdated, error) {
+	if err := exp.validate(); err != nil {
+		return nil, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("deposit: transaction %s reverted", receipt.TxHash)
+	}
+	var event *primev.BuilderStakingStakeUpdated
+	for _, log := range receipt.Logs {
+		if log.Address != g.address {
+			continue
+		}
+		parsed, err := g.contract.ParseStakeUpdated(*log)
+		if err != nil {
+			continue
+		}
+		if parsed.Builder == builder && parsed.Commitment == commitment {
+			event = parsed
+		}
+	}
+	if event == nil {
+		return nil, ErrNoStakeUpdated
+	}
+	if exp.MinSubscriptionEnd != nil && event.SubscriptionEnd.Cmp(exp.MinSubscriptionEnd) < 0 {
+		return event, fmt.Errorf("%w: expected at least %v, got %v", ErrSubscriptionEndTooLow, exp.MinSubscriptionEnd, event.SubscriptionEnd)
+	}
+
+	// Recompute the outcome from the subscription end just before the
+	// deposit with the pinned terms; a difference means the deposit was
+	// priced differently.
+	prev, err := g.previousEnd(ctx, event)
+	if err != nil {
+		return event, err
+	}
+	want := subscriptionEnd(prev, receipt.BlockNumber, exp.Terms, value)
+	if event.SubscriptionEnd.Cmp(want) != 0 {
+		return event, &OutcomeError{Expected: want, Actual: event.SubscriptionEnd}
+	}
+	return event, nil
+}
+
+// previousEnd returns the commitment's subscription end right before event:
+// that of the last earlier StakeUpdated for the commitment in the same block,
+// as other deposits may precede it there, or else the end at the block before.
+func (g *Guard) previousEnd(ctx context.Context, event *primev.BuilderStakingStakeUpdated) (*big.Int, error) {
+	var end *big.Int
+	block := event.Raw.BlockNumber
+	err := events.StakeUpdated(ctx, &g.contract.BuilderStakingFilterer, events.Range{From: block, To: block}, func(e *primev.BuilderStakingStakeUpdated) error {
+		if e.Raw.Address == g.address && e.Commitment == event.Commitment && e.Raw.Index < event.Raw.Index {
+			end = e.SubscriptionEnd
+		}
+		return nil
+	})
+	if err != nil || end != nil {
+		return end, err
+	}
+	before := new(big.Int).SetUint64(block - 1)
+	stake, err := g.contract.Stakes(&bind.CallOpts{Context: ctx, BlockNumber: before}, event.Commitment)
+	if err != nil {
+		return nil, err
+	}
+	return stake.SubscriptionEnd, nil
+}
+
+// subscriptionEnd mirrors how deposit extends a subscription ending at end
+// when included in block.
+func subscriptionEnd(end, block *big.Int, t terms.Terms, value *big.Int) *big.Int {
+	period := stakemath.SubscriptionPeriod(t.MinimalStake, t.MinimalSubscriptionPeriod, value)
+	if end.Cmp(block) > 0 {
+		return new(big.Int).Add(end, period)
+	}
+	return new(big.Int).Add(block, period)
+}
@@ -0,0 +1,206 @@
+package deposit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+	"github.com/primevprotocol/primev-contracts/pkg/terms"
+)
+
+var contractABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(primev.BuilderStakingMetaData.ABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+var (
+	contract   = common.HexToAddress("0xc0")
+	builder    = common.HexToAddress("0xb1")
+	commitment = common.HexToHash("0x01")
+)
+
+// chain answers builders and stakes reads of a single builder and
+// commitment, and serves contract logs. Methods the guard does not use are
+// left to the nil embedded Backend.
+type chain struct {
+	Backend
+	head         uint64
+	minimalStake int64
+	period       int64
+	end          int64 // Subscription end before the deposit
+	reverts      bool  // Whether the simulated deposit reverts
+	logs         []types.Log
+}
+
+func (c *chain) call(call ethereum.CallMsg) ([]byte, error) {
+	method, err := contractABI.MethodById(call.Data[:4])
+	if err != nil {
+		return nil, err
+	}
+	switch method.Name {
+	case "builders":
+		return method.Outputs.Pack(big.NewInt(c.minimalStake), big.NewInt(c.period))
+	case "stakes":
+		return method.Outputs.Pack(big.NewInt(c.end), big.NewInt(c.minimalStake))
+	case "deposit":
+		if c.reverts {
+			return nil, errors.New("execution reverted")
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unexpected call to %s", method.Name)
+}
+
+func (c *chain) CallContract(ctx context.Context, call ethereum.CallMsg, number *big.Int) ([]byte, error) {
+	return c.call(call)
+}
+
+func (c *chain) PendingCallContract(ctx context.Context, call ethereum.CallMsg) ([]byte, error) {
+	return c.call(call)
+}
+
+func (c *chain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: new(big.Int).SetUint64(c.head)}, nil
+}
+
+func (c *chain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	var logs []types.Log
+	for _, l := range c.logs {
+		if l.Topics[0] == q.Topics[0][0] && l.BlockNumber >= q.FromBlock.Uint64() && l.BlockNumber <= q.ToBlock.Uint64() {
+			logs = append(logs, l)
+		}
+	}
+	return logs, nil
+}
+
+// stakeUpdated adds a StakeUpdated log for the commitment at block and
+// returns it.
+func (c *chain) stakeUpdated(block uint64, stake, end int64) types.Log {
+	ev := contractABI.Events["StakeUpdated"]
+	data, err := ev.Inputs.Pack(builder, commitment, big.NewInt(stake), big.NewInt(end))
+	if err != nil {
+		panic(err)
+	}
+	l := types.Log{Address: contract, Topics: []common.Hash{ev.ID}, Data: data, BlockNumber: block, Index: uint(len(c.logs))}
+	c.logs = append(c.logs, l)
+	return l
+}
+
+func expectation(stake, period, minEnd int64) *Expectation {
+	exp := &Expectation{Terms: terms.Terms{MinimalStake: big.NewInt(stake), MinimalSubscriptionPeriod: big.NewInt(period)}}
+	if minEnd > 0 {
+		exp.MinSubscriptionEnd = big.NewInt(minEnd)
+	}
+	return exp
+}
+
+func TestPreview(t *testing.T) {
+	tests := []struct {
+		name string
+		end  int64 // Subscription end before the deposit
+		exp  *Expectation
+		want int64
+		err  interface{}
+	}{
+		// A deposit of the minimal stake buys the minimal period.
+		{name: "ended subscription", end: 50, exp: expectation(10, 100, 0), want: 201},
+		{name: "active subscription", end: 150, exp: expectation(10, 100, 250), want: 250},
+		{name: "terms changed", exp: expectation(5, 100, 0), err: new(*TermsChangedError)},
+		{name: "end too low", end: 150, exp: expectation(10, 100, 251), err: ErrSubscriptionEndTooLow},
+		{name: "no minimal stake", exp: &Expectation{Terms: terms.Terms{MinimalSubscriptionPeriod: big.NewInt(100)}}, err: ErrInvalidExpectation},
+		{name: "no expectation", err: ErrInvalidExpectation},
+	}
+	for _, tt := range tests {
+		c := &chain{head: 100, minimalStake: 10, period: 100, end: tt.end}
+		g, err := NewGuard(contract, c, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		end, err := g.Preview(context.Background(), common.Address{}, big.NewInt(10), builder, commitment, tt.exp)
+		if !matches(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if tt.err == nil && end.Int64() != tt.want {
+			t.Errorf("%s: end %v, want %d", tt.name, end, tt.want)
+		}
+	}
+}
+
+func TestPreviewSimulationFails(t *testing.T) {
+	c := &chain{head: 100, minimalStake: 10, period: 100, reverts: true}
+	g, err := NewGuard(contract, c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Preview(context.Background(), common.Address{}, big.NewInt(10), builder, commitment, expectation(10, 100, 0)); err == nil {
+		t.Fatal("reverting deposit previewed without error")
+	}
+}
+
+func TestVerifyReceipt(t *testing.T) {
+	tests := []struct {
+		name    string
+		earlier []int64 // Ends of earlier deposits of the commitment in the block
+		end     int64   // End reported by the verified deposit
+		exp     *Expectation
+		err     interface{}
+	}{
+		{name: "as priced", end: 250, exp: expectation(10, 100, 0)},
+		// The deposit was priced with a minimal stake of 5, buying 200
+		// blocks instead of 100.
+		{name: "priced differently", end: 350, exp: expectation(10, 100, 0), err: new(*OutcomeError)},
+		{name: "end too low", end: 250, exp: expectation(10, 100, 300), err: ErrSubscriptionEndTooLow},
+		// Another deposit earlier in the block extended the subscription
+		// to 400 first.
+		{name: "earlier deposit in block", earlier: []int64{300, 400}, end: 500, exp: expectation(10, 100, 0)},
+		{name: "earlier deposit ignored", earlier: []int64{400}, end: 250, exp: expectation(10, 100, 0), err: new(*OutcomeError)},
+		{name: "no minimal period", end: 250, exp: &Expectation{Terms: terms.Terms{MinimalStake: big.NewInt(10)}}, err: ErrInvalidExpectation},
+	}
+	for _, tt := range tests {
+		c := &chain{head: 101, minimalStake: 10, period: 100, end: 150}
+		c.stakeUpdated(99, 10, 150)
+		for _, end := range tt.earlier {
+			c.stakeUpdated(101, 10, end)
+		}
+		receipt := &types.Receipt{
+			Status:      types.ReceiptStatusSuccessful,
+			BlockNumber: big.NewInt(101),
+			Logs:        []*types.Log{ptr(c.stakeUpdated(101, 20, tt.end))},
+		}
+		g, err := NewGuard(contract, c, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = g.VerifyReceipt(context.Background(), receipt, big.NewInt(10), builder, commitment, tt.exp)
+		if !matches(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func ptr(l types.Log) *types.Log { return &l }
+
+// matches reports whether err is want, an error value or a pointer to an
+// error type, or nil if want is nil.
+func matches(err error, want interface{}) bool {
+	switch want := want.(type) {
+	case nil:
+		return err == nil
+	case error:
+		return errors.Is(err, want)
+	default:
+		return err != nil && errors.As(err, want)
+	}
+}
@@ -0,0 +1,277 @@
+// Package directory is a registry of every builder that called UpdateBuilder,
+// built from BuilderUpdated and StakeUpdated events, for searchers comparing
+// builders.
+package directory
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/primevprotocol/primev-contracts/pkg/events"
+	"github.com/primevprotocol/primev-contracts/pkg/heads"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// Backend is the node connection needed by Directory.
+type Backend interface {
+	bind.ContractFilterer
+	heads.Reader
+}
+
+// Config configures a Directory.
+type Config struct {
+	FromBlock    uint64        // Block event replay starts at, must not be after the deployment block
+	Step         uint64        // Blocks per log query, events.DefaultStep if zero
+	PollInterval time.Duration // Head polling interval when subscriptions are unsupported
+}
+
+// Terms are builder terms set by one BuilderUpdated event.
+type Terms struct {
+	Block                     uint64       `json:"block"`
+	TxHash                    common.Hash  `json:"txHash"`
+	MinimalStake              *hexutil.Big `json:"minimalStake"`
+	MinimalSubscriptionPeriod *hexutil.Big `json:"minimalSubscriptionPeriod"`
+	// PricePerBlock is the nominal price in wei per block, MinimalStake /
+	// MinimalSubscriptionPeriod rounded down, nil if the period is zero.
+	PricePerBlock *hexutil.Big `json:"pricePerBlock"`
+}
+
+// Entry describes a builder as of Block.
+type Entry struct {
+	Builder       common.Address `json:"builder"`
+	Block         uint64         `json:"block"` // Block the counts are evaluated at
+	Current       Terms          `json:"current"`
+	History       []Terms        `json:"history"` // Oldest first, ending with Current
+	LastChange    uint64         `json:"lastChange"`
+	Subscribers   int            `json:"subscribers"`   // Commitments that ever deposited
+	Active        int            `json:"active"`        // Last deposited to the builder, subscription end after Block
+	Eligible      int            `json:"eligible"`      // Active and holding the current minimal stake
+	TotalDeposits *hexutil.Big   `json:"totalDeposits"` // Sum of all deposits to the builder, in wei
+}
+
+// stake is the latest StakeUpdated state of a commitment. The contract keys
+// stakes by commitment only, so a deposit to another builder carries the
+// stake over.
+type stake struct {
+	builder                common.Address
+	stake, subscriptionEnd *big.Int
+}
+
+type builder struct {
+	history     []Terms
+	subscribers map[common.Hash]bool // Commitments that deposited to the builder
+	deposits    *big.Int
+}
+
+// Directory holds the builder registry. Its methods are safe for concurrent
+// use.
+type Directory struct {
+	cfg      Config
+	backend  Backend
+	filterer *primev.BuilderStakingFilterer
+
+	mu       sync.RWMutex
+	synced   uint64 // Last replayed block
+	builders map[common.Address]*builder
+	stakes   map[common.Hash]stake
+}
+
+// New creates a Directory for the BuilderStaking contract at address.
+func New(address common.Address, backend Backend, cfg Config) (*Directory, error) {
+	filterer, err := primev.NewBuilderStakingFilterer(address, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &Directory{
+		cfg:      cfg,
+		backend:  backend,
+		filterer: filterer,
+		builders: make(map[common.Address]*builder),
+		stakes:   make(map[common.Hash]stake),
+	}, nil
+}
+
+// Sync replays events up to the latest block.
+func (d *Directory) Sync(ctx context.Context) error {
+	head, err := d.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	return d.sync(ctx, head.Number.Uint64())
+}
+
+// Run keeps the directory at the chain head until ctx is done. Events are
+// read at the head, so an update reorged out stays in the history until the
+// directory is rebuilt.
+func (d *Directory) Run(ctx context.Context) error {
+	return heads.Watch(ctx, d.backend, d.cfg.PollInterval, func(head *types.Header) error {
+		if err := d.sync(ctx, head.Number.Uint64()); err != nil {
+			log.Warn("Failed to update builder directory", "block", head.Number, "err", err)
+		}
+		return nil
+	})
+}
+
+func (d *Directory) sync(ctx context.Context, block uint64) error {
+	d.mu.RLock()
+	from := d.synced + 1
+	if d.synced == 0 {
+		from = d.cfg.FromBlock
+	}
+	d.mu.RUnlock()
+	if from > block {
+		return nil
+	}
+	// Events are collected first so readers never see a half applied range.
+	var terms []*primev.BuilderStakingBuilderUpdated
+	var stakes []*primev.BuilderStakingStakeUpdated
+	rng := events.Range{From: from, To: block, Step: d.cfg.Step}
+	err := events.BuilderUpdated(ctx, d.filterer, rng, func(e *primev.BuilderStakingBuilderUpdated) error {
+		terms = append(terms, e)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = events.StakeUpdated(ctx, d.filterer, rng, func(e *primev.BuilderStakingStakeUpdated) error {
+		stakes = append(stakes, e)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, e := range terms {
+		b := d.builder(e.Builder)
+		b.history = append(b.history, Terms{
+			Block:                     e.Raw.BlockNumber,
+			TxHash:                    e.Raw.TxHash,
+			MinimalStake:              (*hexutil.Big)(e.MinimalStake),
+			MinimalSubscriptionPeriod: (*hexutil.Big)(e.MinimalSubsriptionPeriod),
+			PricePerBlock:             price(e.MinimalStake, e.MinimalSubsriptionPeriod),
+		})
+	}
+	for _, e := range stakes {
+		// Stake is cumulative across builders, so each deposit is the
+		// increase over the commitment's previous stake.
+		c := common.Hash(e.Commitment)
+		amount := new(big.Int).Set(e.Stake)
+		if prev, ok := d.stakes[c]; ok {
+			amount.Sub(amount, prev.stake)
+		}
+		b := d.builder(e.Builder)
+		b.subscribers[c] = true
+		if amount.Sign() > 0 {
+			b.deposits.Add(b.deposits, amount)
+		}
+		d.stakes[c] = stake{builder: e.Builder, stake: e.Stake, subscriptionEnd: e.SubscriptionEnd}
+	}
+	d.synced = block
+	return nil
+}
+
+// builder returns the record of addr, creating it. Deposits can only be made
+// to builders with terms, so records created for StakeUpdated events always
+// receive terms as well.
+func (d *Directory) builder(addr common.Address) *builder {
+	b, ok := d.builders[addr]
+	if !ok {
+		b = &builder{subscribers: make(map[common.Hash]bool), deposits: new(big.Int)}
+		d.builders[addr] = b
+	}
+	return b
+}
+
+// Block returns the last replayed block.
+func (d *Directory) Block() uint64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.synced
+}
+
+// Builder returns the entry of addr, or false if it never set terms.
+func (d *Directory) Builder(addr common.Address) (Entry, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	b, ok := d.builders[addr]
+	if !ok || len(b.history) == 0 {
+		return Entry{}, false
+	}
+	return d.entry(addr, b), true
+}
+
+// Builders returns all entries ordered by address.
+func (d *Directory) Builders() []Entry {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	entries := make([]Entry, 0, len(d.builders))
+	for addr, b := range d.builders {
+		if len(b.history) > 0 {
+			entries = append(entries, d.entry(addr, b))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].Builder[:], entries[j].Builder[:]) < 0
+	})
+	return entries
+}
+
+// TermsAt returns the terms of addr in effect after block.
+func (d *Directory) TermsAt(addr common.Address, block uint64) (Terms, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	b, ok := d.builders[addr]
+	if !ok {
+		return Terms{}, false
+	}
+	i := sort.Search(len(b.history), func(i int) bool { return b.history[i].Block > block })
+	if i == 0 {
+		return Terms{}, false
+	}
+	return b.history[i-1], true
+}
+
+func (d *Directory) entry(addr common.Address, b *builder) Entry {
+	current := b.history[len(b.history)-1]
+	e := Entry{
+		Builder:     addr,
+		Block:       d.synced,
+		Current:     current,
+		History:     append([]Terms(nil), b.history...),
+		LastChange:  current.Block,
+		Subscribers: len(b.subscribers),
+		// Copied so callers cannot change the running total.
+		TotalDeposits: (*hexutil.Big)(new(big.Int).Set(b.deposits)),
+	}
+	head := new(big.Int).SetUint64(d.synced)
+	minimal := current.MinimalStake.ToInt()
+	for _, s := range d.stakes {
+		// A commitment counts for the builder it last deposited to.
+		if s.builder != addr || s.subscriptionEnd.Cmp(head) <= 0 {
+			continue
+		}
+		e.Active++
+		if minimal.Sign() > 0 && s.stake.Cmp(minimal) >= 0 {
+			e.Eligible++
+		}
+	}
+	return e
+}
+
+func price(stake, period *big.Int) *hexutil.Big {
+	if period.Sign() == 0 {
+		return nil
+	}
+	return (*hexutil.Big)(new(big.Int).Div(stake, period))
+}
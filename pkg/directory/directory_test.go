@@ -0,0 +1,123 @@
+package directory
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+var contractABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(primev.BuilderStakingMetaData.ABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// chain serves a fixed list of contract logs.
+type chain struct {
+	head uint64
+	logs []types.Log
+}
+
+func (c *chain) emit(block uint64, name string, args ...interface{}) {
+	ev := contractABI.Events[name]
+	data, err := ev.Inputs.Pack(args...)
+	if err != nil {
+		panic(err)
+	}
+	c.logs = append(c.logs, types.Log{
+		Topics:      []common.Hash{ev.ID},
+		Data:        data,
+		BlockNumber: block,
+		Index:       uint(len(c.logs)),
+	})
+}
+
+func (c *chain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	var logs []types.Log
+	for _, l := range c.logs {
+		if l.Topics[0] != q.Topics[0][0] || l.BlockNumber < q.FromBlock.Uint64() || l.BlockNumber > q.ToBlock.Uint64() {
+			continue
+		}
+		logs = append(logs, l)
+	}
+	return logs, nil
+}
+
+func (c *chain) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	}), nil
+}
+
+func (c *chain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: new(big.Int).SetUint64(c.head)}, nil
+}
+
+var (
+	builderA = common.HexToAddress("0x000000000000000000000000000000000000000a")
+	builderB = common.HexToAddress("0x000000000000000000000000000000000000000b")
+	first    = common.HexToHash("0x01")
+	second   = common.HexToHash("0x02")
+)
+
+func TestDepositsAcrossBuilders(t *testing.T) {
+	c := &chain{head: 100}
+	c.emit(1, "BuilderUpdated", builderA, big.NewInt(10), big.NewInt(100))
+	c.emit(1, "BuilderUpdated", builderB, big.NewInt(20), big.NewInt(100))
+	// The first commitment deposits 10 to A and then 30 to B. Stakes are
+	// keyed by commitment only, so its stake grows to 40 and moves to B.
+	c.emit(2, "StakeUpdated", builderA, first, big.NewInt(10), big.NewInt(102))
+	c.emit(3, "StakeUpdated", builderB, first, big.NewInt(40), big.NewInt(300))
+	c.emit(4, "StakeUpdated", builderA, second, big.NewInt(15), big.NewInt(200))
+	c.emit(5, "BuilderUpdated", builderA, big.NewInt(16), big.NewInt(100))
+
+	d, err := New(common.Address{}, c, Config{Step: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		builder                                common.Address
+		subscribers, active, eligible, changes int
+		deposits                               int64
+	}{
+		// The second commitment is active with A but below the raised
+		// minimal stake.
+		{builderA, 2, 1, 0, 2, 25},
+		{builderB, 1, 1, 1, 1, 30},
+	}
+	for _, tt := range tests {
+		e, ok := d.Builder(tt.builder)
+		if !ok {
+			t.Fatalf("builder %s missing", tt.builder)
+		}
+		if e.Subscribers != tt.subscribers || e.Active != tt.active || e.Eligible != tt.eligible || len(e.History) != tt.changes {
+			t.Errorf("builder %s: %d subscribers, %d active, %d eligible, %d changes; want %d, %d, %d, %d",
+				tt.builder, e.Subscribers, e.Active, e.Eligible, len(e.History), tt.subscribers, tt.active, tt.eligible, tt.changes)
+		}
+		if e.TotalDeposits.ToInt().Int64() != tt.deposits {
+			t.Errorf("builder %s: total deposits %v, want %d", tt.builder, e.TotalDeposits, tt.deposits)
+		}
+	}
+
+	if terms, ok := d.TermsAt(builderA, 4); !ok || terms.MinimalStake.ToInt().Int64() != 10 || terms.PricePerBlock.ToInt().Int64() != 0 {
+		t.Errorf("terms of A at block 4 = %+v", terms)
+	}
+	if entries := d.Builders(); len(entries) != 2 || entries[0].Builder != builderA || entries[1].Builder != builderB {
+		t.Errorf("builders = %+v", entries)
+	}
+}
@@ -0,0 +1,92 @@
+// Package events scans BuilderStaking event history in block ranges small
+// enough for public RPC providers.
+package events
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// DefaultStep is the number of blocks per eth_getLogs request if Range.Step
+// is zero.
+const DefaultStep = 5000
+
+// Range is an inclusive block range scanned in chunks of Step blocks.
+type Range struct {
+	From uint64
+	To   uint64
+	Step uint64
+}
+
+func (r Range) each(ctx context.Context, fn func(opts *bind.FilterOpts) error) error {
+	step := r.Step
+	if step == 0 {
+		step = DefaultStep
+	}
+	for start := r.From; start <= r.To; start += step {
+		end := start + step - 1
+		if end > r.To || end < start {
+			end = r.To
+		}
+		if err := fn(&bind.FilterOpts{Start: start, End: &end, Context: ctx}); err != nil {
+			return err
+		}
+		if end == r.To {
+			break
+		}
+	}
+	return nil
+}
+
+// StakeUpdated calls fn for every StakeUpdated event in r, in order.
+func StakeUpdated(ctx context.Context, f *primev.BuilderStakingFilterer, r Range, fn func(*primev.BuilderStakingStakeUpdated) error) error {
+	return r.each(ctx, func(opts *bind.FilterOpts) error {
+		it, err := f.FilterStakeUpdated(opts)
+		if err != nil {
+			return err
+		}
+		defer it.Close()
+		for it.Next() {
+			if err := fn(it.Event); err != nil {
+				return err
+			}
+		}
+		return it.Error()
+	})
+}
+
+// BuilderUpdated calls fn for every BuilderUpdated event in r, in order.
+func BuilderUpdated(ctx context.Context, f *primev.BuilderStakingFilterer, r Range, fn func(*primev.BuilderStakingBuilderUpdated) error) error {
+	return r.each(ctx, func(opts *bind.FilterOpts) error {
+		it, err := f.FilterBuilderUpdated(opts)
+		if err != nil {
+			return err
+		}
+		defer it.Close()
+		for it.Next() {
+			if err := fn(it.Event); err != nil {
+				return err
+			}
+		}
+		return it.Error()
+	})
+}
+
+// Withdrawal calls fn for every Withdrawal event in r, in order.
+func Withdrawal(ctx context.Context, f *primev.BuilderStakingFilterer, r Range, fn func(*primev.BuilderStakingWithdrawal) error) error {
+	return r.each(ctx, func(opts *bind.FilterOpts) error {
+		it, err := f.FilterWithdrawal(opts)
+		if err != nil {
+			return err
+		}
+		defer it.Close()
+		for it.Next() {
+			if err := fn(it.Event); err != nil {
+				return err
+			}
+		}
+		return it.Error()
+	})
+}
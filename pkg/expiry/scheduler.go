@@ -0,0 +1,258 @@
+// Package expiry turns the silent passing of a subscription's end block into
+// events. The contract only emits StakeUpdated when a subscription starts or
+// is extended; the scheduler keys every commitment by its subscriptionEnd and
+// emits SubscriptionExpiringSoon and SubscriptionExpired when new heads reach
+// those heights. A deposit that extends the subscription reschedules both.
+package expiry
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/primevprotocol/primev-contracts/pkg/events"
+	"github.com/primevprotocol/primev-contracts/pkg/heads"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// DefaultWarning is the number of blocks before the end of a subscription
+// SubscriptionExpiringSoon is emitted at if Config.Warning is zero, about an
+// hour at 12 second blocks.
+const DefaultWarning = 300
+
+// Kind is the type of an Event.
+type Kind string
+
+const (
+	SubscriptionExpiringSoon Kind = "SubscriptionExpiringSoon" // Warning blocks or less are left
+	SubscriptionExpired      Kind = "SubscriptionExpired"      // The head reached the end block
+)
+
+// Event is a synthetic subscription event.
+type Event struct {
+	Kind            Kind           `json:"kind"`
+	Builder         common.Address `json:"builder"`
+	Commitment      common.Hash    `json:"commitment"`
+	SubscriptionEnd uint64         `json:"subscriptionEnd"`
+	Block           uint64         `json:"block"` // Head the event was emitted at
+}
+
+// Backend is the node connection needed by Scheduler.
+type Backend interface {
+	bind.ContractFilterer
+	heads.Reader
+}
+
+// Config configures a Scheduler.
+type Config struct {
+	FromBlock    uint64        // Block event replay starts at, must not be after the deployment block
+	Step         uint64        // Blocks per log query, events.DefaultStep if zero
+	Warning      uint64        // Blocks before the end SubscriptionExpiringSoon is emitted at
+	PollInterval time.Duration // Head polling interval when subscriptions are unsupported
+}
+
+// subscription is the scheduling state of a commitment.
+type subscription struct {
+	builder common.Address
+	end     uint64
+	warned  bool
+	expired bool
+}
+
+// timer fires kind for commitment at block. It is stale once the
+// commitment's end is no longer end.
+type timer struct {
+	block      uint64
+	kind       Kind
+	commitment common.Hash
+	end        uint64
+}
+
+type timerHeap []timer
+
+func (h timerHeap) Len() int            { return len(h) }
+func (h timerHeap) Less(i, j int) bool  { return h[i].block < h[j].block }
+func (h timerHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *timerHeap) Push(x interface{}) { *h = append(*h, x.(timer)) }
+func (h *timerHeap) Pop() interface{} {
+	old := *h
+	t := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return t
+}
+
+// Scheduler emits expiry events for all commitments of a BuilderStaking
+// contract.
+type Scheduler struct {
+	cfg      Config
+	backend  Backend
+	filterer *primev.BuilderStakingFilterer
+	feed     event.Feed
+
+	mu     sync.Mutex
+	head   uint64
+	subs   map[common.Hash]*subscription
+	timers timerHeap
+}
+
+// New creates a Scheduler for the BuilderStaking contract at address.
+func New(address common.Address, backend Backend, cfg Config) (*Scheduler, error) {
+	if cfg.Warning == 0 {
+		cfg.Warning = DefaultWarning
+	}
+	filterer, err := primev.NewBuilderStakingFilterer(address, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &Scheduler{
+		cfg:      cfg,
+		backend:  backend,
+		filterer: filterer,
+		subs:     make(map[common.Hash]*subscription),
+	}, nil
+}
+
+// Subscribe delivers events to ch until the subscription is closed. Sends
+// block until every subscriber has received the event, so ch should be
+// buffered or drained promptly.
+func (s *Scheduler) Subscribe(ch chan<- Event) event.Subscription {
+	return s.feed.Subscribe(ch)
+}
+
+// Next returns the end block of commitment's subscription and whether it has
+// not expired yet.
+func (s *Scheduler) Next(commitment common.Hash) (uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subs[commitment]
+	if !ok {
+		return 0, false
+	}
+	return sub.end, !sub.expired
+}
+
+// Run replays StakeUpdated events from FromBlock and then emits events on new
+// heads until ctx is done. Subscriptions that ended before the first head are
+// not reported as expired.
+func (s *Scheduler) Run(ctx context.Context) error {
+	var last uint64
+	return heads.Watch(ctx, s.backend, s.cfg.PollInterval, func(head *types.Header) error {
+		number := head.Number.Uint64()
+		from := last + 1
+		switch {
+		case last == 0:
+			from = s.cfg.FromBlock
+		case from > number:
+			// A reorg to a lower height; StakeUpdated carries absolute
+			// values, so replaying the new head's block is enough.
+			from = number
+		}
+		err := events.StakeUpdated(ctx, s.filterer, events.Range{From: from, To: number, Step: s.cfg.Step}, func(e *primev.BuilderStakingStakeUpdated) error {
+			s.Update(e.Builder, common.Hash(e.Commitment), e.SubscriptionEnd.Uint64())
+			return nil
+		})
+		if err != nil {
+			log.Warn("Failed to read stake updates", "block", number, "err", err)
+			return nil
+		}
+		if last == 0 {
+			s.skipExpired(number)
+		}
+		s.SetHead(number)
+		last = number
+		return nil
+	})
+}
+
+// Update schedules commitment's subscription to end at end, as announced by a
+// StakeUpdated event. Pending timers for an earlier end are dropped. An end
+// the head already reached, as after a reorg replaced a deposit, expires the
+// subscription at once unless it has expired before.
+func (s *Scheduler) Update(builder common.Address, commitment common.Hash, end uint64) {
+	s.mu.Lock()
+	sub, ok := s.subs[commitment]
+	if !ok {
+		sub = &subscription{builder: builder}
+		s.subs[commitment] = sub
+	}
+	if ok && sub.end == end {
+		s.mu.Unlock()
+		return
+	}
+	sub.end = end
+	if end <= s.head && s.head > 0 {
+		if sub.expired {
+			s.mu.Unlock()
+			return
+		}
+		sub.expired, sub.warned = true, true
+		e := Event{Kind: SubscriptionExpired, Builder: sub.builder, Commitment: commitment, SubscriptionEnd: end, Block: s.head}
+		s.mu.Unlock()
+		s.send(e)
+		return
+	}
+	// A renewed subscription expires again, and warns again unless it is
+	// still within the warning window.
+	sub.expired = false
+	sub.warned = sub.warned && end <= s.head+s.cfg.Warning
+	warn := uint64(0)
+	if end > s.cfg.Warning {
+		warn = end - s.cfg.Warning
+	}
+	heap.Push(&s.timers, timer{block: warn, kind: SubscriptionExpiringSoon, commitment: commitment, end: end})
+	heap.Push(&s.timers, timer{block: end, kind: SubscriptionExpired, commitment: commitment, end: end})
+	s.mu.Unlock()
+}
+
+// SetHead emits the events due at block.
+func (s *Scheduler) SetHead(block uint64) {
+	s.mu.Lock()
+	if block > s.head {
+		s.head = block
+	}
+	var due []Event
+	for len(s.timers) > 0 && s.timers[0].block <= block {
+		t := heap.Pop(&s.timers).(timer)
+		sub := s.subs[t.commitment]
+		if sub.end != t.end {
+			continue
+		}
+		switch {
+		case t.kind == SubscriptionExpired && !sub.expired:
+			sub.expired = true
+		case t.kind == SubscriptionExpiringSoon && !sub.warned && !sub.expired && block < sub.end:
+			sub.warned = true
+		default:
+			continue
+		}
+		due = append(due, Event{Kind: t.kind, Builder: sub.builder, Commitment: t.commitment, SubscriptionEnd: sub.end, Block: block})
+	}
+	s.mu.Unlock()
+
+	for _, e := range due {
+		s.send(e)
+	}
+}
+
+func (s *Scheduler) send(e Event) {
+	log.Debug("Subscription event", "kind", e.Kind, "builder", e.Builder, "commitment", e.Commitment, "end", e.SubscriptionEnd, "block", e.Block)
+	s.feed.Send(e)
+}
+
+// skipExpired marks subscriptions that ended by block as expired without
+// emitting events, so startup does not report the whole history.
+func (s *Scheduler) skipExpired(block uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.subs {
+		if sub.end <= block {
+			sub.expired, sub.warned = true, true
+		}
+	}
+}
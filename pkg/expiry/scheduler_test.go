@@ -0,0 +1,119 @@
+package expiry
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var builder = common.HexToAddress("0xb1")
+
+func newScheduler(t *testing.T) (*Scheduler, chan Event) {
+	s, err := New(common.Address{}, nil, Config{Warning: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan Event, 100)
+	s.Subscribe(ch)
+	return s, ch
+}
+
+// advance moves the head one block at a time from the scheduler's head to
+// block.
+func advance(s *Scheduler, block uint64) {
+	for b := s.head + 1; b <= block; b++ {
+		s.SetHead(b)
+	}
+}
+
+// drain returns the buffered events as "kind commitment@block" strings.
+func drain(ch chan Event) []string {
+	var got []string
+	for {
+		select {
+		case e := <-ch:
+			got = append(got, fmt.Sprintf("%s %x@%d", e.Kind, e.Commitment[31], e.Block))
+		default:
+			return got
+		}
+	}
+}
+
+func TestSchedulerOrder(t *testing.T) {
+	s, ch := newScheduler(t)
+	s.Update(builder, common.HexToHash("0x03"), 300)
+	s.Update(builder, common.HexToHash("0x01"), 100)
+	s.Update(builder, common.HexToHash("0x02"), 200)
+	advance(s, 300)
+	want := []string{
+		"SubscriptionExpiringSoon 1@90", "SubscriptionExpired 1@100",
+		"SubscriptionExpiringSoon 2@190", "SubscriptionExpired 2@200",
+		"SubscriptionExpiringSoon 3@290", "SubscriptionExpired 3@300",
+	}
+	if got := drain(ch); !reflect.DeepEqual(got, want) {
+		t.Fatalf("events %v, want %v", got, want)
+	}
+}
+
+func TestSchedulerStaleTimers(t *testing.T) {
+	tests := []struct {
+		name    string
+		updates []uint64 // Ends announced at block 50, in order
+		want    []string
+	}{
+		{
+			name:    "extended",
+			updates: []uint64{100, 200},
+			want:    []string{"SubscriptionExpiringSoon 1@190", "SubscriptionExpired 1@200"},
+		},
+		{
+			name:    "shortened",
+			updates: []uint64{200, 100},
+			want:    []string{"SubscriptionExpiringSoon 1@90", "SubscriptionExpired 1@100"},
+		},
+		{
+			// The timers of the first end 100 match the commitment's end
+			// again, but the flags keep them from firing twice.
+			name:    "restored",
+			updates: []uint64{100, 200, 100},
+			want:    []string{"SubscriptionExpiringSoon 1@90", "SubscriptionExpired 1@100"},
+		},
+	}
+	for _, tt := range tests {
+		s, ch := newScheduler(t)
+		advance(s, 50)
+		for _, end := range tt.updates {
+			s.Update(builder, common.HexToHash("0x01"), end)
+		}
+		advance(s, 300)
+		if got := drain(ch); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: events %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSchedulerEndBeforeHead(t *testing.T) {
+	s, ch := newScheduler(t)
+	commitment := common.HexToHash("0x01")
+	s.Update(builder, commitment, 200)
+	advance(s, 150)
+	// A reorg replaced the deposit by a smaller one that already ended.
+	s.Update(builder, commitment, 120)
+	advance(s, 300)
+	want := []string{"SubscriptionExpired 1@150"}
+	if got := drain(ch); !reflect.DeepEqual(got, want) {
+		t.Fatalf("events %v, want %v", got, want)
+	}
+	if end, active := s.Next(commitment); end != 120 || active {
+		t.Errorf("Next = %d, %v", end, active)
+	}
+
+	// An already expired subscription is not reported again.
+	s.Update(builder, commitment, 110)
+	advance(s, 310)
+	if got := drain(ch); len(got) != 0 {
+		t.Errorf("events %v after a second lower end", got)
+	}
+}
@@ -0,0 +1,194 @@
+// Package fees decides the fees and gas limits of BuilderStaking
+// transactions. Strategies suggest EIP-1559 fees and can be wrapped to cap
+// or scale them; a Transactor applies a Policy of strategies and a gas margin
+// to every call it sends.
+package fees
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	ethereum "github.com/ethereum/go-ethereum"
+)
+
+const (
+	defaultHistoryBlocks     = 20
+	defaultRewardPercentile  = 50
+	defaultBaseFeeMultiplier = 2
+)
+
+// ErrNoFeeHistory is returned when the node reports no priority fee rewards.
+var ErrNoFeeHistory = errors.New("fees: no priority fee rewards in fee history")
+
+// Fees holds EIP-1559 fee parameters for a single transaction.
+type Fees struct {
+	TipCap *big.Int // Maximum priority fee per gas
+	FeeCap *big.Int // Maximum total fee per gas
+}
+
+// Strategy suggests fees for the next transaction.
+type Strategy interface {
+	Fees(ctx context.Context) (*Fees, error)
+}
+
+// FeeHistoryReader is implemented by backends supporting eth_feeHistory.
+type FeeHistoryReader interface {
+	FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+}
+
+// Percentile takes the median of a reward percentile over recent blocks as the
+// priority fee and caps the total fee at a multiple of the next base fee.
+type Percentile struct {
+	Backend           FeeHistoryReader
+	Blocks            uint64  // Number of recent blocks to sample, 20 if zero
+	Percentile        float64 // Reward percentile within each block, 50 if zero
+	BaseFeeMultiplier uint64  // Multiplier applied to the next base fee, 2 if zero
+}
+
+// Fees implements Strategy.
+func (p *Percentile) Fees(ctx context.Context) (*Fees, error) {
+	blocks, percentile, multiplier := p.Blocks, p.Percentile, p.BaseFeeMultiplier
+	if blocks == 0 {
+		blocks = defaultHistoryBlocks
+	}
+	if percentile == 0 {
+		percentile = defaultRewardPercentile
+	}
+	if multiplier == 0 {
+		multiplier = defaultBaseFeeMultiplier
+	}
+
+	history, err := p.Backend.FeeHistory(ctx, blocks, nil, []float64{percentile})
+	if err != nil {
+		return nil, fmt.Errorf("fees: fee history: %w", err)
+	}
+	if len(history.BaseFee) == 0 {
+		return nil, ErrNoFeeHistory
+	}
+
+	var tips []*big.Int
+	for _, rewards := range history.Reward {
+		if len(rewards) > 0 && rewards[0] != nil {
+			tips = append(tips, rewards[0])
+		}
+	}
+	if len(tips) == 0 {
+		return nil, ErrNoFeeHistory
+	}
+	sort.Slice(tips, func(i, j int) bool { return tips[i].Cmp(tips[j]) < 0 })
+	tip := new(big.Int).Set(tips[len(tips)/2])
+
+	// The last base fee returned by eth_feeHistory belongs to the next block.
+	baseFee := history.BaseFee[len(history.BaseFee)-1]
+	feeCap := new(big.Int).Mul(baseFee, new(big.Int).SetUint64(multiplier))
+	feeCap.Add(feeCap, tip)
+
+	return &Fees{TipCap: tip, FeeCap: feeCap}, nil
+}
+
+// Fixed always suggests the same fees.
+type Fixed struct {
+	TipCap *big.Int
+	FeeCap *big.Int
+}
+
+// Fees implements Strategy.
+func (f *Fixed) Fees(context.Context) (*Fees, error) {
+	if f.TipCap == nil || f.FeeCap == nil {
+		return nil, errors.New("fees: fixed strategy requires both tip cap and fee cap")
+	}
+	if f.TipCap.Cmp(f.FeeCap) > 0 {
+		return nil, fmt.Errorf("fees: tip cap %v exceeds fee cap %v", f.TipCap, f.FeeCap)
+	}
+	return &Fees{TipCap: new(big.Int).Set(f.TipCap), FeeCap: new(big.Int).Set(f.FeeCap)}, nil
+}
+
+// Capped limits the fees suggested by another strategy to a maximum fee cap.
+type Capped struct {
+	Strategy Strategy
+	Max      *big.Int
+}
+
+// Fees implements Strategy.
+func (c *Capped) Fees(ctx context.Context) (*Fees, error) {
+	fees, err := c.Strategy.Fees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if fees.FeeCap.Cmp(c.Max) > 0 {
+		fees.FeeCap = new(big.Int).Set(c.Max)
+	}
+	if fees.TipCap.Cmp(fees.FeeCap) > 0 {
+		fees.TipCap = new(big.Int).Set(fees.FeeCap)
+	}
+	return fees, nil
+}
+
+// Urgent scales the fees suggested by another strategy by a percentage,
+// e.g. 200 doubles both the tip and the fee cap. Scaling applies after any
+// cap of the wrapped strategy, so a cap must wrap Urgent rather than the
+// other way round.
+type Urgent struct {
+	Strategy Strategy
+	Percent  uint64
+}
+
+// Fees implements Strategy.
+func (u *Urgent) Fees(ctx context.Context) (*Fees, error) {
+	fees, err := u.Strategy.Fees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Fees{
+		TipCap: scale(fees.TipCap, u.Percent),
+		FeeCap: scale(fees.FeeCap, u.Percent),
+	}, nil
+}
+
+// validate reports strategy settings that would otherwise only fail, or
+// panic, once fees are requested. Wrapped strategies are checked as well.
+func validate(s Strategy) error {
+	return validateWithin(s, false)
+}
+
+// validateWithin validates s, where capped tells whether an enclosing Capped
+// applies after s.
+func validateWithin(s Strategy, capped bool) error {
+	switch s := s.(type) {
+	case nil:
+		return errors.New("fees: no fee strategy")
+	case *Capped:
+		if s.Max == nil {
+			return errors.New("fees: capped strategy has no maximum fee cap")
+		}
+		return validateWithin(s.Strategy, true)
+	case *Urgent:
+		if s.Percent < 100 {
+			return fmt.Errorf("fees: urgent strategy percent %d would lower fees", s.Percent)
+		}
+		if !capped && hasCap(s.Strategy) {
+			return errors.New("fees: urgent strategy would scale fees above the cap it wraps, cap the urgent strategy instead")
+		}
+		return validateWithin(s.Strategy, capped)
+	}
+	return nil
+}
+
+// hasCap reports whether s is a Capped strategy, possibly wrapped in Urgent.
+func hasCap(s Strategy) bool {
+	switch s := s.(type) {
+	case *Capped:
+		return true
+	case *Urgent:
+		return hasCap(s.Strategy)
+	}
+	return false
+}
+
+func scale(v *big.Int, percent uint64) *big.Int {
+	r := new(big.Int).Mul(v, new(big.Int).SetUint64(percent))
+	return r.Div(r, big.NewInt(100))
+}
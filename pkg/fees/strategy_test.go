@@ -0,0 +1,90 @@
+package fees
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+)
+
+// history answers eth_feeHistory with one reward per block and the given
+// base fees, the last of which belongs to the next block.
+type history struct {
+	rewards  []int64
+	baseFees []int64
+}
+
+func (h *history) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	fh := &ethereum.FeeHistory{}
+	for _, r := range h.rewards {
+		fh.Reward = append(fh.Reward, []*big.Int{big.NewInt(r)})
+	}
+	for _, b := range h.baseFees {
+		fh.BaseFee = append(fh.BaseFee, big.NewInt(b))
+	}
+	return fh, nil
+}
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name        string
+		history     history
+		multiplier  uint64
+		tip, feeCap int64
+		noHistory   bool
+	}{
+		{name: "odd count", history: history{[]int64{5, 1, 3}, []int64{10, 10, 10, 20}}, tip: 3, feeCap: 43},
+		// The upper median is taken for an even count.
+		{name: "even count", history: history{[]int64{4, 1, 3, 2}, []int64{10}}, tip: 3, feeCap: 23},
+		{name: "multiplier", history: history{[]int64{2}, []int64{10}}, multiplier: 3, tip: 2, feeCap: 32},
+		{name: "no rewards", history: history{nil, []int64{10}}, noHistory: true},
+		{name: "no base fee", history: history{[]int64{1}, nil}, noHistory: true},
+	}
+	for _, tt := range tests {
+		h := tt.history
+		fees, err := (&Percentile{Backend: &h, BaseFeeMultiplier: tt.multiplier}).Fees(context.Background())
+		if tt.noHistory {
+			if err != ErrNoFeeHistory {
+				t.Errorf("%s: err = %v, want ErrNoFeeHistory", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if fees.TipCap.Int64() != tt.tip || fees.FeeCap.Int64() != tt.feeCap {
+			t.Errorf("%s: fees %v/%v, want %d/%d", tt.name, fees.TipCap, fees.FeeCap, tt.tip, tt.feeCap)
+		}
+	}
+}
+
+func TestWrappedStrategies(t *testing.T) {
+	fixed := func(tip, feeCap int64) *Fixed { return &Fixed{TipCap: big.NewInt(tip), FeeCap: big.NewInt(feeCap)} }
+	tests := []struct {
+		name        string
+		strategy    Strategy
+		tip, feeCap int64
+	}{
+		{"below cap", &Capped{Strategy: fixed(2, 10), Max: big.NewInt(20)}, 2, 10},
+		{"fee cap clamped", &Capped{Strategy: fixed(2, 30), Max: big.NewInt(20)}, 2, 20},
+		{"tip clamped to fee cap", &Capped{Strategy: fixed(25, 30), Max: big.NewInt(20)}, 20, 20},
+		{"urgent", &Urgent{Strategy: fixed(3, 10), Percent: 150}, 4, 15},
+		{"capped urgent", &Capped{Strategy: &Urgent{Strategy: fixed(10, 20), Percent: 200}, Max: big.NewInt(30)}, 20, 30},
+	}
+	for _, tt := range tests {
+		if err := validate(tt.strategy); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		fees, err := tt.strategy.Fees(context.Background())
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if fees.TipCap.Int64() != tt.tip || fees.FeeCap.Int64() != tt.feeCap {
+			t.Errorf("%s: fees %v/%v, want %d/%d", tt.name, fees.TipCap, fees.FeeCap, tt.tip, tt.feeCap)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package fees
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// Policy decides fees and gas limits for BuilderStaking transactions.
+type Policy struct {
+	Strategy     Strategy // Default fee strategy
+	Urgent       Strategy // Fee strategy for renewals close to expiry, Strategy if nil
+	UrgentWithin uint64   // Remaining subscription blocks at which Urgent is used
+	GasMargin    uint64   // Percent added on top of the simulated gas usage
+}
+
+// ForRenewal returns the fee strategy for a renewal with the given number of
+// subscription blocks left.
+func (p *Policy) ForRenewal(remaining uint64) Strategy {
+	if p.Urgent != nil && remaining <= p.UrgentWithin {
+		return p.Urgent
+	}
+	return p.Strategy
+}
+
+// Transactor sends BuilderStaking transactions with fees and gas limits taken
+// from a Policy instead of the bind defaults.
+type Transactor struct {
+	contract *primev.BuilderStakingTransactor
+	backend  bind.ContractTransactor
+	address  common.Address
+	abi      *abi.ABI
+	policy   Policy
+}
+
+// NewTransactor creates a Transactor bound to a deployed BuilderStaking contract.
+func NewTransactor(address common.Address, backend bind.ContractTransactor, policy Policy) (*Transactor, error) {
+	if policy.Strategy == nil {
+		return nil, errors.New("fees: policy has no fee strategy")
+	}
+	if err := validate(policy.Strategy); err != nil {
+		return nil, err
+	}
+	if policy.Urgent != nil {
+		if err := validate(policy.Urgent); err != nil {
+			return nil, err
+		}
+	}
+	contract, err := primev.NewBuilderStakingTransactor(address, backend)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := primev.BuilderStakingMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return &Transactor{
+		contract: contract,
+		backend:  backend,
+		address:  address,
+		abi:      parsed,
+		policy:   policy,
+	}, nil
+}
+
+// Deposit sends a deposit using the default fee strategy.
+func (t *Transactor) Deposit(opts *bind.TransactOpts, builder common.Address, commitment [32]byte) (*types.Transaction, error) {
+	opts, err := t.prepare(opts, t.policy.Strategy, "deposit", builder, commitment)
+	if err != nil {
+		return nil, err
+	}
+	return t.contract.Deposit(opts, builder, commitment)
+}
+
+// Renew sends a deposit extending a subscription that has remaining blocks
+// left, switching to the urgent fee strategy when it is close to expiry.
+func (t *Transactor) Renew(opts *bind.TransactOpts, builder common.Address, commitment [32]byte, remaining uint64) (*types.Transaction, error) {
+	opts, err := t.prepare(opts, t.policy.ForRenewal(remaining), "deposit", builder, commitment)
+	if err != nil {
+		return nil, err
+	}
+	return t.contract.Deposit(opts, builder, commitment)
+}
+
+// Withdraw sends a withdrawal of vested funds using the default fee strategy.
+func (t *Transactor) Withdraw(opts *bind.TransactOpts) (*types.Transaction, error) {
+	opts, err := t.prepare(opts, t.policy.Strategy, "withdraw")
+	if err != nil {
+		return nil, err
+	}
+	return t.contract.Withdraw(opts)
+}
+
+// UpdateBuilder sends a builder terms update using the default fee strategy.
+func (t *Transactor) UpdateBuilder(opts *bind.TransactOpts, minimalStake *big.Int, minimalSubscriptionPeriod *big.Int) (*types.Transaction, error) {
+	opts, err := t.prepare(opts, t.policy.Strategy, "updateBuilder", minimalStake, minimalSubscriptionPeriod)
+	if err != nil {
+		return nil, err
+	}
+	return t.contract.UpdateBuilder(opts, minimalStake, minimalSubscriptionPeriod)
+}
+
+// Fees returns the fees the default strategy currently suggests.
+func (t *Transactor) Fees(ctx context.Context) (*Fees, error) {
+	return t.policy.Strategy.Fees(ctx)
+}
+
+// EstimateGas simulates a call to method and returns the gas limit including
+// the policy safety margin.
+func (t *Transactor) EstimateGas(opts *bind.TransactOpts, method string, params ...interface{}) (uint64, error) {
+	input, err := t.abi.Pack(method, params...)
+	if err != nil {
+		return 0, err
+	}
+	gas, err := t.backend.EstimateGas(ensureContext(opts.Context), ethereum.CallMsg{
+		From:  opts.From,
+		To:    &t.address,
+		Value: opts.Value,
+		Data:  input,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("fees: estimate gas for %s: %w", method, err)
+	}
+	return gas + gas*t.policy.GasMargin/100, nil
+}
+
+// prepare returns a copy of opts with the gas limit and fees filled in unless
+// the caller already set them explicitly.
+func (t *Transactor) prepare(opts *bind.TransactOpts, strategy Strategy, method string, params ...interface{}) (*bind.TransactOpts, error) {
+	prepared := *opts
+	if prepared.GasLimit == 0 {
+		gas, err := t.EstimateGas(opts, method, params...)
+		if err != nil {
+			return nil, err
+		}
+		prepared.GasLimit = gas
+	}
+	if prepared.GasPrice == nil && prepared.GasFeeCap == nil && prepared.GasTipCap == nil {
+		fees, err := strategy.Fees(ensureContext(opts.Context))
+		if err != nil {
+			return nil, err
+		}
+		prepared.GasTipCap = fees.TipCap
+		prepared.GasFeeCap = fees.FeeCap
+	}
+	return &prepared, nil
+}
+
+func ensureContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
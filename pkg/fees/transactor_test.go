@@ -0,0 +1,35 @@
+package fees
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestNewTransactorValidatesPolicy(t *testing.T) {
+	fixed := &Fixed{TipCap: big.NewInt(1), FeeCap: big.NewInt(2)}
+	tests := []struct {
+		name   string
+		policy Policy
+		ok     bool
+	}{
+		{"fixed", Policy{Strategy: fixed}, true},
+		{"no strategy", Policy{Urgent: fixed}, false},
+		{"capped", Policy{Strategy: &Capped{Strategy: fixed, Max: big.NewInt(2)}}, true},
+		{"capped without max", Policy{Strategy: &Capped{Strategy: fixed}}, false},
+		{"capped without strategy", Policy{Strategy: &Capped{Max: big.NewInt(2)}}, false},
+		{"urgent", Policy{Strategy: fixed, Urgent: &Urgent{Strategy: fixed, Percent: 100}}, true},
+		{"urgent below 100", Policy{Strategy: fixed, Urgent: &Urgent{Strategy: fixed, Percent: 99}}, false},
+		{"urgent wrapping capped without max", Policy{Strategy: fixed, Urgent: &Urgent{Strategy: &Capped{Strategy: fixed}, Percent: 150}}, false},
+		{"urgent wrapping capped", Policy{Strategy: fixed, Urgent: &Urgent{Strategy: &Capped{Strategy: fixed, Max: big.NewInt(2)}, Percent: 150}}, false},
+		{"capped urgent", Policy{Strategy: fixed, Urgent: &Capped{Strategy: &Urgent{Strategy: fixed, Percent: 150}, Max: big.NewInt(2)}}, true},
+		{"capped urgent wrapping capped", Policy{Strategy: &Capped{Strategy: &Urgent{Strategy: &Capped{Strategy: fixed, Max: big.NewInt(2)}, Percent: 150}, Max: big.NewInt(2)}}, true},
+	}
+	for _, tt := range tests {
+		_, err := NewTransactor(common.Address{}, nil, tt.policy)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package gate
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// DefaultTTL is how long ChainChecker caches stakes, terms and the head.
+const DefaultTTL = 12 * time.Second
+
+// maxEntries is the cache size above which expired entries are pruned.
+const maxEntries = 10_000
+
+// Access is the result of a stake check for a commitment.
+type Access struct {
+	Commitment      common.Hash
+	Block           uint64 // Head the subscription end was compared with
+	Stake           *big.Int
+	SubscriptionEnd *big.Int
+	MinimalStake    *big.Int
+	HasMinimalStake bool // Same rule as the contract's hasMinimalStake
+	Active          bool // Subscription end lies after Block
+}
+
+// Allowed reports whether bundles of the commitment may be forwarded.
+func (a *Access) Allowed() bool {
+	return a.HasMinimalStake && a.Active
+}
+
+// StakeChecker decides whether a commitment may reach the builder.
+type StakeChecker interface {
+	Check(ctx context.Context, commitment common.Hash) (*Access, error)
+}
+
+// Backend is the node connection needed by ChainChecker.
+type Backend interface {
+	bind.ContractCaller
+	BlockNumber(ctx context.Context) (uint64, error)
+}
+
+type stakeEntry struct {
+	stake, subscriptionEnd *big.Int
+	expires                time.Time
+}
+
+// ChainChecker checks commitments against the contract, caching every read
+// for a TTL so the node is not queried per bundle.
+type ChainChecker struct {
+	caller  *primev.BuilderStakingCaller
+	backend Backend
+	builder common.Address
+	ttl     time.Duration
+
+	mu           sync.Mutex
+	head         uint64
+	headExpires  time.Time
+	minimal      *big.Int
+	termsExpires time.Time
+	stakes       map[common.Hash]stakeEntry
+}
+
+// NewChainChecker creates a ChainChecker for builder on the BuilderStaking
+// contract at address. A zero ttl means DefaultTTL.
+func NewChainChecker(address common.Address, backend Backend, builder common.Address, ttl time.Duration) (*ChainChecker, error) {
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	caller, err := primev.NewBuilderStakingCaller(address, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &ChainChecker{
+		caller:  caller,
+		backend: backend,
+		builder: builder,
+		ttl:     ttl,
+		stakes:  make(map[common.Hash]stakeEntry),
+	}, nil
+}
+
+// Check implements StakeChecker.
+func (c *ChainChecker) Check(ctx context.Context, commitment common.Hash) (*Access, error) {
+	now := time.Now()
+	head, err := c.headAt(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	minimal, err := c.minimalStake(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	stake, err := c.stake(ctx, commitment, now)
+	if err != nil {
+		return nil, err
+	}
+	return &Access{
+		Commitment:      commitment,
+		Block:           head,
+		Stake:           stake.stake,
+		SubscriptionEnd: stake.subscriptionEnd,
+		MinimalStake:    minimal,
+		HasMinimalStake: minimal.Sign() > 0 && stake.stake.Cmp(minimal) >= 0,
+		Active:          stake.subscriptionEnd.Cmp(new(big.Int).SetUint64(head)) > 0,
+	}, nil
+}
+
+func (c *ChainChecker) headAt(ctx context.Context, now time.Time) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Before(c.headExpires) {
+		return c.head, nil
+	}
+	head, err := c.backend.BlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+	c.head, c.headExpires = head, now.Add(c.ttl)
+	return head, nil
+}
+
+func (c *ChainChecker) minimalStake(ctx context.Context, now time.Time) (*big.Int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Before(c.termsExpires) {
+		return c.minimal, nil
+	}
+	info, err := c.caller.Builders(&bind.CallOpts{Context: ctx}, c.builder)
+	if err != nil {
+		return nil, err
+	}
+	c.minimal, c.termsExpires = info.MinimalStake, now.Add(c.ttl)
+	return info.MinimalStake, nil
+}
+
+func (c *ChainChecker) stake(ctx context.Context, commitment common.Hash, now time.Time) (stakeEntry, error) {
+	c.mu.Lock()
+	entry, ok := c.stakes[commitment]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry, nil
+	}
+	s, err := c.caller.Stakes(&bind.CallOpts{Context: ctx}, commitment)
+	if err != nil {
+		return stakeEntry{}, err
+	}
+	entry = stakeEntry{stake: s.Stake, subscriptionEnd: s.SubscriptionEnd, expires: now.Add(c.ttl)}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.stakes) >= maxEntries {
+		for k, v := range c.stakes {
+			if !now.Before(v.expires) {
+				delete(c.stakes, k)
+			}
+		}
+	}
+	c.stakes[commitment] = entry
+	return entry, nil
+}
@@ -0,0 +1,257 @@
+// Package gate is a reverse proxy for a builder's bundle endpoint that only
+// forwards bundles from searchers whose commitment holds the builder's
+// minimal stake and an active subscription.
+package gate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/primevprotocol/primev-contracts/pkg/proof"
+)
+
+// DefaultCommitmentHeader carries the searcher's commitment hash.
+const DefaultCommitmentHeader = "X-Primev-Commitment"
+
+// DefaultMethods are the JSON-RPC methods gated if Config.Methods is empty.
+var DefaultMethods = []string{"eth_sendBundle", "eth_sendPrivateTransaction"}
+
+// maxBodySize limits request bodies read by the proxy.
+const maxBodySize = 10 << 20
+
+// JSON-RPC error codes returned for rejected requests.
+const (
+	CodeInvalidRequest      = -32600
+	CodeParseError          = -32700
+	CodeMissingCommitment   = -32001
+	CodeInsufficientStake   = -32002
+	CodeSubscriptionExpired = -32003
+	CodeCheckFailed         = -32004
+	CodeInvalidProof        = -32005
+)
+
+// Error is a JSON-RPC error. Extractors may return it to choose the code
+// and message sent to the searcher.
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Extractor finds the commitment a request claims access with.
+type Extractor interface {
+	// Commitment returns the commitment of r, whose body has already been
+	// read into body.
+	Commitment(r *http.Request, body []byte) (common.Hash, error)
+}
+
+// HeaderExtractor reads the commitment hash from a request header.
+type HeaderExtractor struct {
+	Header string // DefaultCommitmentHeader if empty
+}
+
+// Commitment implements Extractor.
+func (e HeaderExtractor) Commitment(r *http.Request, body []byte) (common.Hash, error) {
+	header := e.Header
+	if header == "" {
+		header = DefaultCommitmentHeader
+	}
+	value := r.Header.Get(header)
+	if value == "" {
+		return common.Hash{}, &Error{Code: CodeMissingCommitment, Message: fmt.Sprintf("missing %s header", header)}
+	}
+	b, err := hexutil.Decode(value)
+	if err != nil || len(b) != common.HashLength {
+		return common.Hash{}, &Error{Code: CodeMissingCommitment, Message: fmt.Sprintf("invalid %s header", header)}
+	}
+	return common.BytesToHash(b), nil
+}
+
+// ProofExtractor takes the commitment from a signed ownership proof, so only
+// the searcher holding the commitment account's key gets through.
+type ProofExtractor struct {
+	Verifier *proof.Verifier
+}
+
+// Commitment implements Extractor.
+func (e ProofExtractor) Commitment(r *http.Request, body []byte) (common.Hash, error) {
+	_, commitment, err := e.Verifier.Verify(r, body)
+	if errors.Is(err, proof.ErrMissingProof) {
+		return common.Hash{}, &Error{Code: CodeMissingCommitment, Message: err.Error()}
+	}
+	if err != nil {
+		return common.Hash{}, &Error{Code: CodeInvalidProof, Message: err.Error()}
+	}
+	return commitment, nil
+}
+
+// Config configures a Proxy.
+type Config struct {
+	Target    *url.URL     // Builder endpoint requests are forwarded to
+	Checker   StakeChecker // Decides on access
+	Extractor Extractor    // HeaderExtractor if nil
+	Methods   []string     // Gated methods, DefaultMethods if empty; others pass through
+}
+
+// Proxy gates bundle submissions on stake checks.
+type Proxy struct {
+	cfg     Config
+	methods map[string]bool
+	proxy   *httputil.ReverseProxy
+}
+
+// NewProxy creates a Proxy forwarding to cfg.Target.
+func NewProxy(cfg Config) *Proxy {
+	if cfg.Extractor == nil {
+		cfg.Extractor = HeaderExtractor{}
+	}
+	if len(cfg.Methods) == 0 {
+		cfg.Methods = DefaultMethods
+	}
+	methods := make(map[string]bool, len(cfg.Methods))
+	for _, m := range cfg.Methods {
+		methods[m] = true
+	}
+	return &Proxy{cfg: cfg, methods: methods, proxy: httputil.NewSingleHostReverseProxy(cfg.Target)}
+}
+
+type request struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   *Error          `json:"error"`
+}
+
+// accessData is attached to stake rejections.
+type accessData struct {
+	Commitment      common.Hash  `json:"commitment"`
+	Block           uint64       `json:"block"`
+	Stake           *hexutil.Big `json:"stake"`
+	MinimalStake    *hexutil.Big `json:"minimalStake"`
+	SubscriptionEnd *hexutil.Big `json:"subscriptionEnd"`
+}
+
+// ServeHTTP implements http.Handler.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		// Only JSON-RPC POSTs can be checked, so nothing else reaches the
+		// builder.
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeErrors(w, nil, false, &Error{Code: CodeInvalidRequest, Message: "request body too large or unreadable"})
+		return
+	}
+	r.Body.Close()
+
+	reqs, batch, err := parseRequests(body)
+	if err != nil {
+		writeErrors(w, nil, false, &Error{Code: CodeParseError, Message: "invalid JSON-RPC request"})
+		return
+	}
+	gated := false
+	for _, req := range reqs {
+		gated = gated || p.methods[req.Method]
+	}
+	if gated {
+		if rejection := p.check(r, body); rejection != nil {
+			log.Debug("Rejected request", "remote", r.RemoteAddr, "code", rejection.Code, "reason", rejection.Message)
+			writeErrors(w, reqs, batch, rejection)
+			return
+		}
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	p.proxy.ServeHTTP(w, r)
+}
+
+// check returns the error to reject r with, or nil to forward it.
+func (p *Proxy) check(r *http.Request, body []byte) *Error {
+	commitment, err := p.cfg.Extractor.Commitment(r, body)
+	if err != nil {
+		var rpcErr *Error
+		if errors.As(err, &rpcErr) {
+			return rpcErr
+		}
+		return &Error{Code: CodeMissingCommitment, Message: err.Error()}
+	}
+	access, err := p.cfg.Checker.Check(r.Context(), commitment)
+	if err != nil {
+		log.Warn("Stake check failed", "commitment", commitment, "err", err)
+		return &Error{Code: CodeCheckFailed, Message: "stake check unavailable, try again"}
+	}
+	data := &accessData{
+		Commitment:      commitment,
+		Block:           access.Block,
+		Stake:           (*hexutil.Big)(access.Stake),
+		MinimalStake:    (*hexutil.Big)(access.MinimalStake),
+		SubscriptionEnd: (*hexutil.Big)(access.SubscriptionEnd),
+	}
+	switch {
+	case !access.HasMinimalStake:
+		return &Error{Code: CodeInsufficientStake, Message: fmt.Sprintf("commitment stake %v is below the minimal stake %v", access.Stake, access.MinimalStake), Data: data}
+	case !access.Active:
+		return &Error{Code: CodeSubscriptionExpired, Message: fmt.Sprintf("subscription ended at block %v, head is %d", access.SubscriptionEnd, access.Block), Data: data}
+	}
+	return nil
+}
+
+func parseRequests(body []byte) ([]request, bool, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var reqs []request
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			return nil, true, err
+		}
+		if len(reqs) == 0 {
+			return nil, true, errors.New("empty batch")
+		}
+		return reqs, true, nil
+	}
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, false, err
+	}
+	return []request{req}, false, nil
+}
+
+// writeErrors answers every request with rpcErr.
+func writeErrors(w http.ResponseWriter, reqs []request, batch bool, rpcErr *Error) {
+	w.Header().Set("Content-Type", "application/json")
+	if len(reqs) == 0 {
+		reqs = []request{{ID: json.RawMessage("null")}}
+	}
+	resps := make([]response, len(reqs))
+	for i, req := range reqs {
+		id := req.ID
+		if id == nil {
+			id = json.RawMessage("null")
+		}
+		resps[i] = response{JSONRPC: "2.0", ID: id, Error: rpcErr}
+	}
+	if batch {
+		json.NewEncoder(w).Encode(resps)
+	} else {
+		json.NewEncoder(w).Encode(resps[0])
+	}
+}
@@ -0,0 +1,260 @@
+package gate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/primevprotocol/primev-contracts/pkg/proof"
+)
+
+// staticChecker answers from a map; unknown commitments fail the check.
+type staticChecker map[common.Hash]*Access
+
+func (c staticChecker) Check(ctx context.Context, commitment common.Hash) (*Access, error) {
+	if a, ok := c[commitment]; ok {
+		return a, nil
+	}
+	return nil, errors.New("node unavailable")
+}
+
+var (
+	allowed      = common.HexToHash("0x01")
+	understaked  = common.HexToHash("0x02")
+	expired      = common.HexToHash("0x03")
+	unavailable  = common.HexToHash("0x04")
+	testBuilder  = common.HexToAddress("0xb0")
+	minimalStake = big.NewInt(10)
+)
+
+func access(commitment common.Hash, stake, end int64) *Access {
+	a := &Access{
+		Commitment:      commitment,
+		Block:           100,
+		Stake:           big.NewInt(stake),
+		SubscriptionEnd: big.NewInt(end),
+		MinimalStake:    minimalStake,
+	}
+	a.HasMinimalStake = a.Stake.Cmp(minimalStake) >= 0
+	a.Active = end > 100
+	return a
+}
+
+var checker = staticChecker{
+	allowed:     access(allowed, 10, 200),
+	understaked: access(understaked, 9, 200),
+	expired:     access(expired, 10, 100),
+}
+
+// mockBuilder counts forwarded requests and echoes their bodies.
+type mockBuilder struct {
+	*httptest.Server
+	mu     sync.Mutex
+	bodies []string
+}
+
+func newMockBuilder(t *testing.T) *mockBuilder {
+	m := new(mockBuilder)
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		m.mu.Lock()
+		m.bodies = append(m.bodies, string(body))
+		m.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	t.Cleanup(m.Close)
+	return m
+}
+
+func (m *mockBuilder) forwarded() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.bodies...)
+}
+
+func newGate(t *testing.T, extractor Extractor) (*httptest.Server, *mockBuilder) {
+	builder := newMockBuilder(t)
+	target, _ := url.Parse(builder.URL)
+	gate := httptest.NewServer(NewProxy(Config{Target: target, Checker: checker, Extractor: extractor}))
+	t.Cleanup(gate.Close)
+	return gate, builder
+}
+
+func post(t *testing.T, url string, body string, header http.Header) (*http.Response, []byte) {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp, b
+}
+
+func commitmentHeader(c common.Hash) http.Header {
+	return http.Header{DefaultCommitmentHeader: {c.Hex()}}
+}
+
+const bundle = `{"jsonrpc":"2.0","id":7,"method":"eth_sendBundle","params":[{"txs":["0x01"]}]}`
+
+func TestProxyForwardsAllowed(t *testing.T) {
+	gate, builder := newGate(t, nil)
+	resp, body := post(t, gate.URL, bundle, commitmentHeader(allowed))
+	if resp.StatusCode != http.StatusOK || string(body) != bundle {
+		t.Fatalf("response = %d %s", resp.StatusCode, body)
+	}
+	if got := builder.forwarded(); len(got) != 1 || got[0] != bundle {
+		t.Fatalf("forwarded %q", got)
+	}
+}
+
+func TestProxyUngatedMethodPassesThrough(t *testing.T) {
+	gate, builder := newGate(t, nil)
+	call := `{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`
+	if resp, _ := post(t, gate.URL, call, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if got := builder.forwarded(); len(got) != 1 {
+		t.Fatalf("forwarded %d requests", len(got))
+	}
+}
+
+func TestProxyBatch(t *testing.T) {
+	gate, builder := newGate(t, nil)
+	batch := `[{"jsonrpc":"2.0","id":1,"method":"eth_chainId"},{"jsonrpc":"2.0","id":"b","method":"eth_sendBundle","params":[]}]`
+
+	if _, body := post(t, gate.URL, batch, commitmentHeader(allowed)); string(body) != batch {
+		t.Fatalf("allowed batch answered %s", body)
+	}
+
+	_, body := post(t, gate.URL, batch, commitmentHeader(understaked))
+	var resps []response
+	if err := json.Unmarshal(body, &resps); err != nil {
+		t.Fatalf("rejected batch answered %s: %v", body, err)
+	}
+	if len(resps) != 2 || string(resps[0].ID) != "1" || string(resps[1].ID) != `"b"` {
+		t.Fatalf("rejected batch answered %s", body)
+	}
+	for _, r := range resps {
+		if r.Error == nil || r.Error.Code != CodeInsufficientStake {
+			t.Errorf("response %s has error %+v", r.ID, r.Error)
+		}
+	}
+	if got := builder.forwarded(); len(got) != 1 {
+		t.Fatalf("forwarded %d requests, want only the allowed batch", len(got))
+	}
+}
+
+func TestProxyRejections(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	signed := func(body string) http.Header {
+		p, err := proof.Sign(key, testBuilder, time.Now().Unix(), []byte(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := http.Header{}
+		p.SetHeaders(h)
+		return h
+	}
+	checker[proof.Commitment(account, testBuilder)] = access(proof.Commitment(account, testBuilder), 10, 200)
+
+	tests := []struct {
+		name      string
+		extractor Extractor
+		body      string
+		header    http.Header
+		code      int
+		data      bool
+	}{
+		{"parse error", nil, `{"jsonrpc":`, commitmentHeader(allowed), CodeParseError, false},
+		{"empty batch", nil, `[]`, commitmentHeader(allowed), CodeParseError, false},
+		{"missing commitment", nil, bundle, nil, CodeMissingCommitment, false},
+		{"malformed commitment", nil, bundle, http.Header{DefaultCommitmentHeader: {"0x1234"}}, CodeMissingCommitment, false},
+		{"insufficient stake", nil, bundle, commitmentHeader(understaked), CodeInsufficientStake, true},
+		{"subscription expired", nil, bundle, commitmentHeader(expired), CodeSubscriptionExpired, true},
+		{"check failed", nil, bundle, commitmentHeader(unavailable), CodeCheckFailed, false},
+		{"missing proof", ProofExtractor{Verifier: proof.NewVerifier(testBuilder, 0, nil)}, bundle, commitmentHeader(allowed), CodeMissingCommitment, false},
+		{"invalid proof", ProofExtractor{Verifier: proof.NewVerifier(testBuilder, 0, nil)}, bundle, signed(`{"other":"body"}`), CodeInvalidProof, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gate, builder := newGate(t, tt.extractor)
+			resp, body := post(t, gate.URL, tt.body, tt.header)
+			var r response
+			if err := json.Unmarshal(body, &r); err != nil {
+				t.Fatalf("answered %d %s: %v", resp.StatusCode, body, err)
+			}
+			if r.Error == nil || r.Error.Code != tt.code {
+				t.Fatalf("answered %s, want code %d", body, tt.code)
+			}
+			if (r.Error.Data != nil) != tt.data {
+				t.Errorf("error data = %v", r.Error.Data)
+			}
+			if got := builder.forwarded(); len(got) != 0 {
+				t.Errorf("forwarded %q", got)
+			}
+		})
+	}
+
+	t.Run("valid proof", func(t *testing.T) {
+		gate, builder := newGate(t, ProofExtractor{Verifier: proof.NewVerifier(testBuilder, 0, nil)})
+		if _, body := post(t, gate.URL, bundle, signed(bundle)); string(body) != bundle {
+			t.Fatalf("answered %s", body)
+		}
+		if got := builder.forwarded(); len(got) != 1 {
+			t.Fatalf("forwarded %d requests", len(got))
+		}
+	})
+}
+
+func TestProxyBodyTooLarge(t *testing.T) {
+	gate, builder := newGate(t, nil)
+	large := `{"jsonrpc":"2.0","id":1,"method":"eth_sendBundle","params":["` + strings.Repeat("a", maxBodySize) + `"]}`
+	_, body := post(t, gate.URL, large, commitmentHeader(allowed))
+	var r response
+	if err := json.Unmarshal(body, &r); err != nil || r.Error == nil || r.Error.Code != CodeInvalidRequest {
+		t.Fatalf("answered %.200s", body)
+	}
+	if got := builder.forwarded(); len(got) != 0 {
+		t.Fatalf("forwarded %d requests", len(got))
+	}
+}
+
+func TestProxyRejectsOtherMethods(t *testing.T) {
+	gate, builder := newGate(t, nil)
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		req, _ := http.NewRequest(method, gate.URL, bytes.NewReader([]byte(bundle)))
+		req.Header.Set(DefaultCommitmentHeader, allowed.Hex())
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed || resp.Header.Get("Allow") != http.MethodPost {
+			t.Errorf("%s answered %d", method, resp.StatusCode)
+		}
+	}
+	if got := builder.forwarded(); len(got) != 0 {
+		t.Fatalf("forwarded %d requests", len(got))
+	}
+}
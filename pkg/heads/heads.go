@@ -0,0 +1,92 @@
+// Package heads delivers new chain heads to long-running services, using a
+// subscription when the node supports it and polling otherwise.
+package heads
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// DefaultPollInterval is used by Watch when no interval is given.
+const DefaultPollInterval = 4 * time.Second
+
+// Reader reads chain heads.
+type Reader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// Subscriber is implemented by backends supporting eth_subscribe("newHeads").
+type Subscriber interface {
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
+// Watch calls fn for every new head until ctx is done or fn returns an error.
+// Heads are delivered in order of arrival; a reorg may deliver the same number
+// twice. If the subscription fails Watch falls back to polling every interval.
+func Watch(ctx context.Context, backend Reader, interval time.Duration, fn func(*types.Header) error) error {
+	if interval == 0 {
+		interval = DefaultPollInterval
+	}
+	if sub, ok := backend.(Subscriber); ok {
+		ch := make(chan *types.Header, 16)
+		s, err := sub.SubscribeNewHead(ctx, ch)
+		if err == nil {
+			err = watchSubscription(ctx, s, ch, fn)
+			if ctx.Err() != nil || !isSubscriptionError(err) {
+				return err
+			}
+			log.Warn("Head subscription failed, falling back to polling", "err", err)
+		}
+	}
+	return poll(ctx, backend, interval, fn)
+}
+
+type subscriptionError struct{ error }
+
+func isSubscriptionError(err error) bool {
+	_, ok := err.(subscriptionError)
+	return ok
+}
+
+func watchSubscription(ctx context.Context, s ethereum.Subscription, ch <-chan *types.Header, fn func(*types.Header) error) error {
+	defer s.Unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-s.Err():
+			return subscriptionError{err}
+		case head := <-ch:
+			if err := fn(head); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func poll(ctx context.Context, backend Reader, interval time.Duration, fn func(*types.Header) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last *types.Header
+	for {
+		head, err := backend.HeaderByNumber(ctx, nil)
+		if err != nil {
+			log.Warn("Failed to fetch head", "err", err)
+		} else if last == nil || head.Hash() != last.Hash() {
+			last = head
+			if err := fn(head); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Package history answers questions about past BuilderStaking state, such as
+// the stake of a commitment and the builder's terms at a given block, or
+// whether a commitment was eligible when a bundle landed.
+//
+// Reads go to the node at the requested block first, which needs an archive
+// node for anything older than the recent state a full node keeps. If that
+// fails the answer is replayed from StakeUpdated and BuilderUpdated events,
+// which carry absolute values and therefore determine the state exactly.
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// Source tells where an answer came from.
+type Source string
+
+const (
+	Archive Source = "archive" // State read at the block
+	Events  Source = "events"  // Replayed from indexed events
+)
+
+// ErrFutureBlock is returned for queries after the current head.
+var ErrFutureBlock = errors.New("history: block is after the head")
+
+// Backend is the node connection needed by History.
+type Backend interface {
+	bind.ContractBackend
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// DefaultConfirmations is the depth at which replayed events are cached if
+// Config.Confirmations is zero.
+const DefaultConfirmations = 64
+
+// Config configures a History.
+type Config struct {
+	FromBlock     uint64 // Block event replay starts at, must not be after the deployment block
+	Step          uint64 // Blocks per log query, events.DefaultStep if zero
+	Confirmations uint64 // Blocks behind the head before replayed events are cached
+	NoArchive     bool   // Always replay from events
+}
+
+// Stake is a commitment's stake after a block.
+type Stake struct {
+	Block           uint64
+	Source          Source
+	Stake           *big.Int
+	SubscriptionEnd *big.Int
+}
+
+// Terms are a builder's terms after a block.
+type Terms struct {
+	Block                     uint64
+	Source                    Source
+	MinimalStake              *big.Int
+	MinimalSubscriptionPeriod *big.Int
+}
+
+// Eligibility describes a commitment's access to a builder after a block.
+type Eligibility struct {
+	Block  uint64
+	Stake  Stake
+	Terms  Terms
+	Active bool // Subscription end lies after Block
+
+	// HasMinimalStake is what hasMinimalStake returned, the check builders
+	// gate bundles on.
+	HasMinimalStake bool
+}
+
+// History queries past state of a BuilderStaking contract.
+type History struct {
+	cfg     Config
+	backend Backend
+	caller  *primev.BuilderStakingCaller
+
+	mu    sync.Mutex
+	index *index
+}
+
+// New creates a History for the BuilderStaking contract at address.
+func New(address common.Address, backend Backend, cfg Config) (*History, error) {
+	contract, err := primev.NewBuilderStaking(address, backend)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Confirmations == 0 {
+		cfg.Confirmations = DefaultConfirmations
+	}
+	return &History{
+		cfg:     cfg,
+		backend: backend,
+		caller:  &contract.BuilderStakingCaller,
+		index:   newIndex(&contract.BuilderStakingFilterer, cfg.Step, cfg.FromBlock),
+	}, nil
+}
+
+// Stakes returns the stake of commitment after block, which must not be after
+// the head.
+func (h *History) Stakes(ctx context.Context, commitment [32]byte, block uint64) (Stake, error) {
+	if !h.cfg.NoArchive {
+		s, err := h.caller.Stakes(h.opts(ctx, block), commitment)
+		if err == nil {
+			return Stake{Block: block, Source: Archive, Stake: s.Stake, SubscriptionEnd: s.SubscriptionEnd}, nil
+		}
+		log.Debug("Archive read failed, replaying events", "block", block, "err", err)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	recent, err := h.replay(ctx, block)
+	if err != nil {
+		return Stake{}, err
+	}
+	result := Stake{Block: block, Source: Events, Stake: new(big.Int), SubscriptionEnd: new(big.Int)}
+	if p := h.index.stake(recent, commitment, block); p != nil {
+		result.Stake, result.SubscriptionEnd = p.a, p.b
+	}
+	return result, nil
+}
+
+// Builders returns the terms of builder after block, which must not be after
+// the head.
+func (h *History) Builders(ctx context.Context, builder common.Address, block uint64) (Terms, error) {
+	if !h.cfg.NoArchive {
+		t, err := h.caller.Builders(h.opts(ctx, block), builder)
+		if err == nil {
+			return Terms{Block: block, Source: Archive, MinimalStake: t.MinimalStake, MinimalSubscriptionPeriod: t.MinimalSubscriptionPeriod}, nil
+		}
+		log.Debug("Archive read failed, replaying events", "block", block, "err", err)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	recent, err := h.replay(ctx, block)
+	if err != nil {
+		return Terms{}, err
+	}
+	result := Terms{Block: block, Source: Events, MinimalStake: new(big.Int), MinimalSubscriptionPeriod: new(big.Int)}
+	if p := h.index.terms(recent, builder, block); p != nil {
+		result.MinimalStake, result.MinimalSubscriptionPeriod = p.a, p.b
+	}
+	return result, nil
+}
+
+// Eligible returns whether commitment had access to builder after block.
+func (h *History) Eligible(ctx context.Context, builder common.Address, commitment [32]byte, block uint64) (Eligibility, error) {
+	stake, err := h.Stakes(ctx, commitment, block)
+	if err != nil {
+		return Eligibility{}, err
+	}
+	terms, err := h.Builders(ctx, builder, block)
+	if err != nil {
+		return Eligibility{}, err
+	}
+	return Eligibility{
+		Block:           block,
+		Stake:           stake,
+		Terms:           terms,
+		Active:          stake.SubscriptionEnd.Cmp(new(big.Int).SetUint64(block)) > 0,
+		HasMinimalStake: terms.MinimalStake.Sign() > 0 && stake.Stake.Cmp(terms.MinimalStake) >= 0,
+	}, nil
+}
+
+// EligibleAtInclusion returns whether commitment had access to builder when
+// the bundle containing tx landed. The builder decided on the bundle while
+// building on the parent block, so the state after the parent is used.
+func (h *History) EligibleAtInclusion(ctx context.Context, builder common.Address, commitment [32]byte, tx common.Hash) (Eligibility, error) {
+	receipt, err := h.backend.TransactionReceipt(ctx, tx)
+	if err != nil {
+		return Eligibility{}, err
+	}
+	parent := receipt.BlockNumber.Uint64()
+	if parent > 0 {
+		parent--
+	}
+	return h.Eligible(ctx, builder, commitment, parent)
+}
+
+// replay indexes events up to block. Blocks at least Confirmations behind the
+// head are added to the cached index; the events of later blocks, up to the
+// head, are returned in a separate index so a reorg never leaves them cached.
+// The caller must hold h.mu.
+func (h *History) replay(ctx context.Context, block uint64) (*index, error) {
+	header, err := h.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	head := header.Number.Uint64()
+	if block > head {
+		return nil, fmt.Errorf("%w: %d > %d", ErrFutureBlock, block, head)
+	}
+	var final uint64
+	if head > h.cfg.Confirmations {
+		final = head - h.cfg.Confirmations
+	}
+	if err := h.index.extend(ctx, min(block, final)); err != nil {
+		return nil, err
+	}
+	if block < h.index.next {
+		return nil, nil
+	}
+	recent := newIndex(h.index.filterer, h.index.step, h.index.next)
+	if err := recent.extend(ctx, block); err != nil {
+		return nil, err
+	}
+	return recent, nil
+}
+
+func min(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func (h *History) opts(ctx context.Context, block uint64) *bind.CallOpts {
+	return &bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(block)}
+}
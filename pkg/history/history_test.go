@@ -0,0 +1,234 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+var contractABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(primev.BuilderStakingMetaData.ABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// chain serves contract logs and answers stakes and builders reads from them,
+// like an archive node, for blocks from pruned on. Methods History does not
+// use are left to the nil embedded Backend.
+type chain struct {
+	Backend
+	head   uint64
+	pruned uint64 // First block with state
+	logs   []types.Log
+	reads  int // Archive reads answered
+}
+
+func (c *chain) emit(block uint64, name string, args ...interface{}) {
+	ev := contractABI.Events[name]
+	data, err := ev.Inputs.Pack(args...)
+	if err != nil {
+		panic(err)
+	}
+	c.logs = append(c.logs, types.Log{
+		Topics:      []common.Hash{ev.ID},
+		Data:        data,
+		BlockNumber: block,
+		Index:       uint(len(c.logs)),
+	})
+}
+
+// latest returns the values of the last name event matching key up to block.
+func (c *chain) latest(name string, key interface{}, block uint64) []interface{} {
+	ev := contractABI.Events[name]
+	var values []interface{}
+	for _, l := range c.logs {
+		if l.Topics[0] != ev.ID || l.BlockNumber > block {
+			continue
+		}
+		v, err := ev.Inputs.Unpack(l.Data)
+		if err != nil {
+			panic(err)
+		}
+		if v[0] == key || v[1] == key {
+			values = v
+		}
+	}
+	return values
+}
+
+func (c *chain) CallContract(ctx context.Context, call ethereum.CallMsg, number *big.Int) ([]byte, error) {
+	block := number.Uint64()
+	if block > c.head {
+		return nil, errors.New("header not found")
+	}
+	if block < c.pruned {
+		return nil, errors.New("missing trie node")
+	}
+	method, err := contractABI.MethodById(call.Data[:4])
+	if err != nil {
+		return nil, err
+	}
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	c.reads++
+	switch method.Name {
+	case "stakes":
+		// StakeUpdated(builder, commitment, stake, subscriptionEnd)
+		if v := c.latest("StakeUpdated", args[0], block); v != nil {
+			return method.Outputs.Pack(v[3], v[2])
+		}
+		return method.Outputs.Pack(new(big.Int), new(big.Int))
+	case "builders":
+		if v := c.latest("BuilderUpdated", args[0], block); v != nil {
+			return method.Outputs.Pack(v[1], v[2])
+		}
+		return method.Outputs.Pack(new(big.Int), new(big.Int))
+	}
+	return nil, fmt.Errorf("unexpected call to %s", method.Name)
+}
+
+func (c *chain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	var logs []types.Log
+	for _, l := range c.logs {
+		if l.Topics[0] != q.Topics[0][0] || l.BlockNumber < q.FromBlock.Uint64() || l.BlockNumber > q.ToBlock.Uint64() {
+			continue
+		}
+		logs = append(logs, l)
+	}
+	return logs, nil
+}
+
+func (c *chain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: new(big.Int).SetUint64(c.head)}, nil
+}
+
+func (c *chain) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	return &types.Receipt{TxHash: hash, BlockNumber: new(big.Int).SetBytes(hash[:])}, nil
+}
+
+var (
+	builder    = common.HexToAddress("0xb")
+	commitment = common.HexToHash("0x01")
+)
+
+// newChain has the builder ask for 10 wei from block 2 and 20 wei from block
+// 6, and the commitment stake 15 wei until block 50 in block 4.
+func newChain() *chain {
+	c := &chain{head: 100}
+	c.emit(2, "BuilderUpdated", builder, big.NewInt(10), big.NewInt(5))
+	c.emit(4, "StakeUpdated", builder, commitment, big.NewInt(15), big.NewInt(50))
+	c.emit(6, "BuilderUpdated", builder, big.NewInt(20), big.NewInt(5))
+	return c
+}
+
+func TestEligible(t *testing.T) {
+	tests := []struct {
+		name      string
+		pruned    uint64
+		noArchive bool
+		block     uint64
+		source    Source
+		stake     int64
+		minimal   int64
+		eligible  bool
+		active    bool
+		reads     int
+	}{
+		{name: "archive", block: 5, source: Archive, stake: 15, minimal: 10, eligible: true, active: true, reads: 2},
+		{name: "archive before deposit", block: 3, source: Archive, minimal: 10, reads: 2},
+		{name: "archive after raise", block: 6, source: Archive, stake: 15, minimal: 20, active: true, reads: 2},
+		{name: "pruned", pruned: 50, block: 5, source: Events, stake: 15, minimal: 10, eligible: true, active: true},
+		{name: "pruned after expiry", pruned: 90, block: 60, source: Events, stake: 15, minimal: 20},
+		{name: "no archive", noArchive: true, block: 5, source: Events, stake: 15, minimal: 10, eligible: true, active: true},
+		{name: "head", block: 100, source: Archive, stake: 15, minimal: 20, reads: 2},
+	}
+	for _, tt := range tests {
+		c := newChain()
+		c.pruned = tt.pruned
+		h, err := New(common.Address{}, c, Config{Step: 3, NoArchive: tt.noArchive})
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := h.Eligible(context.Background(), builder, commitment, tt.block)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if e.Block != tt.block || e.Stake.Block != tt.block || e.Terms.Block != tt.block {
+			t.Errorf("%s: blocks %d/%d/%d, want %d", tt.name, e.Block, e.Stake.Block, e.Terms.Block, tt.block)
+		}
+		if e.Stake.Source != tt.source || e.Terms.Source != tt.source {
+			t.Errorf("%s: sources %s/%s, want %s", tt.name, e.Stake.Source, e.Terms.Source, tt.source)
+		}
+		if e.Stake.Stake.Int64() != tt.stake || e.Terms.MinimalStake.Int64() != tt.minimal {
+			t.Errorf("%s: stake %v, minimal stake %v; want %d, %d", tt.name, e.Stake.Stake, e.Terms.MinimalStake, tt.stake, tt.minimal)
+		}
+		if e.HasMinimalStake != tt.eligible || e.Active != tt.active {
+			t.Errorf("%s: has minimal stake %v, active %v; want %v, %v", tt.name, e.HasMinimalStake, e.Active, tt.eligible, tt.active)
+		}
+		if c.reads != tt.reads {
+			t.Errorf("%s: %d archive reads, want %d", tt.name, c.reads, tt.reads)
+		}
+	}
+}
+
+func TestFutureBlock(t *testing.T) {
+	for _, noArchive := range []bool{false, true} {
+		h, err := New(common.Address{}, newChain(), Config{NoArchive: noArchive})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := h.Eligible(context.Background(), builder, commitment, 101); !errors.Is(err, ErrFutureBlock) {
+			t.Errorf("no archive %v: err %v, want %v", noArchive, err, ErrFutureBlock)
+		}
+	}
+}
+
+func TestRecentEventsNotCached(t *testing.T) {
+	c := newChain()
+	c.head = 10
+	h, err := New(common.Address{}, c, Config{Confirmations: 5, NoArchive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := h.Stakes(context.Background(), commitment, 10)
+	if err != nil || s.Stake.Int64() != 15 {
+		t.Fatalf("stake %v, %v; want 15", s.Stake, err)
+	}
+	// Block 6 and later are not final yet; a reorg replaces the raise.
+	c.logs = c.logs[:2]
+	c.emit(7, "BuilderUpdated", builder, big.NewInt(30), big.NewInt(5))
+	terms, err := h.Builders(context.Background(), builder, 10)
+	if err != nil || terms.MinimalStake.Int64() != 30 {
+		t.Errorf("minimal stake %v, %v; want 30", terms.MinimalStake, err)
+	}
+}
+
+func TestEligibleAtInclusion(t *testing.T) {
+	h, err := New(common.Address{}, newChain(), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fake receipt's block is the hash's value: the bundle landed in
+	// block 6, which raised the minimal stake, and was built on block 5.
+	e, err := h.EligibleAtInclusion(context.Background(), builder, commitment, common.BigToHash(big.NewInt(6)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Block != 5 || !e.HasMinimalStake {
+		t.Errorf("block %d, has minimal stake %v; want 5, true", e.Block, e.HasMinimalStake)
+	}
+}
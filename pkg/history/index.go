@@ -0,0 +1,100 @@
+package history
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/primevprotocol/primev-contracts/pkg/events"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// point is a pair of values that took effect in block.
+type point struct {
+	block uint64
+	a, b  *big.Int
+}
+
+// series is the ordered history of one key.
+type series []point
+
+// at returns the last value in effect after block, or nil.
+func (s series) at(block uint64) *point {
+	i := sort.Search(len(s), func(i int) bool { return s[i].block > block })
+	if i == 0 {
+		return nil
+	}
+	return &s[i-1]
+}
+
+// index holds StakeUpdated and BuilderUpdated history from events. Both events
+// carry absolute values, so the latest event before a block fully determines
+// the state at that block.
+//
+// History keeps one index of final blocks and builds a throwaway one for the
+// blocks after them, which a reorg may still change.
+type index struct {
+	filterer *primev.BuilderStakingFilterer
+	step     uint64
+	next     uint64 // First block not yet indexed
+
+	stakes   map[[32]byte]series       // a: stake, b: subscriptionEnd
+	builders map[common.Address]series // a: minimalStake, b: minimalSubscriptionPeriod
+}
+
+func newIndex(filterer *primev.BuilderStakingFilterer, step, next uint64) *index {
+	return &index{
+		filterer: filterer,
+		step:     step,
+		next:     next,
+		stakes:   make(map[[32]byte]series),
+		builders: make(map[common.Address]series),
+	}
+}
+
+// extend indexes events up to and including block.
+func (ix *index) extend(ctx context.Context, block uint64) error {
+	if block < ix.next {
+		return nil
+	}
+	rng := events.Range{From: ix.next, To: block, Step: ix.step}
+	err := events.StakeUpdated(ctx, ix.filterer, rng, func(e *primev.BuilderStakingStakeUpdated) error {
+		ix.stakes[e.Commitment] = append(ix.stakes[e.Commitment], point{e.Raw.BlockNumber, e.Stake, e.SubscriptionEnd})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = events.BuilderUpdated(ctx, ix.filterer, rng, func(e *primev.BuilderStakingBuilderUpdated) error {
+		ix.builders[e.Builder] = append(ix.builders[e.Builder], point{e.Raw.BlockNumber, e.MinimalStake, e.MinimalSubsriptionPeriod})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	ix.next = block + 1
+	return nil
+}
+
+// stake returns the stake point of commitment after block, looking at recent
+// first if set.
+func (ix *index) stake(recent *index, commitment [32]byte, block uint64) *point {
+	if recent != nil {
+		if p := recent.stakes[commitment].at(block); p != nil {
+			return p
+		}
+	}
+	return ix.stakes[commitment].at(block)
+}
+
+// terms returns the terms point of builder after block, looking at recent
+// first if set.
+func (ix *index) terms(recent *index, builder common.Address, block uint64) *point {
+	if recent != nil {
+		if p := recent.builders[builder].at(block); p != nil {
+			return p
+		}
+	}
+	return ix.builders[builder].at(block)
+}
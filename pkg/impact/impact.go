@@ -0,0 +1,112 @@
+// Package impact analyzes what a change of builder terms does to existing
+// subscribers.
+//
+// hasMinimalStake compares a commitment's cumulative stake with the builder's
+// current minimalStake, so raising it with UpdateBuilder immediately
+// disqualifies every subscriber below the new value, even though their
+// subscription end is unchanged. The blocks they paid for until then are
+// forfeited unless they deposit again, and deposits must be at least the new
+// minimal stake.
+package impact
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/primevprotocol/primev-contracts/pkg/terms"
+)
+
+// Subscriber is a commitment with a stake. Stakes are keyed by commitment
+// only and hasMinimalStake does not check which builder they were deposited
+// for, so a subscriber counts for every builder whose minimal stake it holds.
+type Subscriber struct {
+	Commitment      common.Hash
+	Builder         common.Address // Builder of the last deposit
+	Stake           *big.Int
+	SubscriptionEnd *big.Int
+}
+
+// Loss describes a subscriber that would lose eligibility.
+type Loss struct {
+	Subscriber
+	RemainingBlocks *big.Int // Paid-for blocks after the analyzed block
+	ForfeitedWei    *big.Rat // RemainingBlocks at the current nominal price
+	Shortfall       *big.Int // Stake missing for the proposed minimal stake
+}
+
+// Report is the impact of replacing Current with Proposed at Block.
+type Report struct {
+	Builder  common.Address
+	Block    uint64
+	Current  terms.Terms
+	Proposed terms.Terms
+
+	Eligible        int          // Subscribers eligible under Current
+	Losing          []Loss       // Subscribers eligible under Current but not Proposed
+	Gaining         []Subscriber // Active subscribers eligible under Proposed but not Current
+	ForfeitedBlocks *big.Int
+	ForfeitedWei    *big.Rat
+
+	// RestoreDeposit is the smallest deposit that makes a losing subscriber
+	// eligible again, since deposit requires at least the minimal stake.
+	RestoreDeposit *big.Int
+}
+
+// Analyze lists the subscribers that are eligible at block under current but
+// would not be under proposed, and those a lower minimal stake makes eligible.
+// Subscribers whose subscription ended by block are not eligible either way
+// and are ignored. subs should hold every commitment with a stake, not only
+// the ones that deposited with builder.
+func Analyze(builder common.Address, current, proposed terms.Terms, block uint64, subs []Subscriber) *Report {
+	r := &Report{
+		Builder:         builder,
+		Block:           block,
+		Current:         current,
+		Proposed:        proposed,
+		ForfeitedBlocks: new(big.Int),
+		ForfeitedWei:    new(big.Rat),
+		RestoreDeposit:  proposed.MinimalStake,
+	}
+	var price *big.Rat
+	if current.MinimalSubscriptionPeriod != nil && current.MinimalSubscriptionPeriod.Sign() > 0 {
+		price = new(big.Rat).SetFrac(current.MinimalStake, current.MinimalSubscriptionPeriod)
+	}
+	head := new(big.Int).SetUint64(block)
+	for _, s := range subs {
+		if s.SubscriptionEnd.Cmp(head) <= 0 {
+			continue
+		}
+		was, will := eligible(current.MinimalStake, s.Stake), eligible(proposed.MinimalStake, s.Stake)
+		if !was {
+			if will {
+				r.Gaining = append(r.Gaining, s)
+			}
+			continue
+		}
+		r.Eligible++
+		if will {
+			continue
+		}
+		loss := Loss{
+			Subscriber:      s,
+			RemainingBlocks: new(big.Int).Sub(s.SubscriptionEnd, head),
+			ForfeitedWei:    new(big.Rat),
+			Shortfall:       new(big.Int),
+		}
+		if price != nil {
+			loss.ForfeitedWei.Mul(new(big.Rat).SetInt(loss.RemainingBlocks), price)
+		}
+		if proposed.MinimalStake != nil && proposed.MinimalStake.Cmp(s.Stake) > 0 {
+			loss.Shortfall.Sub(proposed.MinimalStake, s.Stake)
+		}
+		r.Losing = append(r.Losing, loss)
+		r.ForfeitedBlocks.Add(r.ForfeitedBlocks, loss.RemainingBlocks)
+		r.ForfeitedWei.Add(r.ForfeitedWei, loss.ForfeitedWei)
+	}
+	return r
+}
+
+// eligible applies hasMinimalStake.
+func eligible(minimalStake, stake *big.Int) bool {
+	return minimalStake != nil && minimalStake.Sign() > 0 && stake.Cmp(minimalStake) >= 0
+}
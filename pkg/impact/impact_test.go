@@ -0,0 +1,47 @@
+package impact
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/primevprotocol/primev-contracts/pkg/terms"
+)
+
+func sub(commitment byte, stake, end int64) Subscriber {
+	return Subscriber{Commitment: common.Hash{commitment}, Stake: big.NewInt(stake), SubscriptionEnd: big.NewInt(end)}
+}
+
+func TestAnalyze(t *testing.T) {
+	subs := []Subscriber{sub(1, 10, 200), sub(2, 20, 200), sub(3, 30, 200), sub(4, 10, 50)}
+	tests := []struct {
+		name             string
+		current, changed int64
+		eligible         int
+		losing, gaining  []byte
+		forfeited        int64
+	}{
+		// Commitment 4 ended at block 50 and is ignored.
+		{name: "raised", current: 20, changed: 30, eligible: 2, losing: []byte{2}, forfeited: 100},
+		{name: "lowered", current: 20, changed: 10, eligible: 2, gaining: []byte{1}},
+		{name: "first terms", current: 0, changed: 20, gaining: []byte{2, 3}},
+		{name: "unchanged", current: 20, changed: 20, eligible: 2},
+	}
+	for _, tt := range tests {
+		current := terms.Terms{MinimalStake: big.NewInt(tt.current), MinimalSubscriptionPeriod: big.NewInt(100)}
+		proposed := terms.Terms{MinimalStake: big.NewInt(tt.changed), MinimalSubscriptionPeriod: big.NewInt(100)}
+		r := Analyze(common.Address{}, current, proposed, 100, subs)
+		var losing, gaining []byte
+		for _, l := range r.Losing {
+			losing = append(losing, l.Commitment[0])
+		}
+		for _, s := range r.Gaining {
+			gaining = append(gaining, s.Commitment[0])
+		}
+		if r.Eligible != tt.eligible || string(losing) != string(tt.losing) || string(gaining) != string(tt.gaining) ||
+			r.ForfeitedBlocks.Int64() != tt.forfeited {
+			t.Errorf("%s: eligible %d, losing %v, gaining %v, forfeited %v blocks; want %d, %v, %v, %d",
+				tt.name, r.Eligible, losing, gaining, r.ForfeitedBlocks, tt.eligible, tt.losing, tt.gaining, tt.forfeited)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package impact
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/primevprotocol/primev-contracts/pkg/events"
+	"github.com/primevprotocol/primev-contracts/pkg/heads"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+	"github.com/primevprotocol/primev-contracts/pkg/terms"
+)
+
+// Backend is the node connection needed by Tracker.
+type Backend interface {
+	bind.ContractFilterer
+	heads.Reader
+}
+
+// Config configures a Tracker.
+type Config struct {
+	FromBlock    uint64           // Block event replay starts at, must not be after the deployment block
+	Step         uint64           // Blocks per log query, events.DefaultStep if zero
+	Builders     []common.Address // Builders to report term changes of, all if empty
+	Commitments  []common.Hash    // Only report losses of these commitments, all if empty
+	PollInterval time.Duration    // Head polling interval when subscriptions are unsupported
+	OnChange     func(*Report)    // Called for every BuilderUpdated event after the first head
+}
+
+// Tracker replays StakeUpdated and BuilderUpdated events to know every
+// builder's terms and every commitment's stake. It analyzes proposed terms for builders
+// and, while running, reports the impact of each BuilderUpdated event as it
+// appears, which lets searchers learn that a builder disqualified them.
+//
+// Replayed state only moves forward: after the first replay each head reads
+// the blocks after the last replayed one. Events of reorged-out blocks stay
+// applied and events a reorg adds at or below that block are not read, so
+// reports can be wrong until the affected commitment or builder emits its next
+// event. Callers needing reorg-safe state should use pkg/stakeview.
+type Tracker struct {
+	cfg         Config
+	backend     Backend
+	filterer    *primev.BuilderStakingFilterer
+	builders    map[common.Address]bool
+	commitments map[common.Hash]bool
+
+	mu     sync.Mutex
+	synced uint64 // Last replayed block
+	terms  map[common.Address]terms.Terms
+	stakes map[common.Hash]Subscriber
+}
+
+// New creates a Tracker for the BuilderStaking contract at address.
+func New(address common.Address, backend Backend, cfg Config) (*Tracker, error) {
+	filterer, err := primev.NewBuilderStakingFilterer(address, backend)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tracker{
+		cfg:         cfg,
+		backend:     backend,
+		filterer:    filterer,
+		builders:    make(map[common.Address]bool),
+		commitments: make(map[common.Hash]bool),
+		terms:       make(map[common.Address]terms.Terms),
+		stakes:      make(map[common.Hash]Subscriber),
+	}
+	for _, b := range cfg.Builders {
+		t.builders[b] = true
+	}
+	for _, c := range cfg.Commitments {
+		t.commitments[c] = true
+	}
+	return t, nil
+}
+
+// Preflight replays events up to the latest block and analyzes replacing
+// builder's terms with proposed there.
+func (t *Tracker) Preflight(ctx context.Context, builder common.Address, proposed terms.Terms) (*Report, error) {
+	head, err := t.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	number := head.Number.Uint64()
+	if err := t.sync(ctx, number, nil); err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return Analyze(builder, t.current(builder), proposed, number, t.subscribers()), nil
+}
+
+// Run replays events and then reports term changes on new heads until ctx is
+// done.
+func (t *Tracker) Run(ctx context.Context) error {
+	first := true
+	return heads.Watch(ctx, t.backend, t.cfg.PollInterval, func(head *types.Header) error {
+		var report func(*Report)
+		if !first {
+			report = t.cfg.OnChange
+		}
+		if err := t.sync(ctx, head.Number.Uint64(), report); err != nil {
+			log.Warn("Failed to read builder updates", "block", head.Number, "err", err)
+			return nil
+		}
+		first = false
+		return nil
+	})
+}
+
+// change is a StakeUpdated or BuilderUpdated event.
+type change struct {
+	block, index uint64
+	builder      common.Address
+	stake        *Subscriber
+	terms        *terms.Terms
+}
+
+// sync applies events up to block in log order, calling report for every
+// BuilderUpdated event of a watched builder.
+func (t *Tracker) sync(ctx context.Context, block uint64, report func(*Report)) error {
+	t.mu.Lock()
+	from := t.synced + 1
+	if t.synced == 0 {
+		from = t.cfg.FromBlock
+	}
+	t.mu.Unlock()
+	if from > block {
+		// Nothing new, or a reorg to a lower height. Only the head block is
+		// read again; see the Tracker doc for what reorgs leave behind.
+		from = block
+	}
+
+	var changes []change
+	rng := events.Range{From: from, To: block, Step: t.cfg.Step}
+	err := events.StakeUpdated(ctx, t.filterer, rng, func(e *primev.BuilderStakingStakeUpdated) error {
+		changes = append(changes, change{block: e.Raw.BlockNumber, index: uint64(e.Raw.Index), builder: e.Builder, stake: &Subscriber{
+			Commitment:      common.Hash(e.Commitment),
+			Builder:         e.Builder,
+			Stake:           e.Stake,
+			SubscriptionEnd: e.SubscriptionEnd,
+		}})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = events.BuilderUpdated(ctx, t.filterer, rng, func(e *primev.BuilderStakingBuilderUpdated) error {
+		changes = append(changes, change{block: e.Raw.BlockNumber, index: uint64(e.Raw.Index), builder: e.Builder, terms: &terms.Terms{
+			MinimalStake:              e.MinimalStake,
+			MinimalSubscriptionPeriod: e.MinimalSubsriptionPeriod,
+		}})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].block != changes[j].block {
+			return changes[i].block < changes[j].block
+		}
+		return changes[i].index < changes[j].index
+	})
+
+	var reports []*Report
+	t.mu.Lock()
+	for _, c := range changes {
+		if c.stake != nil {
+			t.stakes[c.stake.Commitment] = *c.stake
+			continue
+		}
+		current := t.current(c.builder)
+		if report != nil && (len(t.builders) == 0 || t.builders[c.builder]) && !same(current, *c.terms) {
+			reports = append(reports, Analyze(c.builder, current, *c.terms, c.block, t.subscribers()))
+		}
+		t.terms[c.builder] = *c.terms
+	}
+	t.synced = block
+	t.mu.Unlock()
+
+	for _, r := range reports {
+		log.Info("Builder terms changed", "builder", r.Builder, "block", r.Block,
+			"minimalStake", r.Proposed.MinimalStake, "period", r.Proposed.MinimalSubscriptionPeriod,
+			"eligible", r.Eligible, "losing", len(r.Losing), "gaining", len(r.Gaining), "forfeitedBlocks", r.ForfeitedBlocks)
+		report(r)
+	}
+	return nil
+}
+
+// current returns builder's replayed terms, zero if it never set any.
+func (t *Tracker) current(builder common.Address) terms.Terms {
+	if c, ok := t.terms[builder]; ok {
+		return c
+	}
+	return terms.Terms{MinimalStake: new(big.Int), MinimalSubscriptionPeriod: new(big.Int)}
+}
+
+// subscribers returns the watched commitments, whichever builder they
+// deposited with; see Subscriber.
+func (t *Tracker) subscribers() []Subscriber {
+	var subs []Subscriber
+	for c, s := range t.stakes {
+		if len(t.commitments) == 0 || t.commitments[c] {
+			subs = append(subs, s)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		return bytes.Compare(subs[i].Commitment[:], subs[j].Commitment[:]) < 0
+	})
+	return subs
+}
+
+func same(a, b terms.Terms) bool {
+	return a.MinimalStake.Cmp(b.MinimalStake) == 0 && a.MinimalSubscriptionPeriod.Cmp(b.MinimalSubscriptionPeriod) == 0
+}
@@ -0,0 +1,110 @@
+package impact
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+	"github.com/primevprotocol/primev-contracts/pkg/terms"
+)
+
+var contractABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(primev.BuilderStakingMetaData.ABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// chain serves a fixed list of contract logs.
+type chain struct {
+	head uint64
+	logs []types.Log
+}
+
+func (c *chain) emit(block uint64, name string, args ...interface{}) {
+	ev := contractABI.Events[name]
+	data, err := ev.Inputs.Pack(args...)
+	if err != nil {
+		panic(err)
+	}
+	c.logs = append(c.logs, types.Log{
+		Topics:      []common.Hash{ev.ID},
+		Data:        data,
+		BlockNumber: block,
+		Index:       uint(len(c.logs)),
+	})
+}
+
+func (c *chain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	var logs []types.Log
+	for _, l := range c.logs {
+		if l.Topics[0] != q.Topics[0][0] || l.BlockNumber < q.FromBlock.Uint64() || l.BlockNumber > q.ToBlock.Uint64() {
+			continue
+		}
+		logs = append(logs, l)
+	}
+	return logs, nil
+}
+
+func (c *chain) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	}), nil
+}
+
+func (c *chain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: new(big.Int).SetUint64(c.head)}, nil
+}
+
+func TestPreflightCountsStakesWithOtherBuilders(t *testing.T) {
+	builderA := common.HexToAddress("0xa")
+	builderB := common.HexToAddress("0xb")
+	c := &chain{head: 100}
+	c.emit(1, "BuilderUpdated", builderA, big.NewInt(20), big.NewInt(100))
+	c.emit(1, "BuilderUpdated", builderB, big.NewInt(10), big.NewInt(100))
+	// Commitment 1 deposited with A, commitment 2 only with B, yet
+	// hasMinimalStake(A, 2) holds while its stake covers A's terms.
+	c.emit(2, "StakeUpdated", builderA, common.Hash{1}, big.NewInt(20), big.NewInt(200))
+	c.emit(3, "StakeUpdated", builderB, common.Hash{2}, big.NewInt(25), big.NewInt(200))
+	// Commitment 3 moved its stake from A to B with its second deposit.
+	c.emit(4, "StakeUpdated", builderA, common.Hash{3}, big.NewInt(20), big.NewInt(200))
+	c.emit(5, "StakeUpdated", builderB, common.Hash{3}, big.NewInt(30), big.NewInt(300))
+
+	tracker, err := New(common.Address{}, c, Config{Step: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	proposed := terms.Terms{MinimalStake: big.NewInt(26), MinimalSubscriptionPeriod: big.NewInt(100)}
+	r, err := tracker.Preflight(context.Background(), builderA, proposed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Eligible != 3 || len(r.Losing) != 2 || r.Losing[0].Commitment != (common.Hash{1}) || r.Losing[1].Commitment != (common.Hash{2}) {
+		t.Fatalf("eligible %d, losing %+v; want 3 eligible, commitments 1 and 2 losing", r.Eligible, r.Losing)
+	}
+	if r.Losing[0].Builder != builderA || r.Losing[1].Builder != builderB {
+		t.Errorf("losing deposited with %s and %s, want A and B", r.Losing[0].Builder, r.Losing[1].Builder)
+	}
+
+	// Once B raised its minimal stake to 30, lowering it to 20 qualifies
+	// commitment 1 as well, although it deposited with A.
+	c.head = 110
+	c.emit(105, "BuilderUpdated", builderB, big.NewInt(30), big.NewInt(100))
+	lowered := terms.Terms{MinimalStake: big.NewInt(20), MinimalSubscriptionPeriod: big.NewInt(100)}
+	r, err = tracker.Preflight(context.Background(), builderB, lowered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Eligible != 1 || len(r.Gaining) != 2 || r.Gaining[0].Commitment != (common.Hash{1}) || r.Gaining[1].Commitment != (common.Hash{2}) {
+		t.Errorf("eligible %d, gaining %+v; want 1 eligible, commitments 1 and 2 gaining", r.Eligible, r.Gaining)
+	}
+}
@@ -0,0 +1,281 @@
+// Package lockmon watches how many time locks builders accumulate.
+//
+// Every deposit pushes a time lock for the builder and withdraw iterates over
+// all of them, so anyone paying the minimal stake repeatedly can grow the
+// array until withdraw no longer fits into a block. The monitor estimates
+// withdraw gas as the count grows and alerts well before that point.
+package lockmon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/primevprotocol/primev-contracts/pkg/heads"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// Gas model defaults for withdraw, used until a simulation calibrates them.
+const (
+	DefaultBaseGas    = 45_000
+	DefaultPerLockGas = 15_000
+	// DefaultAlertFraction of the block gas limit triggers an alert.
+	DefaultAlertFraction = 0.5
+)
+
+// Revert reasons of withdraw for a builder with nothing to release.
+var idleReasons = []string{"No locked funds", "Nothing to withdraw"}
+
+// Backend is the node connection needed by Monitor.
+type Backend interface {
+	bind.ContractCaller
+	heads.Reader
+}
+
+// Estimator sends eth_estimateGas requests. *rpc.Client implements it; unlike
+// ethclient it allows pinning the estimate to a block.
+type Estimator interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// Config configures a Monitor.
+type Config struct {
+	Builders      []common.Address
+	AlertFraction float64       // Fraction of the block gas limit at which to alert
+	Every         uint64        // Check every this many blocks, every block if zero
+	PollInterval  time.Duration // Head polling interval when subscriptions are unsupported
+	OnAlert       func(Alert)   // Called for every alert in addition to logging
+}
+
+// Status is the latest observation for a builder.
+type Status struct {
+	Builder      common.Address
+	Block        uint64
+	Count        uint64  // Number of time locks
+	EstimatedGas uint64  // Projected withdraw gas for Count locks
+	Simulated    bool    // EstimatedGas comes from a simulation rather than the model
+	Exceeded     bool    // The simulation ran out of gas, withdraw no longer fits
+	GasLimit     uint64  // Block gas limit at Block
+	GrowthRate   float64 // Time locks added per block since the first observation
+	BlocksLeft   uint64  // Blocks until the alert fraction is reached at GrowthRate, 0 if unknown
+	MaxLocks     uint64  // Number of locks at which withdraw exceeds the block gas limit
+}
+
+// Alert is raised when a builder's withdraw gas approaches the block gas limit.
+type Alert struct {
+	Status
+	Fraction float64 // EstimatedGas / GasLimit
+	Critical bool    // Withdraw already exceeds the gas the node allows
+}
+
+// history is what the monitor learned about a builder. The per lock cost is
+// calibrated per builder, as it depends on how many of its locks release.
+type history struct {
+	firstBlock, firstCount uint64
+	perLockGas             uint64
+}
+
+// Monitor tracks time lock counts per builder.
+type Monitor struct {
+	cfg     Config
+	backend Backend
+	est     Estimator
+	caller  *primev.BuilderStakingCaller
+	address common.Address
+	input   []byte
+
+	mu      sync.RWMutex
+	baseGas uint64
+	status  map[common.Address]Status
+	history map[common.Address]history
+}
+
+// New creates a Monitor for the BuilderStaking contract at address. Withdraw
+// is simulated through est at the block being checked.
+func New(address common.Address, backend Backend, est Estimator, cfg Config) (*Monitor, error) {
+	if cfg.AlertFraction == 0 {
+		cfg.AlertFraction = DefaultAlertFraction
+	}
+	if cfg.Every == 0 {
+		cfg.Every = 1
+	}
+	caller, err := primev.NewBuilderStakingCaller(address, backend)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := primev.BuilderStakingMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	input, err := parsed.Pack("withdraw")
+	if err != nil {
+		return nil, err
+	}
+	return &Monitor{
+		cfg:     cfg,
+		backend: backend,
+		est:     est,
+		caller:  caller,
+		address: address,
+		input:   input,
+		baseGas: DefaultBaseGas,
+		status:  make(map[common.Address]Status),
+		history: make(map[common.Address]history),
+	}, nil
+}
+
+// Status returns the latest observation for builder.
+func (m *Monitor) Status(builder common.Address) (Status, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.status[builder]
+	return s, ok
+}
+
+// Run checks all builders on new heads until ctx is done.
+func (m *Monitor) Run(ctx context.Context) error {
+	return heads.Watch(ctx, m.backend, m.cfg.PollInterval, func(head *types.Header) error {
+		if head.Number.Uint64()%m.cfg.Every != 0 {
+			return nil
+		}
+		for _, builder := range m.cfg.Builders {
+			if _, err := m.Check(ctx, head, builder); err != nil {
+				log.Warn("Time lock check failed", "builder", builder, "err", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Check observes builder at head and raises an alert if needed.
+func (m *Monitor) Check(ctx context.Context, head *types.Header, builder common.Address) (Status, error) {
+	count, err := m.caller.TimeLocksCount(&bind.CallOpts{Context: ctx, BlockNumber: head.Number}, builder)
+	if err != nil {
+		return Status{}, err
+	}
+	s := Status{
+		Builder:  builder,
+		Block:    head.Number.Uint64(),
+		Count:    count.Uint64(),
+		GasLimit: head.GasLimit,
+	}
+
+	// withdraw reverts when nothing is vested, in which case the linear model
+	// is used. A successful simulation recalibrates the builder's per lock
+	// cost, running out of gas means withdraw is already stuck.
+	gas, err := m.estimate(ctx, head, builder)
+	switch {
+	case err == nil:
+		s.Simulated = s.Count > 0
+	case isOutOfGas(err):
+		s.Exceeded = true
+	case isIdle(err):
+	default:
+		return Status{}, fmt.Errorf("lockmon: estimate withdraw: %w", err)
+	}
+	m.mu.Lock()
+	h, ok := m.history[builder]
+	if !ok {
+		h = history{firstBlock: s.Block, firstCount: s.Count, perLockGas: DefaultPerLockGas}
+	}
+	if s.Simulated {
+		s.EstimatedGas = gas
+		if gas > m.baseGas {
+			h.perLockGas = (gas - m.baseGas) / s.Count
+		}
+	} else {
+		s.EstimatedGas = m.baseGas + h.perLockGas*s.Count
+		if s.Exceeded && s.EstimatedGas < s.GasLimit {
+			s.EstimatedGas = s.GasLimit
+		}
+	}
+	m.history[builder] = h
+	if h.perLockGas > 0 && s.GasLimit > m.baseGas {
+		s.MaxLocks = (s.GasLimit - m.baseGas) / h.perLockGas
+	}
+	if s.Block > h.firstBlock && s.Count > h.firstCount {
+		s.GrowthRate = float64(s.Count-h.firstCount) / float64(s.Block-h.firstBlock)
+		alertLocks := uint64(float64(s.MaxLocks) * m.cfg.AlertFraction)
+		if alertLocks > s.Count {
+			s.BlocksLeft = uint64(float64(alertLocks-s.Count) / s.GrowthRate)
+		}
+	}
+	m.status[builder] = s
+	m.mu.Unlock()
+
+	fraction := float64(s.EstimatedGas) / float64(s.GasLimit)
+	if s.Exceeded || (s.GasLimit > 0 && fraction >= m.cfg.AlertFraction) {
+		alert := Alert{Status: s, Fraction: fraction, Critical: s.Exceeded}
+		if alert.Critical {
+			log.Error("Withdraw exceeds the gas allowance", "builder", builder, "locks", s.Count,
+				"limit", s.GasLimit, "maxLocks", s.MaxLocks)
+		} else {
+			log.Warn("Withdraw gas approaching block gas limit", "builder", builder, "locks", s.Count,
+				"gas", s.EstimatedGas, "limit", s.GasLimit, "fraction", fraction, "maxLocks", s.MaxLocks)
+		}
+		if m.cfg.OnAlert != nil {
+			m.cfg.OnAlert(alert)
+		}
+	} else {
+		log.Debug("Time locks", "builder", builder, "locks", s.Count, "gas", s.EstimatedGas,
+			"simulated", s.Simulated, "growth", s.GrowthRate, "blocksLeft", s.BlocksLeft)
+	}
+	return s, nil
+}
+
+// estimate simulates withdraw for builder at head.
+func (m *Monitor) estimate(ctx context.Context, head *types.Header, builder common.Address) (uint64, error) {
+	arg := map[string]interface{}{"from": builder, "to": m.address, "data": hexutil.Bytes(m.input)}
+	var gas hexutil.Uint64
+	err := m.est.CallContext(ctx, &gas, "eth_estimateGas", arg, hexutil.EncodeBig(head.Number))
+	return uint64(gas), err
+}
+
+// isIdle reports whether err is withdraw reverting because nothing is locked
+// or vested.
+func isIdle(err error) bool {
+	reason := err.Error()
+	var de rpc.DataError
+	if errors.As(err, &de) {
+		if data, ok := de.ErrorData().(string); ok {
+			if unpacked, uerr := abi.UnpackRevert(common.FromHex(data)); uerr == nil {
+				reason = unpacked
+			}
+		}
+	}
+	for _, r := range idleReasons {
+		if strings.Contains(reason, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// isOutOfGas reports whether err is the node giving up on the estimate
+// because withdraw needs more gas than it allows.
+func isOutOfGas(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "gas required exceeds allowance") || strings.Contains(msg, "out of gas")
+}
+
+// EstimateGas returns the modelled withdraw gas for count time locks of
+// builder.
+func (m *Monitor) EstimateGas(builder common.Address, count uint64) uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	perLockGas := uint64(DefaultPerLockGas)
+	if h, ok := m.history[builder]; ok {
+		perLockGas = h.perLockGas
+	}
+	return m.baseGas + perLockGas*count
+}
@@ -0,0 +1,217 @@
+package lockmon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+var contractABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(primev.BuilderStakingMetaData.ABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+const gasLimit = 1_000_000
+
+// step is one observation: count time locks at block, with withdraw either
+// simulated at gas or failing with err.
+type step struct {
+	block, count uint64
+	gas          uint64
+	err          error
+}
+
+// chain answers timeLocksCount and eth_estimateGas for the current step and
+// checks both are pinned to its block. Methods the monitor does not use are
+// left to the nil embedded Backend.
+type chain struct {
+	Backend
+	t    *testing.T
+	step step
+}
+
+func (c *chain) CallContract(ctx context.Context, call ethereum.CallMsg, number *big.Int) ([]byte, error) {
+	method, err := contractABI.MethodById(call.Data[:4])
+	if err != nil {
+		return nil, err
+	}
+	if method.Name != "timeLocksCount" {
+		return nil, fmt.Errorf("unexpected call to %s", method.Name)
+	}
+	if number == nil || number.Uint64() != c.step.block {
+		c.t.Errorf("timeLocksCount read at %v, want %d", number, c.step.block)
+	}
+	return method.Outputs.Pack(new(big.Int).SetUint64(c.step.count))
+}
+
+func (c *chain) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if method != "eth_estimateGas" {
+		return fmt.Errorf("unexpected method %s", method)
+	}
+	if len(args) != 2 || args[1] != hexutil.EncodeUint64(c.step.block) {
+		c.t.Errorf("eth_estimateGas args %v, want block %d", args, c.step.block)
+	}
+	if c.step.err != nil {
+		return c.step.err
+	}
+	*result.(*hexutil.Uint64) = hexutil.Uint64(c.step.gas)
+	return nil
+}
+
+// revertError is a revert carrying its reason only in the error data, as
+// returned by some nodes.
+type revertError struct{ reason string }
+
+func (e revertError) Error() string { return "execution reverted" }
+
+func (e revertError) ErrorData() interface{} {
+	typ, _ := abi.NewType("string", "", nil)
+	data, _ := abi.Arguments{{Type: typ}}.Pack(e.reason)
+	return hexutil.Encode(append(crypto.Keccak256([]byte("Error(string)"))[:4], data...))
+}
+
+func TestCheck(t *testing.T) {
+	builder := common.HexToAddress("0xb")
+	tests := []struct {
+		name       string
+		steps      []step
+		want       Status // Builder, Block, Count and GasLimit are filled in
+		wantAlert  bool
+		wantCrit   bool
+		wantErr    bool
+		growthRate float64
+	}{
+		{
+			name:  "model before calibration",
+			steps: []step{{block: 10, count: 0, err: errors.New("execution reverted: No locked funds")}},
+			want:  Status{EstimatedGas: DefaultBaseGas, MaxLocks: (gasLimit - DefaultBaseGas) / DefaultPerLockGas},
+		},
+		{
+			name: "calibration",
+			steps: []step{
+				{block: 10, count: 3, gas: DefaultBaseGas + 3*20_000},
+				{block: 20, count: 5, err: errors.New("execution reverted: Nothing to withdraw")},
+			},
+			// 47 locks fit, the alert is at 23, reached in 90 blocks at 0.2
+			// locks per block.
+			want:       Status{EstimatedGas: DefaultBaseGas + 5*20_000, MaxLocks: 47, BlocksLeft: 90},
+			growthRate: 0.2,
+		},
+		{
+			name:  "simulated",
+			steps: []step{{block: 10, count: 4, gas: DefaultBaseGas + 4*10_000}},
+			want:  Status{EstimatedGas: DefaultBaseGas + 4*10_000, Simulated: true, MaxLocks: 95},
+		},
+		{
+			name:  "revert reason in error data",
+			steps: []step{{block: 10, count: 2, err: revertError{"Nothing to withdraw"}}},
+			want:  Status{EstimatedGas: DefaultBaseGas + 2*DefaultPerLockGas, MaxLocks: 63},
+		},
+		{
+			name:  "below alert fraction",
+			steps: []step{{block: 10, count: 30, gas: DefaultBaseGas + 30*DefaultPerLockGas}},
+			want:  Status{EstimatedGas: 495_000, Simulated: true, MaxLocks: 63},
+		},
+		{
+			name:      "at alert fraction",
+			steps:     []step{{block: 10, count: 31, gas: DefaultBaseGas + 31*DefaultPerLockGas}},
+			want:      Status{EstimatedGas: 510_000, Simulated: true, MaxLocks: 63},
+			wantAlert: true,
+		},
+		{
+			name:      "out of gas",
+			steps:     []step{{block: 10, count: 60, err: errors.New("gas required exceeds allowance (1000000)")}},
+			want:      Status{EstimatedGas: gasLimit, Exceeded: true, MaxLocks: 63},
+			wantAlert: true,
+			wantCrit:  true,
+		},
+		{
+			name:    "node failure",
+			steps:   []step{{block: 10, count: 2, err: errors.New("connection refused")}},
+			wantErr: true,
+		},
+		{
+			name:    "other revert",
+			steps:   []step{{block: 10, count: 2, err: errors.New("execution reverted: Ownable: caller is not the owner")}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		c := &chain{t: t}
+		var alerts []Alert
+		m, err := New(common.HexToAddress("0xc"), c, c, Config{
+			Builders: []common.Address{builder},
+			OnAlert:  func(a Alert) { alerts = append(alerts, a) },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s Status
+		for _, st := range tt.steps {
+			c.step = st
+			head := &types.Header{Number: new(big.Int).SetUint64(st.block), GasLimit: gasLimit}
+			s, err = m.Check(context.Background(), head, builder)
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: no error", tt.name)
+			}
+			if _, ok := m.Status(builder); ok {
+				t.Errorf("%s: status recorded after error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		last := tt.steps[len(tt.steps)-1]
+		want := tt.want
+		want.Builder, want.Block, want.Count, want.GasLimit = builder, last.block, last.count, gasLimit
+		want.GrowthRate = tt.growthRate
+		if s != want {
+			t.Errorf("%s: status %+v, want %+v", tt.name, s, want)
+		}
+		if got, _ := m.Status(builder); got != s {
+			t.Errorf("%s: stored status %+v, want %+v", tt.name, got, s)
+		}
+		if (len(alerts) > 0) != tt.wantAlert {
+			t.Errorf("%s: alerts %v, want alert %v", tt.name, alerts, tt.wantAlert)
+		} else if tt.wantAlert && alerts[0].Critical != tt.wantCrit {
+			t.Errorf("%s: critical %v, want %v", tt.name, alerts[0].Critical, tt.wantCrit)
+		}
+	}
+}
+
+func TestEstimateGas(t *testing.T) {
+	builder := common.HexToAddress("0xb")
+	c := &chain{t: t, step: step{block: 10, count: 2, gas: DefaultBaseGas + 2*25_000}}
+	m, err := New(common.HexToAddress("0xc"), c, c, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.EstimateGas(builder, 10), uint64(DefaultBaseGas+10*DefaultPerLockGas); got != want {
+		t.Errorf("uncalibrated: %d, want %d", got, want)
+	}
+	head := &types.Header{Number: big.NewInt(10), GasLimit: gasLimit}
+	if _, err := m.Check(context.Background(), head, builder); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.EstimateGas(builder, 10), uint64(DefaultBaseGas+10*25_000); got != want {
+		t.Errorf("calibrated: %d, want %d", got, want)
+	}
+}
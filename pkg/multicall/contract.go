@@ -0,0 +1,39 @@
+package multicall
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultAddress is the address Multicall3 is deployed at on most chains.
+var DefaultAddress = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// multicallABIJSON covers the subset of Multicall3 used by this package.
+const multicallABIJSON = `[
+{"inputs":[{"components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}],"name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}],"name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}
+]`
+
+var multicallABI = mustParseABI(multicallABIJSON)
+
+func mustParseABI(s string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(s))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// call3 is the Multicall3.Call3 struct.
+type call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// result is the Multicall3.Result struct.
+type result struct {
+	Success    bool
+	ReturnData []byte
+}
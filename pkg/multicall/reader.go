@@ -0,0 +1,478 @@
+// Package multicall reads BuilderStaking view functions in bulk. Calls are
+// packed into Multicall3 aggregate3 calls, or sent as JSON-RPC batch requests
+// where Multicall3 is not deployed, and every call of a read is pinned to the
+// same block.
+package multicall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/primevprotocol/primev-contracts/pkg/heads"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// DefaultBatchSize is the number of calls per aggregate3 call or JSON-RPC
+// batch request if Config.BatchSize is zero.
+const DefaultBatchSize = 100
+
+// ErrPending is returned for CallOpts requesting the pending state, which
+// cannot be pinned to a block.
+var ErrPending = errors.New("multicall: pending state is not supported")
+
+// Result types, identical to the ones returned by the generated
+// BuilderStakingCaller methods.
+type (
+	BuilderInfo = struct {
+		MinimalStake              *big.Int
+		MinimalSubscriptionPeriod *big.Int
+	}
+	StakeInfo = struct {
+		SubscriptionEnd *big.Int
+		Stake           *big.Int
+	}
+	TimeLock = struct {
+		InitialAmount   *big.Int
+		RemainingAmount *big.Int
+		StartTime       *big.Int
+		LockDuration    *big.Int
+	}
+)
+
+// CallError reports a failed call within a bulk read.
+type CallError struct {
+	Index  int    // Position of the call in the read
+	Method string // BuilderStaking method
+	Err    error
+}
+
+func (e *CallError) Error() string {
+	return fmt.Sprintf("multicall: %s call %d failed: %v", e.Method, e.Index, e.Err)
+}
+
+func (e *CallError) Unwrap() error {
+	return e.Err
+}
+
+var errReverted = errors.New("execution reverted")
+
+// senderMethods are the views that read msg.sender. Inside aggregate3 the
+// sender is Multicall3, so they are never aggregated.
+var senderMethods = []string{"withdrawableAmount"}
+
+// Batcher sends JSON-RPC batch requests. *rpc.Client implements it.
+type Batcher interface {
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
+
+// Backend is the node connection needed by Reader.
+type Backend interface {
+	bind.ContractCaller
+	heads.Reader
+}
+
+// Config configures a Reader.
+type Config struct {
+	Multicall common.Address // Multicall3 address, DefaultAddress if zero
+	BatchSize int            // Calls per request, DefaultBatchSize if zero
+}
+
+type mode int
+
+const (
+	modeMulticall mode = iota
+	modeBatch
+	modeSequential
+)
+
+// Reader reads a BuilderStaking contract in bulk.
+type Reader struct {
+	cfg     Config
+	address common.Address
+	abi     *abi.ABI
+	backend Backend
+	batcher Batcher
+
+	mu       sync.Mutex
+	deployed *big.Int // Lowest block Multicall3 was found at
+	missing  *big.Int // Highest block Multicall3 was not found at
+}
+
+// NewReader creates a Reader for the BuilderStaking contract at address.
+// batcher is used at blocks where Multicall3 is not deployed and for views
+// reading msg.sender, such as withdrawableAmount; without it those calls are
+// sent one at a time.
+func NewReader(address common.Address, backend Backend, batcher Batcher, cfg Config) (*Reader, error) {
+	if cfg.Multicall == (common.Address{}) {
+		cfg.Multicall = DefaultAddress
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	parsed, err := primev.BuilderStakingMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{cfg: cfg, address: address, abi: parsed, backend: backend, batcher: batcher}, nil
+}
+
+// Builders returns the terms of each builder.
+func (r *Reader) Builders(opts *bind.CallOpts, builders []common.Address) ([]BuilderInfo, error) {
+	calls := make([]Call, len(builders))
+	for i, b := range builders {
+		calls[i] = Call{"builders", []interface{}{b}}
+	}
+	out, err := r.Call(opts, calls)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]BuilderInfo, len(out))
+	for i, o := range out {
+		result[i].MinimalStake = *abi.ConvertType(o[0], new(*big.Int)).(**big.Int)
+		result[i].MinimalSubscriptionPeriod = *abi.ConvertType(o[1], new(*big.Int)).(**big.Int)
+	}
+	return result, nil
+}
+
+// Stakes returns the stake of each commitment.
+func (r *Reader) Stakes(opts *bind.CallOpts, commitments [][32]byte) ([]StakeInfo, error) {
+	calls := make([]Call, len(commitments))
+	for i, c := range commitments {
+		calls[i] = Call{"stakes", []interface{}{c}}
+	}
+	out, err := r.Call(opts, calls)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]StakeInfo, len(out))
+	for i, o := range out {
+		result[i].SubscriptionEnd = *abi.ConvertType(o[0], new(*big.Int)).(**big.Int)
+		result[i].Stake = *abi.ConvertType(o[1], new(*big.Int)).(**big.Int)
+	}
+	return result, nil
+}
+
+// HasMinimalStake reports for each commitment whether it holds the minimal
+// stake of builder.
+func (r *Reader) HasMinimalStake(opts *bind.CallOpts, builder common.Address, commitments [][32]byte) ([]bool, error) {
+	calls := make([]Call, len(commitments))
+	for i, c := range commitments {
+		calls[i] = Call{"hasMinimalStake", []interface{}{builder, c}}
+	}
+	out, err := r.Call(opts, calls)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]bool, len(out))
+	for i, o := range out {
+		result[i] = *abi.ConvertType(o[0], new(bool)).(*bool)
+	}
+	return result, nil
+}
+
+// TimeLocksCount returns the number of time locks of each account.
+func (r *Reader) TimeLocksCount(opts *bind.CallOpts, accounts []common.Address) ([]*big.Int, error) {
+	calls := make([]Call, len(accounts))
+	for i, a := range accounts {
+		calls[i] = Call{"timeLocksCount", []interface{}{a}}
+	}
+	out, err := r.Call(opts, calls)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*big.Int, len(out))
+	for i, o := range out {
+		result[i] = *abi.ConvertType(o[0], new(*big.Int)).(**big.Int)
+	}
+	return result, nil
+}
+
+// TimeLocks returns all time locks of each account, read at a single block.
+func (r *Reader) TimeLocks(opts *bind.CallOpts, accounts []common.Address) ([][]TimeLock, error) {
+	opts, err := r.pin(opts)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := r.TimeLocksCount(opts, accounts)
+	if err != nil {
+		return nil, err
+	}
+	var calls []Call
+	for i, a := range accounts {
+		for j := uint64(0); j < counts[i].Uint64(); j++ {
+			calls = append(calls, Call{"timeLocks", []interface{}{a, new(big.Int).SetUint64(j)}})
+		}
+	}
+	out, err := r.Call(opts, calls)
+	if err != nil {
+		return nil, err
+	}
+	result := make([][]TimeLock, len(accounts))
+	for i := range accounts {
+		n := counts[i].Uint64()
+		result[i] = make([]TimeLock, n)
+		for j := range result[i] {
+			o := out[0]
+			out = out[1:]
+			result[i][j].InitialAmount = *abi.ConvertType(o[0], new(*big.Int)).(**big.Int)
+			result[i][j].RemainingAmount = *abi.ConvertType(o[1], new(*big.Int)).(**big.Int)
+			result[i][j].StartTime = *abi.ConvertType(o[2], new(*big.Int)).(**big.Int)
+			result[i][j].LockDuration = *abi.ConvertType(o[3], new(*big.Int)).(**big.Int)
+		}
+	}
+	return result, nil
+}
+
+// Call is a single BuilderStaking view call.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Call executes arbitrary BuilderStaking view calls at a single block and
+// returns their unpacked outputs. If opts does not name a block, the latest
+// block is resolved once and used for all calls.
+func (r *Reader) Call(opts *bind.CallOpts, calls []Call) ([][]interface{}, error) {
+	opts, err := r.pin(opts)
+	if err != nil {
+		return nil, err
+	}
+	inputs := make([][]byte, len(calls))
+	for i, c := range calls {
+		if inputs[i], err = r.abi.Pack(c.Method, c.Args...); err != nil {
+			return nil, err
+		}
+	}
+	outputs, err := r.execute(opts, inputs)
+	if err != nil {
+		return nil, err
+	}
+	values := make([][]interface{}, len(calls))
+	for i, c := range calls {
+		if outputs[i].err != nil {
+			return nil, &CallError{Index: i, Method: c.Method, Err: outputs[i].err}
+		}
+		if values[i], err = r.abi.Unpack(c.Method, outputs[i].data); err != nil {
+			return nil, &CallError{Index: i, Method: c.Method, Err: err}
+		}
+	}
+	return values, nil
+}
+
+// pin returns a copy of opts with BlockNumber set.
+func (r *Reader) pin(opts *bind.CallOpts) (*bind.CallOpts, error) {
+	if opts == nil {
+		opts = new(bind.CallOpts)
+	}
+	if opts.Pending {
+		return nil, ErrPending
+	}
+	if opts.BlockNumber != nil {
+		return opts, nil
+	}
+	header, err := r.backend.HeaderByNumber(ensureContext(opts.Context), nil)
+	if err != nil {
+		return nil, err
+	}
+	pinned := *opts
+	pinned.BlockNumber = header.Number
+	return &pinned, nil
+}
+
+type output struct {
+	data []byte
+	err  error
+}
+
+func (r *Reader) execute(opts *bind.CallOpts, inputs [][]byte) ([]output, error) {
+	ctx := ensureContext(opts.Context)
+	deployed, err := r.detect(ctx, opts.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	direct := modeSequential
+	if r.batcher != nil {
+		direct = modeBatch
+	}
+	if !deployed {
+		return r.run(ctx, opts, direct, inputs)
+	}
+	var aggregated, separate []int
+	for i, input := range inputs {
+		if r.readsSender(input) {
+			separate = append(separate, i)
+		} else {
+			aggregated = append(aggregated, i)
+		}
+	}
+	if len(separate) == 0 {
+		return r.run(ctx, opts, modeMulticall, inputs)
+	}
+	outputs := make([]output, len(inputs))
+	for _, part := range []struct {
+		mode    mode
+		indices []int
+	}{{modeMulticall, aggregated}, {direct, separate}} {
+		subset := make([][]byte, len(part.indices))
+		for j, i := range part.indices {
+			subset[j] = inputs[i]
+		}
+		out, err := r.run(ctx, opts, part.mode, subset)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range part.indices {
+			outputs[i] = out[j]
+		}
+	}
+	return outputs, nil
+}
+
+// run executes inputs in chunks of BatchSize using mode m.
+func (r *Reader) run(ctx context.Context, opts *bind.CallOpts, m mode, inputs [][]byte) ([]output, error) {
+	outputs := make([]output, 0, len(inputs))
+	for start := 0; start < len(inputs); start += r.cfg.BatchSize {
+		end := start + r.cfg.BatchSize
+		if end > len(inputs) {
+			end = len(inputs)
+		}
+		var (
+			chunk []output
+			err   error
+		)
+		switch m {
+		case modeMulticall:
+			// An aggregate call can exceed the node's eth_call gas cap where
+			// the individual calls would not.
+			chunk, err = r.aggregate(ctx, opts, inputs[start:end])
+			if err != nil && r.batcher != nil {
+				log.Debug("Multicall failed, falling back to batch request", "calls", end-start, "err", err)
+				chunk, err = r.batch(ctx, opts, inputs[start:end])
+			}
+		case modeBatch:
+			chunk, err = r.batch(ctx, opts, inputs[start:end])
+		default:
+			chunk, err = r.sequential(ctx, opts, inputs[start:end])
+		}
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, chunk...)
+	}
+	return outputs, nil
+}
+
+// readsSender reports whether input calls one of senderMethods.
+func (r *Reader) readsSender(input []byte) bool {
+	for _, name := range senderMethods {
+		if bytes.Equal(input[:4], r.abi.Methods[name].ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// detect reports whether Multicall3 is deployed at block. A deployment is
+// permanent, so blocks after one it was found at, or before one it was
+// missing at, are not checked again.
+func (r *Reader) detect(ctx context.Context, block *big.Int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.deployed != nil && block.Cmp(r.deployed) >= 0 {
+		return true, nil
+	}
+	if r.missing != nil && block.Cmp(r.missing) <= 0 {
+		return false, nil
+	}
+	code, err := r.backend.CodeAt(ctx, r.cfg.Multicall, block)
+	if err != nil {
+		return false, err
+	}
+	if len(code) > 0 {
+		r.deployed = new(big.Int).Set(block)
+		return true, nil
+	}
+	r.missing = new(big.Int).Set(block)
+	return false, nil
+}
+
+func (r *Reader) aggregate(ctx context.Context, opts *bind.CallOpts, inputs [][]byte) ([]output, error) {
+	calls := make([]call3, len(inputs))
+	for i, input := range inputs {
+		calls[i] = call3{Target: r.address, AllowFailure: true, CallData: input}
+	}
+	data, err := multicallABI.Pack("aggregate3", calls)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := r.backend.CallContract(ctx, ethereum.CallMsg{From: opts.From, To: &r.cfg.Multicall, Data: data}, opts.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	out, err := multicallABI.Unpack("aggregate3", raw)
+	if err != nil {
+		return nil, err
+	}
+	results := *abi.ConvertType(out[0], new([]result)).(*[]result)
+	if len(results) != len(inputs) {
+		return nil, fmt.Errorf("multicall: got %d results for %d calls", len(results), len(inputs))
+	}
+	outputs := make([]output, len(results))
+	for i, res := range results {
+		outputs[i].data = res.ReturnData
+		if !res.Success {
+			outputs[i].err = errReverted
+		}
+	}
+	return outputs, nil
+}
+
+func (r *Reader) batch(ctx context.Context, opts *bind.CallOpts, inputs [][]byte) ([]output, error) {
+	elems := make([]rpc.BatchElem, len(inputs))
+	for i, input := range inputs {
+		arg := map[string]interface{}{"to": r.address, "data": hexutil.Bytes(input)}
+		if opts.From != (common.Address{}) {
+			arg["from"] = opts.From
+		}
+		elems[i] = rpc.BatchElem{
+			Method: "eth_call",
+			Args:   []interface{}{arg, hexutil.EncodeBig(opts.BlockNumber)},
+			Result: new(hexutil.Bytes),
+		}
+	}
+	if err := r.batcher.BatchCallContext(ctx, elems); err != nil {
+		return nil, err
+	}
+	outputs := make([]output, len(elems))
+	for i, elem := range elems {
+		outputs[i] = output{data: *elem.Result.(*hexutil.Bytes), err: elem.Error}
+	}
+	return outputs, nil
+}
+
+func (r *Reader) sequential(ctx context.Context, opts *bind.CallOpts, inputs [][]byte) ([]output, error) {
+	outputs := make([]output, len(inputs))
+	for i, input := range inputs {
+		data, err := r.backend.CallContract(ctx, ethereum.CallMsg{From: opts.From, To: &r.address, Data: input}, opts.BlockNumber)
+		outputs[i] = output{data: data, err: err}
+	}
+	return outputs, nil
+}
+
+func ensureContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
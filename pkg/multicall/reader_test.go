@@ -0,0 +1,304 @@
+package multicall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+var contractABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(primev.BuilderStakingMetaData.ABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+var (
+	contract    = common.HexToAddress("0xc")
+	builders    = []common.Address{common.HexToAddress("0xb1"), common.HexToAddress("0xb2"), common.HexToAddress("0xb3")}
+	commitments = [][32]byte{{1}, {2}, {3}, {4}}
+)
+
+// chain answers BuilderStaking calls from state that changes every block,
+// executes aggregate3 calls at the Multicall3 address from block deployed on
+// and serves JSON-RPC batches of eth_call. Builder i has i time locks.
+// Methods the reader does not use are left to the nil embedded Backend.
+type chain struct {
+	Backend
+	head     uint64
+	deployed uint64
+
+	codeAt, aggregates, batches, calls int // Requests by kind
+}
+
+// contract executes a BuilderStaking call from sender at block.
+func (c *chain) contract(sender common.Address, data []byte, block uint64) ([]byte, error) {
+	method, err := contractABI.MethodById(data[:4])
+	if err != nil {
+		return nil, err
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	n := new(big.Int).SetUint64(block)
+	locks := func(a common.Address) int64 {
+		for i, b := range builders {
+			if a == b {
+				return int64(i)
+			}
+		}
+		return 0
+	}
+	switch method.Name {
+	case "builders":
+		return method.Outputs.Pack(big.NewInt(10*locks(args[0].(common.Address))), big.NewInt(100))
+	case "stakes":
+		c := int64(args[0].([32]byte)[0])
+		return method.Outputs.Pack(new(big.Int).Add(n, big.NewInt(c)), big.NewInt(5*c+int64(block)))
+	case "hasMinimalStake":
+		c := int64(args[1].([32]byte)[0])
+		return method.Outputs.Pack(5*c+int64(block) >= 10*locks(args[0].(common.Address)))
+	case "timeLocksCount":
+		return method.Outputs.Pack(big.NewInt(locks(args[0].(common.Address))))
+	case "timeLocks":
+		i := args[1].(*big.Int)
+		if i.Int64() >= locks(args[0].(common.Address)) {
+			return nil, errors.New("execution reverted")
+		}
+		return method.Outputs.Pack(new(big.Int).Add(i, big.NewInt(100)), new(big.Int).Add(i, n), i, big.NewInt(10))
+	case "withdrawableAmount":
+		if locks(sender) == 0 {
+			return nil, errors.New("execution reverted: No locked funds")
+		}
+		return method.Outputs.Pack(big.NewInt(7*locks(sender) + int64(block)))
+	}
+	return nil, fmt.Errorf("unexpected call to %s", method.Name)
+}
+
+func (c *chain) CodeAt(ctx context.Context, account common.Address, number *big.Int) ([]byte, error) {
+	c.codeAt++
+	if account == DefaultAddress && number.Uint64() >= c.deployed {
+		return []byte{0xfe}, nil
+	}
+	return nil, nil
+}
+
+func (c *chain) CallContract(ctx context.Context, call ethereum.CallMsg, number *big.Int) ([]byte, error) {
+	if *call.To != DefaultAddress {
+		c.calls++
+		return c.contract(call.From, call.Data, number.Uint64())
+	}
+	if number.Uint64() < c.deployed {
+		return nil, nil
+	}
+	c.aggregates++
+	method := multicallABI.Methods["aggregate3"]
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	calls := *abi.ConvertType(args[0], new([]call3)).(*[]call3)
+	results := make([]result, len(calls))
+	for i, call := range calls {
+		data, err := c.contract(DefaultAddress, call.CallData, number.Uint64())
+		results[i] = result{Success: err == nil, ReturnData: data}
+	}
+	return method.Outputs.Pack(results)
+}
+
+func (c *chain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: new(big.Int).SetUint64(c.head)}, nil
+}
+
+func (c *chain) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	c.batches++
+	for i := range b {
+		arg := b[i].Args[0].(map[string]interface{})
+		block, err := hexutil.DecodeBig(b[i].Args[1].(string))
+		if err != nil {
+			return err
+		}
+		var from common.Address
+		if f, ok := arg["from"]; ok {
+			from = f.(common.Address)
+		}
+		data, err := c.contract(from, arg["data"].(hexutil.Bytes), block.Uint64())
+		*b[i].Result.(*hexutil.Bytes) = data
+		b[i].Error = err
+	}
+	return nil
+}
+
+func TestModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		deployed uint64
+		batcher  bool
+
+		aggregates, batches, calls int
+	}{
+		// 3 builders, 4 stakes, 4 minimal stake checks, 3 counts and 3 locks
+		// in chunks of 2, then a count and the withdrawable amount, which is
+		// sent around Multicall3.
+		{name: "aggregate3", batcher: true, aggregates: 11, batches: 1},
+		{name: "aggregate3 without batcher", aggregates: 11, calls: 1},
+		{name: "batch", deployed: 1 << 62, batcher: true, batches: 11},
+		{name: "sequential", deployed: 1 << 62, calls: 19},
+	}
+	for _, tt := range tests {
+		c := &chain{head: 20, deployed: tt.deployed}
+		var batcher Batcher
+		if tt.batcher {
+			batcher = c
+		}
+		r, err := NewReader(contract, c, batcher, Config{BatchSize: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		caller, err := primev.NewBuilderStakingCaller(contract, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := &bind.CallOpts{BlockNumber: big.NewInt(7)}
+
+		var (
+			wantBuilders []BuilderInfo
+			wantStakes   []StakeInfo
+			wantMinimal  []bool
+			wantCounts   []*big.Int
+			wantLocks    [][]TimeLock
+		)
+		for _, b := range builders {
+			info, err := caller.Builders(opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantBuilders = append(wantBuilders, info)
+			count, err := caller.TimeLocksCount(opts, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantCounts = append(wantCounts, count)
+			locks := []TimeLock{}
+			for i := int64(0); i < count.Int64(); i++ {
+				l, err := caller.TimeLocks(opts, b, big.NewInt(i))
+				if err != nil {
+					t.Fatal(err)
+				}
+				locks = append(locks, l)
+			}
+			wantLocks = append(wantLocks, locks)
+		}
+		for _, cm := range commitments {
+			s, err := caller.Stakes(opts, cm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantStakes = append(wantStakes, s)
+			ok, err := caller.HasMinimalStake(opts, builders[2], cm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantMinimal = append(wantMinimal, ok)
+		}
+		senderOpts := &bind.CallOpts{From: builders[2], BlockNumber: opts.BlockNumber}
+		wantWithdrawable, err := caller.WithdrawableAmount(senderOpts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.calls = 0
+
+		if got, err := r.Builders(opts, builders); err != nil || !reflect.DeepEqual(got, wantBuilders) {
+			t.Errorf("%s: builders %v (%v), want %v", tt.name, got, err, wantBuilders)
+		}
+		if got, err := r.Stakes(opts, commitments); err != nil || !reflect.DeepEqual(got, wantStakes) {
+			t.Errorf("%s: stakes %v (%v), want %v", tt.name, got, err, wantStakes)
+		}
+		if got, err := r.HasMinimalStake(opts, builders[2], commitments); err != nil || !reflect.DeepEqual(got, wantMinimal) {
+			t.Errorf("%s: has minimal stake %v (%v), want %v", tt.name, got, err, wantMinimal)
+		}
+		if got, err := r.TimeLocks(opts, builders); err != nil || !reflect.DeepEqual(got, wantLocks) {
+			t.Errorf("%s: time locks %v (%v), want %v", tt.name, got, err, wantLocks)
+		}
+		// withdrawableAmount is mixed into an otherwise aggregated read.
+		out, err := r.Call(senderOpts, []Call{{"timeLocksCount", []interface{}{builders[2]}}, {"withdrawableAmount", nil}})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		} else if out[0][0].(*big.Int).Cmp(wantCounts[2]) != 0 || out[1][0].(*big.Int).Cmp(wantWithdrawable) != 0 {
+			t.Errorf("%s: count %v, withdrawable %v; want %v, %v", tt.name, out[0][0], out[1][0], wantCounts[2], wantWithdrawable)
+		}
+		if c.aggregates != tt.aggregates || c.batches != tt.batches || c.calls != tt.calls {
+			t.Errorf("%s: %d aggregates, %d batches, %d calls; want %d, %d, %d", tt.name,
+				c.aggregates, c.batches, c.calls, tt.aggregates, tt.batches, tt.calls)
+		}
+		if c.codeAt != 1 {
+			t.Errorf("%s: %d code lookups, want 1", tt.name, c.codeAt)
+		}
+	}
+}
+
+func TestCallError(t *testing.T) {
+	c := &chain{head: 20}
+	r, err := NewReader(contract, c, c, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Call(nil, []Call{{"timeLocksCount", []interface{}{builders[1]}}, {"timeLocks", []interface{}{builders[1], big.NewInt(1)}}})
+	var callErr *CallError
+	if !errors.As(err, &callErr) || callErr.Index != 1 || callErr.Method != "timeLocks" {
+		t.Errorf("err %v, want timeLocks call 1 failing", err)
+	}
+	if _, err := r.Call(&bind.CallOpts{Pending: true}, nil); err != ErrPending {
+		t.Errorf("pending: %v, want %v", err, ErrPending)
+	}
+}
+
+func TestDetectPerBlock(t *testing.T) {
+	c := &chain{head: 20, deployed: 10}
+	r, err := NewReader(contract, c, c, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		block     int64
+		aggregate bool
+		lookup    bool // Whether the block needs a code lookup
+	}{
+		{5, false, true},
+		{12, true, true},
+		{3, false, false},
+		{8, false, true},
+		{9, false, true},
+		{10, true, true},
+		{11, true, false},
+		{20, true, false},
+	}
+	for _, tt := range tests {
+		aggregates, batches, codeAt := c.aggregates, c.batches, c.codeAt
+		if _, err := r.Builders(&bind.CallOpts{BlockNumber: big.NewInt(tt.block)}, builders); err != nil {
+			t.Errorf("block %d: %v", tt.block, err)
+			continue
+		}
+		if aggregate := c.aggregates > aggregates; aggregate != tt.aggregate || (c.batches > batches) == tt.aggregate {
+			t.Errorf("block %d: aggregated %v, want %v", tt.block, aggregate, tt.aggregate)
+		}
+		if lookup := c.codeAt > codeAt; lookup != tt.lookup {
+			t.Errorf("block %d: looked up code %v, want %v", tt.block, lookup, tt.lookup)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package offline
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// ParseArgs converts command line arguments into the Go values expected by
+// the ABI packer for method.
+func ParseArgs(method string, args []string) ([]interface{}, error) {
+	parsed, err := primev.BuilderStakingMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	m, ok := parsed.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("offline: unknown method %q", method)
+	}
+	if len(args) != len(m.Inputs) {
+		return nil, fmt.Errorf("offline: %s expects %d arguments, got %d", method, len(m.Inputs), len(args))
+	}
+	params := make([]interface{}, len(args))
+	for i, input := range m.Inputs {
+		switch input.Type.T {
+		case abi.AddressTy:
+			if !common.IsHexAddress(args[i]) {
+				return nil, fmt.Errorf("offline: %s: invalid address %q", input.Name, args[i])
+			}
+			params[i] = common.HexToAddress(args[i])
+		case abi.UintTy:
+			v, ok := math.ParseBig256(args[i])
+			if !ok || v.Sign() < 0 {
+				return nil, fmt.Errorf("offline: %s: invalid integer %q", input.Name, args[i])
+			}
+			params[i] = v
+		case abi.FixedBytesTy:
+			b, err := hexutil.Decode(args[i])
+			if err != nil || len(b) != input.Type.Size {
+				return nil, fmt.Errorf("offline: %s: invalid %s %q", input.Name, input.Type, args[i])
+			}
+			var v [32]byte
+			copy(v[:], b)
+			params[i] = v
+		default:
+			return nil, fmt.Errorf("offline: %s: unsupported type %s", input.Name, input.Type)
+		}
+	}
+	return params, nil
+}
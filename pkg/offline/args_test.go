@@ -0,0 +1,81 @@
+package offline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// sampleArg returns a valid command line argument of type t.
+func sampleArg(t abi.Type) string {
+	switch t.T {
+	case abi.AddressTy:
+		return "0x00000000000000000000000000000000000000b1"
+	case abi.FixedBytesTy:
+		return "0x" + strings.Repeat("c1", t.Size)
+	default:
+		return "1000000000000000000"
+	}
+}
+
+func TestParseArgsAllMethods(t *testing.T) {
+	parsed, err := primev.BuilderStakingMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, method := range parsed.Methods {
+		var args []string
+		for _, input := range method.Inputs {
+			args = append(args, sampleArg(input.Type))
+		}
+		params, err := ParseArgs(name, args)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		data, err := parsed.Pack(name, params...)
+		if err != nil {
+			t.Errorf("%s: pack: %v", name, err)
+			continue
+		}
+		intent, err := DecodeIntent(data)
+		if err != nil {
+			t.Errorf("%s: decode: %v", name, err)
+			continue
+		}
+		if intent.Method != name || len(intent.Args) != len(args) {
+			t.Errorf("%s: decoded %+v", name, intent)
+			continue
+		}
+		for i, arg := range intent.Args {
+			if !strings.EqualFold(arg.Value, args[i]) {
+				t.Errorf("%s: argument %s decoded as %s, want %s", name, arg.Name, arg.Value, args[i])
+			}
+		}
+	}
+}
+
+func TestParseArgsErrors(t *testing.T) {
+	commitment := "0x" + strings.Repeat("c1", 32)
+	tests := []struct {
+		name   string
+		method string
+		args   []string
+		err    string
+	}{
+		{"unknown method", "mint", nil, "unknown method"},
+		{"missing argument", "deposit", []string{"0x00000000000000000000000000000000000000b1"}, "expects 2 arguments"},
+		{"invalid address", "deposit", []string{"0xb1", commitment}, "invalid address"},
+		{"short commitment", "deposit", []string{"0x00000000000000000000000000000000000000b1", "0xc1"}, "invalid bytes32"},
+		{"invalid integer", "updateBuilder", []string{"1e18", "100"}, "invalid integer"},
+		{"negative integer", "updateBuilder", []string{"-1", "100"}, "invalid integer"},
+	}
+	for _, tt := range tests {
+		_, err := ParseArgs(tt.method, tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package offline
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SignedTx is a signed transaction ready to be broadcast.
+type SignedTx struct {
+	Hash   common.Hash    `json:"hash"`
+	From   common.Address `json:"from"`
+	Raw    hexutil.Bytes  `json:"raw"`
+	Intent Intent         `json:"intent"`
+}
+
+// Transaction builds the unsigned EIP-1559 transaction.
+func (tx *UnsignedTx) Transaction() *types.Transaction {
+	to := tx.To
+	value := new(big.Int)
+	if tx.Value != nil {
+		value = tx.Value.ToInt()
+	}
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   tx.ChainID.ToInt(),
+		Nonce:     uint64(tx.Nonce),
+		GasTipCap: tx.GasTipCap.ToInt(),
+		GasFeeCap: tx.GasFeeCap.ToInt(),
+		Gas:       uint64(tx.Gas),
+		To:        &to,
+		Value:     value,
+		Data:      tx.Data,
+	})
+}
+
+// Sign verifies the unsigned transaction and signs it with key. It does not
+// need any network access.
+func Sign(tx *UnsignedTx, key *ecdsa.PrivateKey) (*SignedTx, error) {
+	if err := tx.Verify(); err != nil {
+		return nil, err
+	}
+	if tx.ChainID == nil || tx.GasTipCap == nil || tx.GasFeeCap == nil {
+		return nil, errors.New("offline: transaction is missing chain id or fees")
+	}
+	if from := crypto.PubkeyToAddress(key.PublicKey); from != tx.From {
+		return nil, fmt.Errorf("offline: key %s does not match sender %s", from, tx.From)
+	}
+	signed, err := types.SignTx(tx.Transaction(), types.LatestSignerForChainID(tx.ChainID.ToInt()), key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &SignedTx{Hash: signed.Hash(), From: tx.From, Raw: raw, Intent: tx.Intent}, nil
+}
+
+// Broadcast sends the signed transaction and waits until it is mined. A
+// receipt with failed status is returned together with an error.
+func Broadcast(ctx context.Context, backend Backend, signed *SignedTx) (*types.Receipt, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(signed.Raw); err != nil {
+		return nil, err
+	}
+	if tx.Hash() != signed.Hash {
+		return nil, fmt.Errorf("offline: raw transaction hash %s does not match %s", tx.Hash(), signed.Hash)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return nil, err
+	}
+	if sender != signed.From {
+		return nil, fmt.Errorf("offline: transaction signed by %s, expected %s", sender, signed.From)
+	}
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if chainID.Cmp(tx.ChainId()) != 0 {
+		return nil, fmt.Errorf("offline: transaction for chain %v, node is on chain %v", tx.ChainId(), chainID)
+	}
+	if err := backend.SendTransaction(ctx, tx); err != nil {
+		return nil, err
+	}
+	receipt, err := bind.WaitMined(ctx, backend, tx)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return receipt, fmt.Errorf("offline: transaction %s reverted", tx.Hash())
+	}
+	return receipt, nil
+}
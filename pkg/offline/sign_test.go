@@ -0,0 +1,91 @@
+package offline
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/primevprotocol/primev-contracts/pkg/fees"
+)
+
+// simulated is a simulated chain that mines every transaction at once.
+type simulated struct {
+	*backends.SimulatedBackend
+}
+
+func (s simulated) ChainID(ctx context.Context) (*big.Int, error) {
+	return s.Blockchain().Config().ChainID, nil
+}
+
+func (s simulated) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := s.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	s.Commit()
+	return nil
+}
+
+func TestSignBroadcast(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	backend := simulated{backends.NewSimulatedBackend(core.GenesisAlloc{from: {Balance: big.NewInt(params.Ether)}}, 10_000_000)}
+	defer backend.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The simulated chain has no contract at the address, so the call
+	// succeeds like a plain transfer.
+	address := crypto.CreateAddress(from, 100)
+	args, err := ParseArgs("transferOwnership", []string{"0x00000000000000000000000000000000000000b1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strategy := &fees.Fixed{TipCap: big.NewInt(1), FeeCap: new(big.Int).Mul(head.BaseFee, big.NewInt(2))}
+	unsigned, err := Export(backend, address, &ExportOpts{Context: ctx, From: from, Strategy: strategy, Margin: 20}, "transferOwnership", args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unsigned.Intent.Method != "transferOwnership" || unsigned.ChainID.ToInt().Cmp(big.NewInt(1337)) != 0 {
+		t.Fatalf("exported %+v", unsigned)
+	}
+
+	other, _ := crypto.GenerateKey()
+	if _, err := Sign(unsigned, other); err == nil || !strings.Contains(err.Error(), "does not match sender") {
+		t.Fatalf("signing with another key: err = %v", err)
+	}
+	signed, err := Sign(unsigned, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forged := *signed
+	forged.From = crypto.PubkeyToAddress(other.PublicKey)
+	if _, err := Broadcast(ctx, backend, &forged); err == nil || !strings.Contains(err.Error(), "signed by") {
+		t.Fatalf("broadcast with another sender: err = %v", err)
+	}
+	receipt, err := Broadcast(ctx, backend, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.TxHash != signed.Hash || receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("receipt %+v", receipt)
+	}
+	tx, _, err := backend.TransactionByHash(ctx, signed.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Gas() != uint64(unsigned.Gas) || string(tx.Data()) != string(unsigned.Data) || *tx.To() != address {
+		t.Errorf("mined transaction differs from the export: %+v", tx)
+	}
+}
@@ -0,0 +1,215 @@
+// Package offline implements an air-gapped signing workflow for BuilderStaking
+// transactions: export an unsigned transaction online, sign it on an offline
+// machine and broadcast the signed transaction online again.
+package offline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/primevprotocol/primev-contracts/pkg/fees"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// Backend is the online node connection needed to export and broadcast
+// transactions.
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// Arg is a single decoded method argument.
+type Arg struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Intent is the human readable meaning of a transaction's calldata.
+type Intent struct {
+	Method string `json:"method"`
+	Args   []Arg  `json:"args"`
+}
+
+// UnsignedTx is an EIP-1559 transaction prepared for offline signing.
+type UnsignedTx struct {
+	ChainID   *hexutil.Big   `json:"chainId"`
+	From      common.Address `json:"from"`
+	To        common.Address `json:"to"`
+	Nonce     hexutil.Uint64 `json:"nonce"`
+	Gas       hexutil.Uint64 `json:"gas"`
+	GasTipCap *hexutil.Big   `json:"maxPriorityFeePerGas"`
+	GasFeeCap *hexutil.Big   `json:"maxFeePerGas"`
+	Value     *hexutil.Big   `json:"value"`
+	Data      hexutil.Bytes  `json:"data"`
+	Intent    Intent         `json:"intent"`
+
+	// Withdrawable is the amount vested for From at export time, set for
+	// withdraw transactions only.
+	Withdrawable *hexutil.Big `json:"withdrawable,omitempty"`
+}
+
+// ExportOpts configures Export.
+type ExportOpts struct {
+	Context  context.Context
+	From     common.Address // Account that will sign the transaction offline
+	Value    *big.Int       // Wei sent along, nil for none
+	Nonce    *uint64        // Nonce to use, pending nonce of From if nil
+	Strategy fees.Strategy  // Fee strategy, required
+	Margin   uint64         // Percent added on top of the simulated gas usage
+}
+
+// Export prepares an unsigned call of method on the BuilderStaking contract
+// at address. The call is simulated to determine the gas limit.
+func Export(backend Backend, address common.Address, opts *ExportOpts, method string, params ...interface{}) (*UnsignedTx, error) {
+	if opts.Strategy == nil {
+		return nil, errors.New("offline: no fee strategy")
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parsed, err := primev.BuilderStakingMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	data, err := parsed.Pack(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	intent, err := DecodeIntent(data)
+	if err != nil {
+		return nil, err
+	}
+
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("offline: chain id: %w", err)
+	}
+	var nonce uint64
+	if opts.Nonce != nil {
+		nonce = *opts.Nonce
+	} else if nonce, err = backend.PendingNonceAt(ctx, opts.From); err != nil {
+		return nil, fmt.Errorf("offline: nonce: %w", err)
+	}
+	value := opts.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	gas, err := backend.EstimateGas(ctx, ethereum.CallMsg{From: opts.From, To: &address, Value: value, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("offline: estimate gas for %s: %w", method, err)
+	}
+	suggested, err := opts.Strategy.Fees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &UnsignedTx{
+		ChainID:   (*hexutil.Big)(chainID),
+		From:      opts.From,
+		To:        address,
+		Nonce:     hexutil.Uint64(nonce),
+		Gas:       hexutil.Uint64(gas + gas*opts.Margin/100),
+		GasTipCap: (*hexutil.Big)(suggested.TipCap),
+		GasFeeCap: (*hexutil.Big)(suggested.FeeCap),
+		Value:     (*hexutil.Big)(value),
+		Data:      data,
+		Intent:    *intent,
+	}
+	if method == "withdraw" {
+		caller, err := primev.NewBuilderStakingCaller(address, backend)
+		if err != nil {
+			return nil, err
+		}
+		amount, err := caller.WithdrawableAmount(&bind.CallOpts{Context: ctx, From: opts.From})
+		if err != nil {
+			return nil, fmt.Errorf("offline: withdrawable amount: %w", err)
+		}
+		tx.Withdrawable = (*hexutil.Big)(amount)
+	}
+	return tx, nil
+}
+
+// DecodeIntent decodes BuilderStaking calldata into its method and arguments.
+func DecodeIntent(data []byte) (*Intent, error) {
+	parsed, err := primev.BuilderStakingMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, errors.New("offline: calldata too short")
+	}
+	method, err := parsed.MethodById(data[:4])
+	if err != nil {
+		return nil, err
+	}
+	values, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	intent := &Intent{Method: method.Name, Args: make([]Arg, len(values))}
+	for i, v := range values {
+		intent.Args[i] = Arg{
+			Name:  method.Inputs[i].Name,
+			Type:  method.Inputs[i].Type.String(),
+			Value: formatValue(method.Inputs[i].Type, v),
+		}
+	}
+	return intent, nil
+}
+
+// Verify checks that the intent recorded in the file matches its calldata, so
+// an edited file cannot make the signer approve something else.
+func (tx *UnsignedTx) Verify() error {
+	intent, err := DecodeIntent(tx.Data)
+	if err != nil {
+		return err
+	}
+	want, _ := json.Marshal(intent)
+	got, _ := json.Marshal(tx.Intent)
+	if string(want) != string(got) {
+		return fmt.Errorf("offline: intent %s does not match calldata %s", got, want)
+	}
+	return nil
+}
+
+func formatValue(t abi.Type, v interface{}) string {
+	switch t.T {
+	case abi.FixedBytesTy:
+		if b, ok := v.([32]byte); ok {
+			return common.Hash(b).Hex()
+		}
+	case abi.AddressTy:
+		return v.(common.Address).Hex()
+	}
+	return fmt.Sprint(v)
+}
+
+// WriteJSON writes v as indented JSON to path.
+func WriteJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// ReadJSON reads JSON from path into v.
+func ReadJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
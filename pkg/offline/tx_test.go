@@ -0,0 +1,91 @@
+package offline
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// unsignedDeposit returns a consistent unsigned deposit to builder.
+func unsignedDeposit(t *testing.T, from common.Address, builder common.Address) *UnsignedTx {
+	parsed, err := primev.BuilderStakingMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := parsed.Pack("deposit", builder, common.HexToHash("0xc1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	intent, err := DecodeIntent(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &UnsignedTx{
+		ChainID:   (*hexutil.Big)(big.NewInt(1337)),
+		From:      from,
+		To:        common.HexToAddress("0x5a"),
+		Gas:       100000,
+		GasTipCap: (*hexutil.Big)(big.NewInt(1)),
+		GasFeeCap: (*hexutil.Big)(big.NewInt(1e10)),
+		Value:     (*hexutil.Big)(big.NewInt(1e18)),
+		Data:      data,
+		Intent:    *intent,
+	}
+}
+
+func TestVerify(t *testing.T) {
+	builder, other := common.HexToAddress("0xb1"), common.HexToAddress("0xb2")
+	otherData := unsignedDeposit(t, common.Address{}, other).Data
+	tests := []struct {
+		name   string
+		modify func(tx *UnsignedTx)
+		err    string
+	}{
+		{name: "unchanged", modify: func(tx *UnsignedTx) {}},
+		{
+			// The calldata pays another builder while the intent still
+			// shows the original one.
+			name:   "calldata changed",
+			modify: func(tx *UnsignedTx) { tx.Data = otherData },
+			err:    "does not match calldata",
+		},
+		{
+			name:   "calldata byte flipped",
+			modify: func(tx *UnsignedTx) { tx.Data[len(tx.Data)-1] ^= 1 },
+			err:    "does not match calldata",
+		},
+		{
+			name:   "intent changed",
+			modify: func(tx *UnsignedTx) { tx.Intent.Args[0].Value = other.Hex() },
+			err:    "does not match calldata",
+		},
+		{
+			name:   "method changed",
+			modify: func(tx *UnsignedTx) { copy(tx.Data[:4], []byte{0xde, 0xad, 0xbe, 0xef}) },
+			err:    "no method with id",
+		},
+		{
+			name:   "truncated",
+			modify: func(tx *UnsignedTx) { tx.Data = tx.Data[:3] },
+			err:    "too short",
+		},
+	}
+	for _, tt := range tests {
+		tx := unsignedDeposit(t, common.Address{}, builder)
+		tt.modify(tx)
+		err := tx.Verify()
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
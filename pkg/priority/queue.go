@@ -0,0 +1,338 @@
+// Package priority orders incoming bundles by the submitting commitment's
+// stake, its remaining subscription blocks and the bundle value.
+//
+// Pure stake ordering would let a single large staker occupy the builder
+// indefinitely, so the queue adds two fairness mechanisms: bundles gain score
+// while they wait, and a commitment that took more than its share of the
+// recent pops is passed over while others are waiting.
+package priority
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/primevprotocol/primev-contracts/pkg/gate"
+)
+
+// Defaults used for zero Config fields.
+const (
+	DefaultMaxShare         = 0.5
+	DefaultWindow           = 100
+	DefaultMaxPerCommitment = 64
+)
+
+var (
+	// ErrCommitmentFull is returned by Push if the commitment already has
+	// MaxPerCommitment bundles queued.
+	ErrCommitmentFull = errors.New("priority: too many queued bundles for commitment")
+	// ErrNotEligible is returned by Push if the commitment lacks the
+	// builder's minimal stake or its subscription has ended.
+	ErrNotEligible = errors.New("priority: commitment not eligible")
+	// ErrClosed is returned once the queue is closed.
+	ErrClosed = errors.New("priority: queue closed")
+)
+
+// Bundle is a queued bundle.
+type Bundle struct {
+	Commitment common.Hash
+	Value      *big.Int    // Value the bundle pays the builder, in wei
+	Payload    interface{} // Opaque to the queue
+	Received   time.Time   // Set by Push if zero
+}
+
+// Weights configure the default score:
+//
+//	Stake*stake[ETH] + Blocks*remainingBlocks + Value*value[ETH] + Age*waited[s]
+type Weights struct {
+	Stake  float64
+	Blocks float64
+	Value  float64
+	Age    float64
+}
+
+// DefaultWeights rank mostly by stake, with waiting time as a tie breaker
+// that eventually lifts every bundle to the front.
+var DefaultWeights = Weights{Stake: 1, Blocks: 0.0001, Value: 10, Age: 0.1}
+
+// StakeState is what the queue knows about a commitment.
+type StakeState struct {
+	Stake           *big.Int
+	SubscriptionEnd *big.Int
+}
+
+// ScoreFunc scores a bundle given its commitment's stake, the remaining
+// subscription blocks (zero once ended) and the bundle. Waiting time is added
+// by the queue using Weights.Age.
+type ScoreFunc func(s StakeState, remaining uint64, b *Bundle) float64
+
+// Config configures a Queue.
+type Config struct {
+	Weights          Weights   // Used by the default score, DefaultWeights if zero
+	Score            ScoreFunc // Replaces the default score if set
+	MaxShare         float64   // Share of the last Window pops one commitment may take while others wait, at least one pop
+	Window           int       // Number of recent pops MaxShare applies to
+	MaxPerCommitment int       // Queued bundles per commitment
+}
+
+type item struct {
+	bundle *Bundle
+	key    float64 // Score without the waiting time term
+	index  int
+}
+
+type itemHeap []*item
+
+func (h itemHeap) Len() int           { return len(h) }
+func (h itemHeap) Less(i, j int) bool { return h[i].key > h[j].key }
+func (h itemHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+func (h *itemHeap) Push(x interface{}) {
+	it := x.(*item)
+	it.index = len(*h)
+	*h = append(*h, it)
+}
+func (h *itemHeap) Pop() interface{} {
+	old := *h
+	it := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return it
+}
+
+// Queue is a concurrent stake-weighted priority queue.
+type Queue struct {
+	cfg     Config
+	checker gate.StakeChecker
+
+	mu      sync.Mutex
+	ready   chan struct{} // Closed and replaced whenever an item is added
+	closed  bool
+	heap    itemHeap
+	queued  map[common.Hash]int
+	stakes  map[common.Hash]StakeState
+	head    uint64
+	recent  []common.Hash // Ring buffer of the last Window pops
+	next    int
+	counted map[common.Hash]int
+}
+
+// New creates a Queue that checks pushed commitments with checker. The share
+// of recent pops must amount to at least one pop, otherwise fairness could
+// not be enforced.
+func New(checker gate.StakeChecker, cfg Config) (*Queue, error) {
+	if cfg.Weights == (Weights{}) {
+		cfg.Weights = DefaultWeights
+	}
+	if cfg.MaxShare == 0 {
+		cfg.MaxShare = DefaultMaxShare
+	}
+	if cfg.Window == 0 {
+		cfg.Window = DefaultWindow
+	}
+	if cfg.MaxPerCommitment == 0 {
+		cfg.MaxPerCommitment = DefaultMaxPerCommitment
+	}
+	if cfg.MaxShare < 0 || cfg.Window < 0 || cfg.MaxPerCommitment < 0 {
+		return nil, errors.New("priority: negative limit")
+	}
+	if int(cfg.MaxShare*float64(cfg.Window)) < 1 {
+		return nil, fmt.Errorf("priority: max share %v of %d pops is less than one pop", cfg.MaxShare, cfg.Window)
+	}
+	return &Queue{
+		cfg:     cfg,
+		checker: checker,
+		ready:   make(chan struct{}),
+		queued:  make(map[common.Hash]int),
+		stakes:  make(map[common.Hash]StakeState),
+		recent:  make([]common.Hash, 0, cfg.Window),
+		counted: make(map[common.Hash]int),
+	}, nil
+}
+
+// Len returns the number of queued bundles.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.heap)
+}
+
+// Push queues b if its commitment is eligible. Every push is checked with the
+// queue's checker, so the checker should cache its reads. The stake the bundle
+// is scored with is the one of the first queued bundle of the commitment,
+// kept up to date by UpdateStake.
+func (q *Queue) Push(ctx context.Context, b *Bundle) error {
+	if b.Received.IsZero() {
+		b.Received = time.Now()
+	}
+	if b.Value == nil {
+		b.Value = new(big.Int)
+	}
+	access, err := q.checker.Check(ctx, b.Commitment)
+	if err != nil {
+		return err
+	}
+	if !access.Allowed() {
+		return ErrNotEligible
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if q.queued[b.Commitment] >= q.cfg.MaxPerCommitment {
+		return ErrCommitmentFull
+	}
+	if _, ok := q.stakes[b.Commitment]; !ok {
+		q.stakes[b.Commitment] = StakeState{Stake: access.Stake, SubscriptionEnd: access.SubscriptionEnd}
+		if access.Block > q.head {
+			q.head = access.Block
+		}
+	}
+	q.queued[b.Commitment]++
+	heap.Push(&q.heap, &item{bundle: b, key: q.key(b)})
+	close(q.ready)
+	q.ready = make(chan struct{})
+	return nil
+}
+
+// Pop removes and returns the best bundle, waiting until one is available.
+func (q *Queue) Pop(ctx context.Context) (*Bundle, error) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return nil, ErrClosed
+		}
+		if len(q.heap) > 0 {
+			b := q.pop()
+			q.mu.Unlock()
+			return b, nil
+		}
+		ready := q.ready
+		q.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ready:
+		}
+	}
+}
+
+// pop takes the best bundle whose commitment is within its share, or the
+// best bundle overall if every waiting commitment is over its share.
+func (q *Queue) pop() *Bundle {
+	var skipped []*item
+	limit := int(q.cfg.MaxShare * float64(q.cfg.Window))
+	var chosen *item
+	for len(q.heap) > 0 {
+		it := heap.Pop(&q.heap).(*item)
+		if q.counted[it.bundle.Commitment] < limit {
+			chosen = it
+			break
+		}
+		skipped = append(skipped, it)
+	}
+	if chosen == nil {
+		chosen, skipped = skipped[0], skipped[1:]
+	}
+	for _, it := range skipped {
+		heap.Push(&q.heap, it)
+	}
+
+	commitment := chosen.bundle.Commitment
+	if len(q.recent) < q.cfg.Window {
+		q.recent = append(q.recent, commitment)
+	} else {
+		q.counted[q.recent[q.next]]--
+		if q.counted[q.recent[q.next]] == 0 {
+			delete(q.counted, q.recent[q.next])
+		}
+		q.recent[q.next] = commitment
+		q.next = (q.next + 1) % q.cfg.Window
+	}
+	q.counted[commitment]++
+
+	if q.queued[commitment]--; q.queued[commitment] == 0 {
+		delete(q.queued, commitment)
+		// Looked up again on the next push rather than tracked forever.
+		delete(q.stakes, commitment)
+	}
+	return chosen.bundle
+}
+
+// UpdateStake records new stake values for commitment, e.g. from a
+// StakeUpdated event, and re-scores its queued bundles.
+func (q *Queue) UpdateStake(commitment common.Hash, stake, subscriptionEnd *big.Int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.queued[commitment] == 0 {
+		return
+	}
+	q.stakes[commitment] = StakeState{Stake: stake, SubscriptionEnd: subscriptionEnd}
+	for _, it := range q.heap {
+		if it.bundle.Commitment == commitment {
+			it.key = q.key(it.bundle)
+			heap.Fix(&q.heap, it.index)
+		}
+	}
+}
+
+// SetHead updates the block remaining subscription blocks are counted from
+// and re-scores every queued bundle.
+func (q *Queue) SetHead(block uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if block <= q.head {
+		return
+	}
+	q.head = block
+	for _, it := range q.heap {
+		it.key = q.key(it.bundle)
+	}
+	heap.Init(&q.heap)
+}
+
+// Close wakes up all waiting Pop calls and rejects further pushes.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed = true
+		close(q.ready)
+	}
+}
+
+// key returns the score of b without the waiting time term. Adding
+// Age*(now-received) to every score preserves their order, so the heap
+// instead subtracts Age*received once and never needs re-sorting over time.
+func (q *Queue) key(b *Bundle) float64 {
+	s := q.stakes[b.Commitment]
+	if s.Stake == nil {
+		s = StakeState{Stake: new(big.Int), SubscriptionEnd: new(big.Int)}
+	}
+	var remaining uint64
+	if head := new(big.Int).SetUint64(q.head); s.SubscriptionEnd.Cmp(head) > 0 {
+		remaining = new(big.Int).Sub(s.SubscriptionEnd, head).Uint64()
+	}
+	var score float64
+	if q.cfg.Score != nil {
+		score = q.cfg.Score(s, remaining, b)
+	} else {
+		w := q.cfg.Weights
+		score = w.Stake*ether(s.Stake) + w.Blocks*float64(remaining) + w.Value*ether(b.Value)
+	}
+	return score - q.cfg.Weights.Age*float64(b.Received.UnixNano())/float64(time.Second)
+}
+
+func ether(wei *big.Int) float64 {
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.Ether)).Float64()
+	return f
+}
@@ -0,0 +1,186 @@
+package priority
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/primevprotocol/primev-contracts/pkg/gate"
+)
+
+// stakes answers stake checks at block 100 with the given stakes in ether, a
+// minimal stake of one ether and a subscription ending at block 1000, or
+// block 50 for negative stakes.
+type stakes map[common.Hash]int64
+
+func (s stakes) Check(ctx context.Context, commitment common.Hash) (*gate.Access, error) {
+	stake, end := s[commitment], int64(1000)
+	if stake < 0 {
+		stake, end = -stake, 50
+	}
+	return &gate.Access{
+		Commitment:      commitment,
+		Block:           100,
+		Stake:           new(big.Int).Mul(big.NewInt(stake), big.NewInt(params.Ether)),
+		SubscriptionEnd: big.NewInt(end),
+		MinimalStake:    big.NewInt(params.Ether),
+		HasMinimalStake: stake >= 1,
+		Active:          end > 100,
+	}, nil
+}
+
+func newQueue(t *testing.T, checker gate.StakeChecker, cfg Config) *Queue {
+	q, err := New(checker, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+var (
+	whale = common.HexToHash("0x01")
+	small = common.HexToHash("0x02")
+	mid   = common.HexToHash("0x03")
+)
+
+func push(t *testing.T, q *Queue, received time.Time, commitments ...common.Hash) {
+	for _, c := range commitments {
+		if err := q.Push(context.Background(), &Bundle{Commitment: c, Received: received}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func popAll(t *testing.T, q *Queue) []common.Hash {
+	var order []common.Hash
+	for q.Len() > 0 {
+		b, err := q.Pop(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		order = append(order, b.Commitment)
+	}
+	return order
+}
+
+func TestQueueOrder(t *testing.T) {
+	received := time.Now()
+	tests := []struct {
+		name   string
+		cfg    Config
+		pushes []common.Hash
+		want   []common.Hash
+	}{
+		{
+			name:   "by stake",
+			pushes: []common.Hash{small, whale, mid},
+			want:   []common.Hash{whale, mid, small},
+		},
+		{
+			// Two of the last four pops is the whale's share, after which
+			// the waiting small staker goes first. Once nobody else waits
+			// the whale gets every pop.
+			name:   "share of recent pops",
+			cfg:    Config{MaxShare: 0.5, Window: 4},
+			pushes: []common.Hash{whale, whale, whale, whale, whale, whale, small, small},
+			want:   []common.Hash{whale, whale, small, small, whale, whale, whale, whale},
+		},
+		{
+			name:   "share shared by several commitments",
+			cfg:    Config{MaxShare: 0.25, Window: 4},
+			pushes: []common.Hash{whale, whale, mid, mid, small, small},
+			want:   []common.Hash{whale, mid, small, whale, mid, small},
+		},
+	}
+	for _, tt := range tests {
+		q := newQueue(t, stakes{whale: 100, mid: 10, small: 1}, tt.cfg)
+		push(t, q, received, tt.pushes...)
+		if got := popAll(t, q); !equal(got, tt.want) {
+			t.Errorf("%s: popped %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestQueueAge(t *testing.T) {
+	q := newQueue(t, stakes{whale: 5, small: 1}, Config{Weights: Weights{Stake: 1, Age: 1}})
+	now := time.Now()
+	// Ten seconds of waiting outweigh four ether of stake.
+	push(t, q, now.Add(-10*time.Second), small)
+	push(t, q, now, whale)
+	if got := popAll(t, q); !equal(got, []common.Hash{small, whale}) {
+		t.Fatalf("popped %v", got)
+	}
+}
+
+func TestQueueUpdateStake(t *testing.T) {
+	q := newQueue(t, stakes{whale: 100, small: 1}, Config{})
+	received := time.Now()
+	push(t, q, received, whale, small)
+	q.UpdateStake(small, new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether)), big.NewInt(1000))
+	if got := popAll(t, q); !equal(got, []common.Hash{small, whale}) {
+		t.Fatalf("popped %v", got)
+	}
+}
+
+func TestQueueLimits(t *testing.T) {
+	q := newQueue(t, stakes{whale: 1}, Config{MaxPerCommitment: 2})
+	push(t, q, time.Now(), whale, whale)
+	if err := q.Push(context.Background(), &Bundle{Commitment: whale}); !errors.Is(err, ErrCommitmentFull) {
+		t.Fatalf("third bundle: err = %v", err)
+	}
+	q.Close()
+	if _, err := q.Pop(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Fatalf("pop after close: err = %v", err)
+	}
+}
+
+func TestQueueEligibility(t *testing.T) {
+	expired := common.HexToHash("0x04")
+	q := newQueue(t, stakes{whale: 1, small: 0, expired: -5}, Config{})
+	for _, c := range []common.Hash{small, expired} {
+		if err := q.Push(context.Background(), &Bundle{Commitment: c}); !errors.Is(err, ErrNotEligible) {
+			t.Errorf("%s: err = %v, want %v", c, err, ErrNotEligible)
+		}
+	}
+	push(t, q, time.Now(), whale)
+	if q.Len() != 1 {
+		t.Errorf("%d bundles queued, want 1", q.Len())
+	}
+}
+
+func TestNewConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		ok   bool
+	}{
+		{"defaults", Config{}, true},
+		{"one pop", Config{MaxShare: 0.01, Window: 100}, true},
+		{"share below one pop", Config{MaxShare: 0.001, Window: 100}, false},
+		{"share of a tiny window", Config{MaxShare: 0.5, Window: 1}, false},
+		{"negative share", Config{MaxShare: -1}, false},
+		{"negative window", Config{Window: -4}, false},
+		{"negative per commitment", Config{MaxPerCommitment: -1}, false},
+	}
+	for _, tt := range tests {
+		if _, err := New(stakes{}, tt.cfg); (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func equal(a, b []common.Hash) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package priority
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/primevprotocol/primev-contracts/pkg/events"
+	"github.com/primevprotocol/primev-contracts/pkg/heads"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// Follow keeps the queue's scores current until ctx is done: every new head
+// updates the remaining subscription blocks and every StakeUpdated event in
+// it updates the stake of the commitment concerned.
+func (q *Queue) Follow(ctx context.Context, reader heads.Reader, filterer *primev.BuilderStakingFilterer, interval time.Duration) error {
+	var last uint64
+	return heads.Watch(ctx, reader, interval, func(head *types.Header) error {
+		number := head.Number.Uint64()
+		from := last + 1
+		if last == 0 || from > number {
+			// First head or a reorg to a lower height.
+			from = number
+		}
+		err := events.StakeUpdated(ctx, filterer, events.Range{From: from, To: number}, func(e *primev.BuilderStakingStakeUpdated) error {
+			q.UpdateStake(e.Commitment, e.Stake, e.SubscriptionEnd)
+			return nil
+		})
+		if err != nil {
+			log.Warn("Failed to read stake updates", "block", number, "err", err)
+			return nil
+		}
+		q.SetHead(number)
+		last = number
+		return nil
+	})
+}
+
+// Serve pops bundles in priority order and hands them to fn on workers
+// goroutines until ctx is done or the queue is closed.
+func (q *Queue) Serve(ctx context.Context, workers int, fn func(context.Context, *Bundle) error) error {
+	if workers <= 0 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				b, err := q.Pop(ctx)
+				if err != nil {
+					return
+				}
+				if err := fn(ctx, b); err != nil {
+					log.Debug("Bundle handler failed", "commitment", b.Commitment, "err", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
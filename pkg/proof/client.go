@@ -0,0 +1,50 @@
+package proof
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Transport signs every request it sends to Builder with Key.
+type Transport struct {
+	Key     *ecdsa.PrivateKey
+	Builder common.Address
+	Base    http.RoundTripper // http.DefaultTransport if nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	p, err := Sign(t.Key, t.Builder, time.Now().Unix(), body)
+	if err != nil {
+		return nil, err
+	}
+	// RoundTrippers must not modify the caller's request.
+	signed := r.Clone(r.Context())
+	signed.Body = io.NopCloser(bytes.NewReader(body))
+	signed.ContentLength = int64(len(body))
+	p.SetHeaders(signed.Header)
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(signed)
+}
+
+// NewClient returns an HTTP client signing requests to builder with key.
+func NewClient(key *ecdsa.PrivateKey, builder common.Address) *http.Client {
+	return &http.Client{Transport: &Transport{Key: key, Builder: builder}}
+}
@@ -0,0 +1,150 @@
+// Package proof lets a searcher prove to a builder that a request comes from
+// the account behind a commitment.
+//
+// A commitment is keccak256(abi.encodePacked(account, builder)). The searcher
+// signs each request with the commitment account's key; the builder recovers
+// the account, recomputes the commitment and checks its stake. The signed
+// message covers the builder, a timestamp, a random nonce and the body hash,
+// so a signature cannot be replayed against another builder, outside a short
+// window or twice within it.
+//
+// Proofs travel in three headers:
+//
+//	X-Primev-Signature: <account>:<signature>
+//	X-Primev-Timestamp: <unix seconds>
+//	X-Primev-Nonce:     <hex, up to 32 bytes>
+//
+// The signature is an EIP-191 personal signature over Digest.
+package proof
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Header names.
+const (
+	SignatureHeader = "X-Primev-Signature"
+	TimestampHeader = "X-Primev-Timestamp"
+	NonceHeader     = "X-Primev-Nonce"
+)
+
+const (
+	domain       = "primev-commitment-proof"
+	maxNonceSize = 32
+)
+
+// Proof is a signed claim that a request body comes from Account.
+type Proof struct {
+	Account   common.Address
+	Timestamp int64
+	Nonce     []byte
+	Signature []byte
+}
+
+// Commitment returns the commitment of account for builder, as computed by
+// searchers when depositing.
+func Commitment(account, builder common.Address) common.Hash {
+	return crypto.Keccak256Hash(account.Bytes(), builder.Bytes())
+}
+
+// Digest returns the hash that is personal-signed for a request to builder.
+func Digest(builder common.Address, timestamp int64, nonce, body []byte) []byte {
+	msg := fmt.Sprintf("%s\n%s\n%d\n%s\n%s", domain, builder.Hex(), timestamp, hexutil.Encode(nonce), crypto.Keccak256Hash(body).Hex())
+	return accounts.TextHash([]byte(msg))
+}
+
+// Sign creates a proof for body sent to builder at timestamp with a fresh
+// random nonce.
+func Sign(key *ecdsa.PrivateKey, builder common.Address, timestamp int64, body []byte) (*Proof, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sig, err := crypto.Sign(Digest(builder, timestamp, nonce, body), key)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return &Proof{
+		Account:   crypto.PubkeyToAddress(key.PublicKey),
+		Timestamp: timestamp,
+		Nonce:     nonce,
+		Signature: sig,
+	}, nil
+}
+
+// Recover returns the account that signed p for body sent to builder. It
+// fails if that is not p.Account.
+func (p *Proof) Recover(builder common.Address, body []byte) (common.Address, error) {
+	if len(p.Signature) != crypto.SignatureLength {
+		return common.Address{}, ErrBadSignature
+	}
+	sig := make([]byte, len(p.Signature))
+	copy(sig, p.Signature)
+	sig[crypto.RecoveryIDOffset] -= 27
+	pub, err := crypto.SigToPub(Digest(builder, p.Timestamp, p.Nonce, body), sig)
+	if err != nil {
+		return common.Address{}, ErrBadSignature
+	}
+	if account := crypto.PubkeyToAddress(*pub); account != p.Account {
+		return common.Address{}, ErrBadSignature
+	}
+	return p.Account, nil
+}
+
+// SetHeaders writes p into h.
+func (p *Proof) SetHeaders(h http.Header) {
+	h.Set(SignatureHeader, p.Account.Hex()+":"+hexutil.Encode(p.Signature))
+	h.Set(TimestampHeader, strconv.FormatInt(p.Timestamp, 10))
+	h.Set(NonceHeader, hexutil.Encode(p.Nonce))
+}
+
+// Errors returned when parsing and verifying proofs.
+var (
+	ErrMissingProof = errors.New("proof: missing " + SignatureHeader + " header")
+	ErrMalformed    = errors.New("proof: malformed proof headers")
+	ErrBadSignature = errors.New("proof: signature does not match account")
+	ErrExpired      = errors.New("proof: timestamp outside the accepted window")
+	ErrReplayed     = errors.New("proof: nonce already used")
+)
+
+// FromHeaders parses a proof from h.
+func FromHeaders(h http.Header) (*Proof, error) {
+	value := h.Get(SignatureHeader)
+	if value == "" {
+		return nil, ErrMissingProof
+	}
+	account, sig, ok := strings.Cut(value, ":")
+	if !ok || !common.IsHexAddress(account) {
+		return nil, ErrMalformed
+	}
+	signature, err := hexutil.Decode(sig)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	timestamp, err := strconv.ParseInt(h.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	nonce, err := hexutil.Decode(h.Get(NonceHeader))
+	if err != nil || len(nonce) == 0 || len(nonce) > maxNonceSize {
+		return nil, ErrMalformed
+	}
+	return &Proof{
+		Account:   common.HexToAddress(account),
+		Timestamp: timestamp,
+		Nonce:     nonce,
+		Signature: signature,
+	}, nil
+}
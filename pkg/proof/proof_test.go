@@ -0,0 +1,109 @@
+package proof
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	builder = common.HexToAddress("0x00000000000000000000000000000000000000b1")
+	other   = common.HexToAddress("0x00000000000000000000000000000000000000b2")
+)
+
+const body = `{"jsonrpc":"2.0","id":1,"method":"eth_sendBundle","params":[]}`
+
+func signedRequest(t *testing.T, key *ecdsa.PrivateKey, to common.Address, at time.Time, body string) *http.Request {
+	p, err := Sign(key, to, at.Unix(), []byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	p.SetHeaders(r.Header)
+	return r
+}
+
+func TestSignVerify(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	v := NewVerifier(builder, 0, nil)
+
+	got, commitment, err := v.Verify(signedRequest(t, key, builder, time.Now(), body), []byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != account || commitment != Commitment(account, builder) {
+		t.Fatalf("verified %s with commitment %s", got, commitment)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	now := time.Now()
+	tests := []struct {
+		name string
+		req  *http.Request
+		body string
+		want error
+	}{
+		{"missing proof", httptest.NewRequest(http.MethodPost, "/", nil), body, ErrMissingProof},
+		{"expired", signedRequest(t, key, builder, now.Add(-time.Minute), body), body, ErrExpired},
+		{"from the future", signedRequest(t, key, builder, now.Add(time.Minute), body), body, ErrExpired},
+		{"other builder", signedRequest(t, key, other, now, body), body, ErrBadSignature},
+		{"other body", signedRequest(t, key, builder, now, body), `{}`, ErrBadSignature},
+	}
+	for _, tt := range tests {
+		v := NewVerifier(builder, 30*time.Second, nil)
+		if _, _, err := v.Verify(tt.req, []byte(tt.body)); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestVerifyReplay(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	v := NewVerifier(builder, 0, nil)
+	r := signedRequest(t, key, builder, time.Now(), body)
+	if _, _, err := v.Verify(r, []byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := v.Verify(r, []byte(body)); !errors.Is(err, ErrReplayed) {
+		t.Fatalf("replayed proof: err = %v", err)
+	}
+	// A fresh proof of the same body carries a new nonce.
+	if _, _, err := v.Verify(signedRequest(t, key, builder, time.Now(), body), []byte(body)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemoryNonceStorePrunes(t *testing.T) {
+	var s MemoryNonceStore
+	account := common.HexToAddress("0x01")
+	now := time.Now()
+	if !s.Use(account, []byte{1}, now.Add(-time.Second)) {
+		t.Fatal("first use of nonce 1 rejected")
+	}
+	if !s.Use(account, []byte{2}, now.Add(time.Hour)) {
+		t.Fatal("first use of nonce 2 rejected")
+	}
+	if s.Use(account, []byte{2}, now.Add(time.Hour)) {
+		t.Fatal("reuse of unexpired nonce 2 accepted")
+	}
+	if len(s.nonces) != 1 || len(s.expires) != 1 {
+		t.Fatalf("store holds %d nonces and %d expiries after pruning", len(s.nonces), len(s.expires))
+	}
+	// An expired nonce may be recorded again.
+	if !s.Use(account, []byte{1}, now.Add(time.Hour)) {
+		t.Fatal("use of expired nonce 1 rejected")
+	}
+	if s.Use(account, []byte{1}, now.Add(time.Hour)) {
+		t.Fatal("reuse of nonce 1 accepted")
+	}
+}
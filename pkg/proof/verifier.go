@@ -0,0 +1,116 @@
+package proof
+
+import (
+	"container/heap"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// DefaultWindow is how far a proof's timestamp may be from the verifier's
+// clock if Verifier.Window is zero.
+const DefaultWindow = 30 * time.Second
+
+// NonceStore remembers used nonces. Use records nonce for account and
+// reports false if it was already recorded and has not expired yet. Gates
+// running several instances need a shared store.
+type NonceStore interface {
+	Use(account common.Address, nonce []byte, expires time.Time) bool
+}
+
+// MemoryNonceStore is an in-process NonceStore.
+type MemoryNonceStore struct {
+	mu      sync.Mutex
+	nonces  map[string]time.Time
+	expires expiryHeap
+}
+
+// expiry is a recorded nonce in expiryHeap.
+type expiry struct {
+	key string
+	at  time.Time
+}
+
+// expiryHeap orders recorded nonces by expiry, earliest first.
+type expiryHeap []expiry
+
+func (h expiryHeap) Len() int            { return len(h) }
+func (h expiryHeap) Less(i, j int) bool  { return h[i].at.Before(h[j].at) }
+func (h expiryHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *expiryHeap) Push(x interface{}) { *h = append(*h, x.(expiry)) }
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// Use implements NonceStore.
+func (s *MemoryNonceStore) Use(account common.Address, nonce []byte, expires time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if s.nonces == nil {
+		s.nonces = make(map[string]time.Time)
+	}
+	// Expired nonces are useless since their proofs fail the window check.
+	// Only those are visited, so pruning costs no more than the nonces it
+	// removes.
+	for len(s.expires) > 0 && !now.Before(s.expires[0].at) {
+		e := heap.Pop(&s.expires).(expiry)
+		if exp, ok := s.nonces[e.key]; ok && !now.Before(exp) {
+			delete(s.nonces, e.key)
+		}
+	}
+	key := account.Hex() + hexutil.Encode(nonce)
+	if exp, ok := s.nonces[key]; ok && now.Before(exp) {
+		return false
+	}
+	s.nonces[key] = expires
+	heap.Push(&s.expires, expiry{key: key, at: expires})
+	return true
+}
+
+// Verifier checks proofs sent to a builder.
+type Verifier struct {
+	builder common.Address
+	window  time.Duration
+	nonces  NonceStore
+}
+
+// NewVerifier creates a Verifier for proofs sent to builder. A zero window
+// means DefaultWindow and a nil store an in-process MemoryNonceStore.
+func NewVerifier(builder common.Address, window time.Duration, nonces NonceStore) *Verifier {
+	if window == 0 {
+		window = DefaultWindow
+	}
+	if nonces == nil {
+		nonces = new(MemoryNonceStore)
+	}
+	return &Verifier{builder: builder, window: window, nonces: nonces}
+}
+
+// Verify checks the proof attached to r, whose body has been read into body,
+// and returns the proven account and its commitment for the builder.
+func (v *Verifier) Verify(r *http.Request, body []byte) (common.Address, common.Hash, error) {
+	p, err := FromHeaders(r.Header)
+	if err != nil {
+		return common.Address{}, common.Hash{}, err
+	}
+	signedAt := time.Unix(p.Timestamp, 0)
+	if d := time.Since(signedAt); d > v.window || d < -v.window {
+		return common.Address{}, common.Hash{}, ErrExpired
+	}
+	account, err := p.Recover(v.builder, body)
+	if err != nil {
+		return common.Address{}, common.Hash{}, err
+	}
+	// Nonces are checked last so invalid proofs cannot burn them.
+	if !v.nonces.Use(account, p.Nonce, signedAt.Add(v.window)) {
+		return common.Address{}, common.Hash{}, ErrReplayed
+	}
+	return account, Commitment(account, v.builder), nil
+}
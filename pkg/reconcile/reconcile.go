@@ -0,0 +1,245 @@
+// Package reconcile checks historical deposits against the 80/20 split the
+// contract is supposed to apply: the builder's new time lock must hold the
+// builder share and the owner must have received the rest.
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/primevprotocol/primev-contracts/pkg/events"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+	"github.com/primevprotocol/primev-contracts/pkg/stakemath"
+)
+
+// Deposit is the reconciliation result for a single StakeUpdated event.
+type Deposit struct {
+	Block      uint64
+	TxHash     common.Hash
+	LogIndex   uint
+	Builder    common.Address
+	Commitment common.Hash
+
+	Value         *big.Int // msg.value of the traced deposit call
+	BuilderAmount *big.Int // Builder share the contract should lock
+	OwnerAmount   *big.Int // Remainder the contract should forward to the owner
+	Dust          *big.Rat // Exact 80% of Value minus BuilderAmount, kept by the owner
+
+	Locked        *big.Int       // initialAmount of the created time lock, nil if not verifiable
+	Owner         common.Address // Recipient of the traced owner transfer
+	OwnerReceived *big.Int       // Value of the traced owner transfer
+
+	Issues []string
+}
+
+// OK reports whether the deposit reconciled without issues.
+func (d *Deposit) OK() bool {
+	return len(d.Issues) == 0
+}
+
+func (d *Deposit) issue(format string, args ...interface{}) {
+	d.Issues = append(d.Issues, fmt.Sprintf(format, args...))
+}
+
+// Reconciler reconciles deposits of a BuilderStaking contract. Reading time
+// locks at historical blocks requires an archive node.
+type Reconciler struct {
+	address  common.Address
+	caller   *primev.BuilderStakingCaller
+	filterer *primev.BuilderStakingFilterer
+	tracer   Tracer
+	abi      *abi.ABI
+}
+
+// New creates a Reconciler for the BuilderStaking contract at address.
+func New(address common.Address, backend bind.ContractBackend, tracer Tracer) (*Reconciler, error) {
+	contract, err := primev.NewBuilderStaking(address, backend)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := primev.BuilderStakingMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return &Reconciler{
+		address:  address,
+		caller:   &contract.BuilderStakingCaller,
+		filterer: &contract.BuilderStakingFilterer,
+		tracer:   tracer,
+		abi:      parsed,
+	}, nil
+}
+
+type builderBlock struct {
+	builder common.Address
+	block   uint64
+}
+
+// Run reconciles every deposit in rng.
+func (r *Reconciler) Run(ctx context.Context, rng events.Range) ([]*Deposit, error) {
+	var (
+		stakes    []*primev.BuilderStakingStakeUpdated
+		withdrawn = make(map[builderBlock]bool)
+	)
+	err := events.StakeUpdated(ctx, r.filterer, rng, func(e *primev.BuilderStakingStakeUpdated) error {
+		stakes = append(stakes, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = events.Withdrawal(ctx, r.filterer, rng, func(e *primev.BuilderStakingWithdrawal) error {
+		withdrawn[builderBlock{e.Builder, e.Raw.BlockNumber}] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		results []*Deposit
+		frames  []*Frame
+		used    []bool
+		lastTx  common.Hash
+	)
+	for i, e := range stakes {
+		if e.Raw.TxHash != lastTx {
+			trace, err := r.tracer.TraceTransaction(ctx, e.Raw.TxHash)
+			if err != nil {
+				return nil, fmt.Errorf("reconcile: trace %s: %w", e.Raw.TxHash, err)
+			}
+			frames = r.depositFrames(trace)
+			used = make([]bool, len(frames))
+			lastTx = e.Raw.TxHash
+		}
+		d := &Deposit{
+			Block:      e.Raw.BlockNumber,
+			TxHash:     e.Raw.TxHash,
+			LogIndex:   e.Raw.Index,
+			Builder:    e.Builder,
+			Commitment: e.Commitment,
+		}
+		results = append(results, d)
+
+		frame := r.matchFrame(frames, used, e)
+		if frame == nil {
+			d.issue("no deposit call found in trace")
+			continue
+		}
+		d.Value = new(big.Int)
+		if frame.Value != nil {
+			d.Value = frame.Value.ToInt()
+		}
+		d.BuilderAmount = stakemath.BuilderAmount(d.Value)
+		d.OwnerAmount = stakemath.OwnerAmount(d.Value)
+		exact := new(big.Rat).SetFrac(new(big.Int).Mul(d.Value, big.NewInt(stakemath.BuilderSharePercent)), big.NewInt(100))
+		d.Dust = exact.Sub(exact, new(big.Rat).SetInt(d.BuilderAmount))
+
+		r.checkOwner(ctx, d, frame)
+		if withdrawn[builderBlock{e.Builder, e.Raw.BlockNumber}] {
+			d.issue("builder withdrew in the same block, time lock not verifiable")
+			continue
+		}
+		later := 0
+		for _, o := range stakes[i+1:] {
+			if o.Raw.BlockNumber != e.Raw.BlockNumber {
+				break
+			}
+			if o.Builder == e.Builder {
+				later++
+			}
+		}
+		r.checkLock(ctx, d, later)
+	}
+	return results, nil
+}
+
+// depositFrames returns all successful deposit calls into the contract.
+func (r *Reconciler) depositFrames(trace *Frame) []*Frame {
+	selector := r.abi.Methods["deposit"].ID
+	var frames []*Frame
+	walk(trace, func(f *Frame) {
+		if f.Type == "CALL" && f.To != nil && *f.To == r.address && len(f.Input) >= 4 && bytes.Equal(f.Input[:4], selector) {
+			frames = append(frames, f)
+		}
+	})
+	return frames
+}
+
+// matchFrame returns the first unused deposit frame for the event's builder
+// and commitment. Frames and events are both in execution order.
+func (r *Reconciler) matchFrame(frames []*Frame, used []bool, e *primev.BuilderStakingStakeUpdated) *Frame {
+	method := r.abi.Methods["deposit"]
+	for i, f := range frames {
+		if used[i] {
+			continue
+		}
+		args, err := method.Inputs.Unpack(f.Input[4:])
+		if err != nil || len(args) != 2 {
+			continue
+		}
+		builder, _ := args[0].(common.Address)
+		commitment, _ := args[1].([32]byte)
+		if builder == e.Builder && commitment == e.Commitment {
+			used[i] = true
+			return f
+		}
+	}
+	return nil
+}
+
+// checkOwner compares the value forwarded by the deposit call with the
+// expected owner share.
+func (r *Reconciler) checkOwner(ctx context.Context, d *Deposit, frame *Frame) {
+	d.OwnerReceived = new(big.Int)
+	for i := range frame.Calls {
+		c := &frame.Calls[i]
+		if c.Error == "" && c.From == r.address && c.To != nil && c.Value != nil && c.Value.ToInt().Sign() > 0 {
+			d.Owner = *c.To
+			d.OwnerReceived = c.Value.ToInt()
+		}
+	}
+	if d.OwnerReceived.Cmp(d.OwnerAmount) != 0 {
+		d.issue("owner received %v wei, expected %v", d.OwnerReceived, d.OwnerAmount)
+	}
+	owner, err := r.caller.Owner(&bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(d.Block)})
+	if err != nil {
+		d.issue("owner at block unavailable: %v", err)
+	} else if owner != d.Owner {
+		d.issue("owner transfer went to %s, owner at block end is %s", d.Owner, owner)
+	}
+}
+
+// checkLock reads the time lock created by the deposit. Deposits only append,
+// so with later deposits of the same builder in the block accounted for, the
+// lock sits at a known index at the end of the block.
+func (r *Reconciler) checkLock(ctx context.Context, d *Deposit, later int) {
+	opts := &bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(d.Block)}
+	count, err := r.caller.TimeLocksCount(opts, d.Builder)
+	if err != nil {
+		d.issue("time locks at block unavailable: %v", err)
+		return
+	}
+	index := new(big.Int).Sub(count, big.NewInt(int64(later+1)))
+	if index.Sign() < 0 {
+		d.issue("builder has %v time locks at block end, expected more", count)
+		return
+	}
+	lock, err := r.caller.TimeLocks(opts, d.Builder, index)
+	if err != nil {
+		d.issue("time lock %v unavailable: %v", index, err)
+		return
+	}
+	d.Locked = lock.InitialAmount
+	if d.Locked.Cmp(d.BuilderAmount) != 0 {
+		d.issue("time lock holds %v wei, expected %v", d.Locked, d.BuilderAmount)
+	}
+	if total := new(big.Int).Add(d.Locked, d.OwnerReceived); total.Cmp(d.Value) != 0 {
+		d.issue("locked plus owner transfer is %v wei, deposit was %v", total, d.Value)
+	}
+}
@@ -0,0 +1,234 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/primevprotocol/primev-contracts/pkg/events"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+var contractABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(primev.BuilderStakingMetaData.ABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+var (
+	contract = common.HexToAddress("0xc")
+	router   = common.HexToAddress("0x7")
+	owner    = common.HexToAddress("0x9")
+	other    = common.HexToAddress("0x1")
+	builderA = common.HexToAddress("0xa")
+	builderB = common.HexToAddress("0xb")
+)
+
+// lock is a time lock of amount the builder received in block.
+type lock struct {
+	block  uint64
+	amount int64
+}
+
+// chain serves contract logs, owner and time lock reads at past blocks, and
+// call traces. Methods the reconciler does not use are left to the nil
+// embedded ContractBackend.
+type chain struct {
+	bind.ContractBackend
+	logs   []types.Log
+	locks  map[common.Address][]lock
+	traces map[common.Hash]*Frame
+}
+
+func newChain() *chain {
+	return &chain{locks: make(map[common.Address][]lock), traces: make(map[common.Hash]*Frame)}
+}
+
+func (c *chain) emit(block uint64, tx common.Hash, name string, args ...interface{}) {
+	ev := contractABI.Events[name]
+	data, err := ev.Inputs.Pack(args...)
+	if err != nil {
+		panic(err)
+	}
+	c.logs = append(c.logs, types.Log{
+		Address:     contract,
+		Topics:      []common.Hash{ev.ID},
+		Data:        data,
+		BlockNumber: block,
+		TxHash:      tx,
+		Index:       uint(len(c.logs)),
+	})
+}
+
+// deposit records a deposit of value in tx through the router, locking locked
+// for the builder and paying paid to recipient. A reverted deposit only
+// leaves its failed frame in the trace.
+func (c *chain) deposit(block uint64, tx common.Hash, builder common.Address, commitment common.Hash, value, locked, paid int64, recipient common.Address, reverted bool) {
+	input, err := contractABI.Pack("deposit", builder, commitment)
+	if err != nil {
+		panic(err)
+	}
+	frame := Frame{Type: "CALL", From: router, To: &contract, Value: (*hexutil.Big)(big.NewInt(value)), Input: input}
+	if paid > 0 {
+		frame.Calls = []Frame{{Type: "CALL", From: contract, To: &recipient, Value: (*hexutil.Big)(big.NewInt(paid))}}
+	}
+	if reverted {
+		frame.Error = "execution reverted"
+	}
+	root, ok := c.traces[tx]
+	if !ok {
+		root = &Frame{Type: "CALL", From: common.HexToAddress("0xe"), To: &router}
+		c.traces[tx] = root
+	}
+	root.Calls = append(root.Calls, frame)
+	if reverted {
+		return
+	}
+	c.locks[builder] = append(c.locks[builder], lock{block, locked})
+	c.emit(block, tx, "StakeUpdated", builder, commitment, big.NewInt(value), big.NewInt(int64(block)+100))
+}
+
+func (c *chain) CallContract(ctx context.Context, call ethereum.CallMsg, number *big.Int) ([]byte, error) {
+	method, err := contractABI.MethodById(call.Data[:4])
+	if err != nil {
+		return nil, err
+	}
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	var locks []lock
+	if len(args) > 0 {
+		for _, l := range c.locks[args[0].(common.Address)] {
+			if l.block <= number.Uint64() {
+				locks = append(locks, l)
+			}
+		}
+	}
+	switch method.Name {
+	case "owner":
+		return method.Outputs.Pack(owner)
+	case "timeLocksCount":
+		return method.Outputs.Pack(big.NewInt(int64(len(locks))))
+	case "timeLocks":
+		l := locks[args[1].(*big.Int).Int64()]
+		return method.Outputs.Pack(big.NewInt(l.amount), big.NewInt(l.amount), new(big.Int), big.NewInt(10))
+	}
+	return nil, fmt.Errorf("unexpected call to %s", method.Name)
+}
+
+func (c *chain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	var logs []types.Log
+	for _, l := range c.logs {
+		if l.Topics[0] != q.Topics[0][0] || l.BlockNumber < q.FromBlock.Uint64() || l.BlockNumber > q.ToBlock.Uint64() {
+			continue
+		}
+		logs = append(logs, l)
+	}
+	return logs, nil
+}
+
+func (c *chain) TraceTransaction(ctx context.Context, hash common.Hash) (*Frame, error) {
+	f, ok := c.traces[hash]
+	if !ok {
+		return nil, fmt.Errorf("transaction %s not found", hash)
+	}
+	return f, nil
+}
+
+func TestRun(t *testing.T) {
+	c := newChain()
+	tx := func(i int64) common.Hash { return common.BigToHash(big.NewInt(i)) }
+	first, second := common.HexToHash("0x01"), common.HexToHash("0x02")
+
+	// Two deposits for A in block 10; the first lock is one below the end.
+	c.deposit(10, tx(1), builderA, first, 1001, 800, 201, owner, false)
+	c.deposit(10, tx(2), builderA, second, 500, 400, 100, owner, false)
+	// Short changed owner in block 11.
+	c.deposit(11, tx(3), builderB, first, 100, 80, 10, owner, false)
+	// Owner share sent elsewhere in block 12.
+	c.deposit(12, tx(4), builderB, second, 100, 80, 20, other, false)
+	// A withdrew in block 13 after depositing.
+	c.deposit(13, tx(5), builderA, first, 100, 80, 20, owner, false)
+	c.emit(13, tx(6), "Withdrawal", builderA, big.NewInt(80))
+	// A batch in block 14 whose first deposit reverted; the second one for
+	// the same commitment must match the event.
+	c.deposit(14, tx(7), builderB, first, 1000, 0, 0, owner, true)
+	c.deposit(14, tx(7), builderB, first, 50, 40, 10, owner, false)
+	// The lock holds too little in block 15.
+	c.deposit(15, tx(8), builderA, second, 10, 7, 2, owner, false)
+
+	r, err := New(contract, c, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deposits, err := r.Run(context.Background(), events.Range{From: 0, To: 20, Step: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		value          int64
+		builderAmount  int64
+		ownerAmount    int64
+		locked         int64 // -1 if not verifiable
+		issues         []string
+		dustNum, dustD int64
+	}{
+		{"odd value", 1001, 800, 201, 800, nil, 4, 5},
+		{"second in block", 500, 400, 100, 400, nil, 0, 1},
+		{"owner short changed", 100, 80, 20, 80, []string{"owner received 10 wei, expected 20", "locked plus owner transfer is 90 wei, deposit was 100"}, 0, 1},
+		{"wrong recipient", 100, 80, 20, 80, []string{"owner transfer went to " + other.Hex() + ", owner at block end is " + owner.Hex()}, 0, 1},
+		{"withdrawn in block", 100, 80, 20, -1, []string{"builder withdrew in the same block, time lock not verifiable"}, 0, 1},
+		{"reverted frame skipped", 50, 40, 10, 40, nil, 0, 1},
+		{"short lock", 10, 8, 2, 7, []string{"time lock holds 7 wei, expected 8", "locked plus owner transfer is 9 wei, deposit was 10"}, 0, 1},
+	}
+	if len(deposits) != len(tests) {
+		t.Fatalf("%d deposits, want %d", len(deposits), len(tests))
+	}
+	for i, tt := range tests {
+		d := deposits[i]
+		if d.Value.Int64() != tt.value || d.BuilderAmount.Int64() != tt.builderAmount || d.OwnerAmount.Int64() != tt.ownerAmount {
+			t.Errorf("%s: value %v, builder %v, owner %v; want %d, %d, %d", tt.name, d.Value, d.BuilderAmount, d.OwnerAmount, tt.value, tt.builderAmount, tt.ownerAmount)
+		}
+		if dust := big.NewRat(tt.dustNum, tt.dustD); d.Dust.Cmp(dust) != 0 {
+			t.Errorf("%s: dust %v, want %v", tt.name, d.Dust, dust)
+		}
+		if tt.locked < 0 && d.Locked != nil || tt.locked >= 0 && (d.Locked == nil || d.Locked.Int64() != tt.locked) {
+			t.Errorf("%s: locked %v, want %d", tt.name, d.Locked, tt.locked)
+		}
+		if strings.Join(d.Issues, "; ") != strings.Join(tt.issues, "; ") || d.OK() != (len(tt.issues) == 0) {
+			t.Errorf("%s: issues %q, want %q", tt.name, d.Issues, tt.issues)
+		}
+	}
+}
+
+func TestRunMissingFrame(t *testing.T) {
+	c := newChain()
+	c.deposit(10, common.HexToHash("0x01"), builderA, common.HexToHash("0x01"), 100, 80, 20, owner, false)
+	// The trace does not contain the deposit, e.g. because it was made by a
+	// different contract address.
+	c.traces[common.HexToHash("0x01")].Calls[0].To = &other
+	r, err := New(contract, c, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deposits, err := r.Run(context.Background(), events.Range{From: 0, To: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deposits) != 1 || deposits[0].OK() || deposits[0].Issues[0] != "no deposit call found in trace" || deposits[0].Value != nil {
+		t.Errorf("deposits %+v", deposits)
+	}
+}
@@ -0,0 +1,53 @@
+package reconcile
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Frame is a call frame as produced by the callTracer.
+type Frame struct {
+	Type    string          `json:"type"`
+	From    common.Address  `json:"from"`
+	To      *common.Address `json:"to,omitempty"`
+	Value   *hexutil.Big    `json:"value,omitempty"`
+	Input   hexutil.Bytes   `json:"input"`
+	Error   string          `json:"error,omitempty"`
+	Calls   []Frame         `json:"calls,omitempty"`
+	GasUsed hexutil.Uint64  `json:"gasUsed"`
+}
+
+// Tracer returns the call trace of a transaction.
+type Tracer interface {
+	TraceTransaction(ctx context.Context, hash common.Hash) (*Frame, error)
+}
+
+// RPCTracer traces transactions with debug_traceTransaction.
+type RPCTracer struct {
+	Client *rpc.Client
+}
+
+// TraceTransaction implements Tracer.
+func (t *RPCTracer) TraceTransaction(ctx context.Context, hash common.Hash) (*Frame, error) {
+	var frame Frame
+	err := t.Client.CallContext(ctx, &frame, "debug_traceTransaction", hash, map[string]interface{}{"tracer": "callTracer"})
+	if err != nil {
+		return nil, err
+	}
+	return &frame, nil
+}
+
+// walk visits frames depth first in execution order, skipping failed
+// frames and everything below them since their effects were reverted.
+func walk(f *Frame, fn func(*Frame)) {
+	if f.Error != "" {
+		return
+	}
+	fn(f)
+	for i := range f.Calls {
+		walk(&f.Calls[i], fn)
+	}
+}
@@ -0,0 +1,415 @@
+// Package renewal keeps searcher subscriptions alive by depositing just
+// enough to extend them to a target horizon whenever they get close to
+// expiring.
+package renewal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/primevprotocol/primev-contracts/pkg/deposit"
+	"github.com/primevprotocol/primev-contracts/pkg/fees"
+	"github.com/primevprotocol/primev-contracts/pkg/heads"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+	"github.com/primevprotocol/primev-contracts/pkg/stakemath"
+	"github.com/primevprotocol/primev-contracts/pkg/terms"
+)
+
+// DefaultPendingTimeout is the number of blocks after which an unmined
+// renewal is replaced with higher fees.
+const DefaultPendingTimeout = 50
+
+// Subscription identifies a commitment staked to a builder.
+type Subscription struct {
+	Builder    common.Address `json:"builder"`
+	Commitment common.Hash    `json:"commitment"`
+}
+
+// Config configures a Renewer.
+type Config struct {
+	Subscriptions  []Subscription
+	Threshold      uint64        // Renew once at most this many subscription blocks are left
+	Horizon        uint64        // Blocks past the current head a renewal extends to
+	Budget         *big.Int      // Total wei of mined and pending renewals, across restarts
+	StatePath      string        // File the state is persisted to, in memory only if empty
+	PendingTimeout uint64        // Blocks after which an unmined renewal is replaced with higher fees
+	PollInterval   time.Duration // Head polling interval when subscriptions are unsupported
+}
+
+// Renewer watches new heads and renews subscriptions close to expiry.
+type Renewer struct {
+	cfg        Config
+	address    common.Address
+	backend    deposit.Backend
+	caller     *primev.BuilderStakingCaller
+	transactor *fees.Transactor
+	opts       *bind.TransactOpts
+	state      *State
+}
+
+// New creates a Renewer sending deposits with opts through transactor and
+// restores its state from cfg.StatePath.
+func New(address common.Address, backend deposit.Backend, transactor *fees.Transactor, opts *bind.TransactOpts, cfg Config) (*Renewer, error) {
+	if cfg.Horizon <= cfg.Threshold {
+		return nil, errors.New("renewal: horizon must exceed threshold")
+	}
+	if cfg.Budget == nil {
+		return nil, errors.New("renewal: no budget")
+	}
+	if cfg.PendingTimeout == 0 {
+		cfg.PendingTimeout = DefaultPendingTimeout
+	}
+	caller, err := primev.NewBuilderStakingCaller(address, backend)
+	if err != nil {
+		return nil, err
+	}
+	state, err := loadState(cfg.StatePath)
+	if err != nil {
+		return nil, err
+	}
+	return &Renewer{
+		cfg:        cfg,
+		address:    address,
+		backend:    backend,
+		caller:     caller,
+		transactor: transactor,
+		opts:       opts,
+		state:      state,
+	}, nil
+}
+
+// State returns the current renewer state. It must not be modified.
+func (r *Renewer) State() *State {
+	return r.state
+}
+
+// Run processes new heads until ctx is done or the state cannot be
+// persisted.
+func (r *Renewer) Run(ctx context.Context) error {
+	return heads.Watch(ctx, r.backend, r.cfg.PollInterval, func(head *types.Header) error {
+		return r.onHead(ctx, head)
+	})
+}
+
+// onHead resolves pending renewals and renews subscriptions close to expiry.
+// A renewal is persisted before it is broadcast, so a restart never forgets a
+// transaction that may be mined; failing to persist is fatal.
+func (r *Renewer) onHead(ctx context.Context, head *types.Header) error {
+	if err := r.resolvePending(ctx, head); err != nil {
+		return err
+	}
+	for _, sub := range r.cfg.Subscriptions {
+		if r.pending(sub) {
+			continue
+		}
+		p, tx, err := r.check(ctx, head, sub)
+		if err != nil {
+			log.Warn("Renewal failed", "builder", sub.Builder, "commitment", sub.Commitment, "err", err)
+			continue
+		}
+		if p == nil {
+			continue
+		}
+		r.state.Pending = append(r.state.Pending, p)
+		if err := r.save(); err != nil {
+			return err
+		}
+		if r.send(ctx, p, tx) != nil {
+			// Later renewals would be signed at the same unused nonce.
+			break
+		}
+		log.Info("Sent renewal", "builder", sub.Builder, "commitment", sub.Commitment,
+			"tx", tx.Hash(), "nonce", p.Nonce, "amount", p.Amount, "target", p.TargetEnd)
+	}
+	return r.save()
+}
+
+func (r *Renewer) save() error {
+	if err := r.state.save(r.cfg.StatePath); err != nil {
+		return fmt.Errorf("renewal: persist state: %w", err)
+	}
+	return nil
+}
+
+// send broadcasts a persisted renewal. A failed send is only logged: the
+// renewal stays pending and is replaced at its nonce once it times out.
+// Callers must not sign further transactions for the head, as they would
+// reuse the nonce.
+func (r *Renewer) send(ctx context.Context, p *Renewal, tx *types.Transaction) error {
+	err := r.backend.SendTransaction(ctx, tx)
+	if err != nil {
+		log.Warn("Failed to send renewal", "builder", p.Builder, "commitment", p.Commitment, "tx", tx.Hash(), "nonce", p.Nonce, "err", err)
+	}
+	return err
+}
+
+// committed returns what mined renewals spent plus the amounts of pending
+// ones, which the budget limits.
+func (r *Renewer) committed() *big.Int {
+	committed := new(big.Int).Set(r.state.Spent.ToInt())
+	for _, p := range r.state.Pending {
+		committed.Add(committed, p.Amount.ToInt())
+	}
+	return committed
+}
+
+func (r *Renewer) pending(sub Subscription) bool {
+	for _, p := range r.state.Pending {
+		if p.Builder == sub.Builder && p.Commitment == sub.Commitment {
+			return true
+		}
+	}
+	return false
+}
+
+// check signs a renewal of sub if its subscription ends within the threshold.
+// It returns a nil renewal if none is due.
+func (r *Renewer) check(ctx context.Context, head *types.Header, sub Subscription) (*Renewal, *types.Transaction, error) {
+	at := &bind.CallOpts{Context: ctx, BlockNumber: head.Number}
+	stake, err := r.caller.Stakes(at, sub.Commitment)
+	if err != nil {
+		return nil, nil, err
+	}
+	remaining := new(big.Int).Sub(stake.SubscriptionEnd, head.Number)
+	if remaining.Sign() < 0 {
+		remaining.SetUint64(0)
+	}
+	if remaining.Cmp(new(big.Int).SetUint64(r.cfg.Threshold)) > 0 {
+		return nil, nil, nil
+	}
+	info, err := r.caller.Builders(at, sub.Builder)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The deposit lands in the next block at the earliest and extends from the
+	// current end if the subscription is still running by then.
+	target := new(big.Int).Add(head.Number, new(big.Int).SetUint64(r.cfg.Horizon))
+	base := new(big.Int).Add(head.Number, common.Big1)
+	if stake.SubscriptionEnd.Cmp(base) > 0 {
+		base.Set(stake.SubscriptionEnd)
+	}
+	if target.Cmp(base) <= 0 {
+		return nil, nil, nil
+	}
+	amount := stakemath.StakeForPeriod(info.MinimalStake, info.MinimalSubscriptionPeriod, new(big.Int).Sub(target, base))
+	if info.MinimalStake.Sign() == 0 || info.MinimalSubscriptionPeriod.Sign() == 0 || amount == nil {
+		return nil, nil, errors.New("renewal: builder terms do not allow deposits")
+	}
+	committed := r.committed()
+	if new(big.Int).Add(committed, amount).Cmp(r.cfg.Budget) > 0 {
+		log.Warn("Renewal budget exhausted", "builder", sub.Builder, "commitment", sub.Commitment,
+			"amount", amount, "spent", r.state.Spent, "committed", committed, "budget", r.cfg.Budget)
+		return nil, nil, nil
+	}
+
+	pinned := terms.Terms{MinimalStake: info.MinimalStake, MinimalSubscriptionPeriod: info.MinimalSubscriptionPeriod}
+	sender := &renewSender{transactor: r.transactor, remaining: remaining.Uint64()}
+	guard, err := deposit.NewGuard(r.address, r.backend, sender)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts := *r.opts
+	opts.Context = ctx
+	opts.Value = amount
+	opts.NoSend = true
+	tx, err := guard.Deposit(&opts, sub.Builder, sub.Commitment, &deposit.Expectation{Terms: pinned, MinSubscriptionEnd: target})
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &Renewal{
+		Builder:                   sub.Builder,
+		Commitment:                sub.Commitment,
+		Amount:                    (*hexutil.Big)(amount),
+		SentAt:                    head.Number.Uint64(),
+		MinimalStake:              (*hexutil.Big)(pinned.MinimalStake),
+		MinimalSubscriptionPeriod: (*hexutil.Big)(pinned.MinimalSubscriptionPeriod),
+		TargetEnd:                 (*hexutil.Big)(target),
+	}
+	p.record(tx)
+	return p, tx, nil
+}
+
+// record makes tx the latest transaction of p.
+func (p *Renewal) record(tx *types.Transaction) {
+	if p.TxHash != (common.Hash{}) {
+		p.Replaced = append(p.Replaced, p.TxHash)
+	}
+	p.TxHash = tx.Hash()
+	p.Nonce = tx.Nonce()
+	p.GasLimit = tx.Gas()
+	p.TipCap = (*hexutil.Big)(tx.GasTipCap())
+	p.FeeCap = (*hexutil.Big)(tx.GasFeeCap())
+}
+
+// resolvePending records the outcome of mined renewals and replaces renewals
+// that stay unmined past the timeout with a transaction at the same nonce and
+// higher fees, so a renewal is never paid twice.
+func (r *Renewer) resolvePending(ctx context.Context, head *types.Header) error {
+	var still, stuck []*Renewal
+	for _, p := range r.state.Pending {
+		receipt, err := r.receipt(ctx, p)
+		switch {
+		case errors.Is(err, ethereum.NotFound):
+			if head.Number.Uint64() > p.SentAt+r.cfg.PendingTimeout {
+				stuck = append(stuck, p)
+			}
+			still = append(still, p)
+		case err != nil:
+			log.Warn("Failed to fetch renewal receipt", "tx", p.TxHash, "err", err)
+			still = append(still, p)
+		default:
+			r.verify(ctx, p, receipt)
+			if receipt.Status == types.ReceiptStatusSuccessful {
+				r.state.Spent = (*hexutil.Big)(new(big.Int).Add(r.state.Spent.ToInt(), p.Amount.ToInt()))
+			}
+			r.finish(p)
+		}
+	}
+	r.state.Pending = still
+
+	for _, p := range stuck {
+		if p.FeeCap == nil {
+			// Renewals persisted before nonces were recorded cannot be
+			// replaced safely.
+			p.Error = "not mined within timeout"
+			r.drop(p)
+			continue
+		}
+		tx, err := r.replace(ctx, p)
+		if err != nil {
+			log.Warn("Failed to replace stuck renewal", "builder", p.Builder, "commitment", p.Commitment, "tx", p.TxHash, "err", err)
+			continue
+		}
+		p.record(tx)
+		p.SentAt = head.Number.Uint64()
+		if err := r.save(); err != nil {
+			return err
+		}
+		switch err := r.send(ctx, p, tx); {
+		case err != nil && strings.Contains(err.Error(), core.ErrNonceTooLow.Error()):
+			// None of the renewal's transactions was mined, yet its nonce
+			// is used up by another transaction.
+			p.Error = fmt.Sprintf("nonce %d used by another transaction", p.Nonce)
+			r.drop(p)
+		case err == nil:
+			log.Info("Replaced stuck renewal", "builder", p.Builder, "commitment", p.Commitment,
+				"tx", p.TxHash, "nonce", p.Nonce, "tipCap", p.TipCap, "feeCap", p.FeeCap)
+		}
+	}
+	return nil
+}
+
+// receipt returns the receipt of whichever transaction of p was mined.
+func (r *Renewer) receipt(ctx context.Context, p *Renewal) (*types.Receipt, error) {
+	for _, hash := range append([]common.Hash{p.TxHash}, p.Replaced...) {
+		receipt, err := r.backend.TransactionReceipt(ctx, hash)
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.TxHash = hash
+		return receipt, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+// replace signs the deposit of p again at its nonce with fees raised by at
+// least feeBump percent, or to the current suggestion if that is higher.
+func (r *Renewer) replace(ctx context.Context, p *Renewal) (*types.Transaction, error) {
+	tip, feeCap := bump(p.TipCap.ToInt()), bump(p.FeeCap.ToInt())
+	if current, err := r.transactor.Fees(ctx); err == nil {
+		if current.TipCap.Cmp(tip) > 0 {
+			tip = current.TipCap
+		}
+		if current.FeeCap.Cmp(feeCap) > 0 {
+			feeCap = current.FeeCap
+		}
+	}
+	opts := *r.opts
+	opts.Context = ctx
+	opts.Value = p.Amount.ToInt()
+	opts.Nonce = new(big.Int).SetUint64(p.Nonce)
+	opts.GasLimit = p.GasLimit
+	opts.GasTipCap = tip
+	opts.GasFeeCap = feeCap
+	opts.NoSend = true
+	return r.transactor.Deposit(&opts, p.Builder, p.Commitment)
+}
+
+// feeBump is the minimum fee increase in percent nodes accept for replacing
+// a pending transaction.
+const feeBump = 10
+
+func bump(v *big.Int) *big.Int {
+	b := new(big.Int).Mul(v, big.NewInt(100+feeBump))
+	b.Div(b, big.NewInt(100))
+	return b.Add(b, common.Big1)
+}
+
+// drop finishes a pending renewal that will not be mined, which releases its
+// amount from the budget.
+func (r *Renewer) drop(p *Renewal) {
+	for i, q := range r.state.Pending {
+		if q == p {
+			r.state.Pending = append(r.state.Pending[:i], r.state.Pending[i+1:]...)
+			break
+		}
+	}
+	r.finish(p)
+}
+
+func (r *Renewer) verify(ctx context.Context, p *Renewal, receipt *types.Receipt) {
+	p.MinedAt = receipt.BlockNumber.Uint64()
+	guard, err := deposit.NewGuard(r.address, r.backend, nil)
+	if err != nil {
+		p.Error = err.Error()
+		return
+	}
+	exp := &deposit.Expectation{
+		Terms:              terms.Terms{MinimalStake: p.MinimalStake.ToInt(), MinimalSubscriptionPeriod: p.MinimalSubscriptionPeriod.ToInt()},
+		MinSubscriptionEnd: p.TargetEnd.ToInt(),
+	}
+	event, err := guard.VerifyReceipt(ctx, receipt, p.Amount.ToInt(), p.Builder, p.Commitment, exp)
+	if event != nil {
+		p.Stake = (*hexutil.Big)(event.Stake)
+		p.SubscriptionEnd = (*hexutil.Big)(event.SubscriptionEnd)
+	}
+	if err != nil {
+		p.Error = err.Error()
+	}
+}
+
+func (r *Renewer) finish(p *Renewal) {
+	r.state.Finished = append(r.state.Finished, p)
+	if p.Error != "" {
+		log.Warn("Renewal finished with error", "builder", p.Builder, "commitment", p.Commitment, "tx", p.TxHash, "err", p.Error)
+		return
+	}
+	log.Info("Renewal mined", "builder", p.Builder, "commitment", p.Commitment, "tx", p.TxHash,
+		"stake", p.Stake, "subscriptionEnd", p.SubscriptionEnd)
+}
+
+// renewSender routes deposits through fees.Transactor.Renew so urgent fees
+// apply to subscriptions about to expire.
+type renewSender struct {
+	transactor *fees.Transactor
+	remaining  uint64
+}
+
+func (s *renewSender) Deposit(opts *bind.TransactOpts, builder common.Address, commitment [32]byte) (*types.Transaction, error) {
+	return s.transactor.Renew(opts, builder, commitment, s.remaining)
+}
@@ -0,0 +1,229 @@
+package renewal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/primevprotocol/primev-contracts/pkg/deposit"
+	"github.com/primevprotocol/primev-contracts/pkg/fees"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+var contractABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(primev.BuilderStakingMetaData.ABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+var builder = common.HexToAddress("0xb1")
+
+// chain is a builder with a minimal stake of 10 wei per 100 blocks whose
+// subscriptions all end at block 105. Methods the renewer does not use are
+// left to the nil embedded Backend.
+type chain struct {
+	deposit.Backend
+	nonce    uint64
+	sent     []*types.Transaction
+	sendErr  error
+	receipts map[common.Hash]*types.Receipt
+}
+
+func (c *chain) call(call ethereum.CallMsg) ([]byte, error) {
+	method, err := contractABI.MethodById(call.Data[:4])
+	if err != nil {
+		return nil, err
+	}
+	switch method.Name {
+	case "builders":
+		return method.Outputs.Pack(big.NewInt(10), big.NewInt(100))
+	case "stakes":
+		return method.Outputs.Pack(big.NewInt(105), big.NewInt(10))
+	case "deposit":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unexpected call to %s", method.Name)
+}
+
+func (c *chain) CallContract(ctx context.Context, call ethereum.CallMsg, number *big.Int) ([]byte, error) {
+	return c.call(call)
+}
+
+func (c *chain) PendingCallContract(ctx context.Context, call ethereum.CallMsg) ([]byte, error) {
+	return c.call(call)
+}
+
+func (c *chain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(100), BaseFee: big.NewInt(1)}, nil
+}
+
+func (c *chain) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return c.nonce, nil
+}
+
+func (c *chain) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	return 90000, nil
+}
+
+func (c *chain) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if c.sendErr != nil {
+		return c.sendErr
+	}
+	c.sent = append(c.sent, tx)
+	c.nonce++
+	return nil
+}
+
+func (c *chain) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	if receipt, ok := c.receipts[hash]; ok {
+		return receipt, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (c *chain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	return nil, nil
+}
+
+// mine makes tx mined at block with the given status.
+func (c *chain) mine(tx common.Hash, block int64, status uint64) {
+	c.receipts[tx] = &types.Receipt{TxHash: tx, Status: status, BlockNumber: big.NewInt(block)}
+}
+
+func newRenewer(t *testing.T, c *chain, budget int64, subs int) *Renewer {
+	key, _ := crypto.GenerateKey()
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactor, err := fees.NewTransactor(common.Address{}, c, fees.Policy{Strategy: &fees.Fixed{TipCap: big.NewInt(1), FeeCap: big.NewInt(10)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := Config{Threshold: 10, Horizon: 100, Budget: big.NewInt(budget)}
+	for i := 0; i < subs; i++ {
+		cfg.Subscriptions = append(cfg.Subscriptions, Subscription{Builder: builder, Commitment: common.BigToHash(big.NewInt(int64(i + 1)))})
+	}
+	r, err := New(common.Address{}, c, transactor, opts, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func head(number int64) *types.Header {
+	return &types.Header{Number: big.NewInt(number), BaseFee: big.NewInt(1)}
+}
+
+func TestBudget(t *testing.T) {
+	c := &chain{receipts: make(map[common.Hash]*types.Receipt)}
+	// Every renewal costs 10 wei, so 25 wei cover two at a time.
+	r := newRenewer(t, c, 25, 3)
+	ctx := context.Background()
+	if err := r.onHead(ctx, head(100)); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.state.Pending) != 2 || r.state.Spent.ToInt().Sign() != 0 || r.committed().Int64() != 20 {
+		t.Fatalf("after the first head: %d pending, spent %v, committed %v", len(r.state.Pending), r.state.Spent, r.committed())
+	}
+
+	// The first renewal is mined, the second reverts and costs nothing.
+	c.mine(r.state.Pending[0].TxHash, 101, types.ReceiptStatusSuccessful)
+	c.mine(r.state.Pending[1].TxHash, 101, types.ReceiptStatusFailed)
+	if err := r.onHead(ctx, head(102)); err != nil {
+		t.Fatal(err)
+	}
+	if r.state.Spent.ToInt().Int64() != 10 || len(r.state.Finished) != 2 {
+		t.Fatalf("after mining: spent %v, %d finished", r.state.Spent, len(r.state.Finished))
+	}
+	// With 10 wei spent only one more renewal fits.
+	if len(r.state.Pending) != 1 || r.committed().Int64() != 20 {
+		t.Fatalf("after the second head: %d pending, committed %v", len(r.state.Pending), r.committed())
+	}
+}
+
+func TestSendFailureStopsHead(t *testing.T) {
+	c := &chain{receipts: make(map[common.Hash]*types.Receipt), sendErr: errors.New("connection refused")}
+	r := newRenewer(t, c, 100, 3)
+	if err := r.onHead(context.Background(), head(100)); err != nil {
+		t.Fatal(err)
+	}
+	// Further renewals would have been signed at the same nonce.
+	if len(r.state.Pending) != 1 {
+		t.Fatalf("%d renewals pending after a failed send, want 1", len(r.state.Pending))
+	}
+}
+
+func TestReplaceStuckRenewal(t *testing.T) {
+	c := &chain{receipts: make(map[common.Hash]*types.Receipt), nonce: 7}
+	r := newRenewer(t, c, 100, 1)
+	ctx := context.Background()
+	if err := r.onHead(ctx, head(100)); err != nil {
+		t.Fatal(err)
+	}
+	p := r.state.Pending[0]
+	first := c.sent[0]
+
+	// Still pending within the timeout.
+	if err := r.onHead(ctx, head(150)); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.sent) != 1 {
+		t.Fatalf("renewal replaced before the timeout")
+	}
+	if err := r.onHead(ctx, head(151)); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.sent) != 2 {
+		t.Fatalf("stuck renewal not replaced")
+	}
+	replacement := c.sent[1]
+	if replacement.Nonce() != first.Nonce() || replacement.Gas() != first.Gas() || replacement.Value().Cmp(first.Value()) != 0 {
+		t.Errorf("replacement nonce %d gas %d value %v, want %d, %d, %v",
+			replacement.Nonce(), replacement.Gas(), replacement.Value(), first.Nonce(), first.Gas(), first.Value())
+	}
+	if replacement.GasTipCap().Cmp(bump(first.GasTipCap())) < 0 || replacement.GasFeeCap().Cmp(bump(first.GasFeeCap())) < 0 {
+		t.Errorf("replacement fees %v/%v not bumped from %v/%v", replacement.GasTipCap(), replacement.GasFeeCap(), first.GasTipCap(), first.GasFeeCap())
+	}
+	if p.TxHash != replacement.Hash() || len(p.Replaced) != 1 || p.Replaced[0] != first.Hash() || p.SentAt != 151 {
+		t.Errorf("renewal after replacement: %+v", p)
+	}
+
+	// The original transaction is mined after all and counted once.
+	c.mine(first.Hash(), 152, types.ReceiptStatusSuccessful)
+	if err := r.onHead(ctx, head(153)); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.state.Pending) != 0 || p.TxHash != first.Hash() || r.state.Spent.ToInt().Int64() != 10 {
+		t.Errorf("after mining: %d pending, tx %s, spent %v", len(r.state.Pending), p.TxHash, r.state.Spent)
+	}
+}
+
+func TestDropReleasesBudget(t *testing.T) {
+	c := &chain{receipts: make(map[common.Hash]*types.Receipt)}
+	r := newRenewer(t, c, 100, 1)
+	ctx := context.Background()
+	if err := r.onHead(ctx, head(100)); err != nil {
+		t.Fatal(err)
+	}
+	// Another transaction took the nonce, so the replacement is rejected.
+	c.sendErr = core.ErrNonceTooLow
+	if err := r.onHead(ctx, head(151)); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.state.Finished) != 1 || r.state.Finished[0].Error == "" || r.state.Spent.ToInt().Sign() != 0 {
+		t.Fatalf("finished %+v, spent %v", r.state.Finished, r.state.Spent)
+	}
+}
@@ -0,0 +1,98 @@
+package renewal
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Renewal records a single renewal deposit.
+type Renewal struct {
+	Builder    common.Address `json:"builder"`
+	Commitment common.Hash    `json:"commitment"`
+	TxHash     common.Hash    `json:"txHash"`
+	Amount     *hexutil.Big   `json:"amount"`
+	SentAt     uint64         `json:"sentAt"`
+
+	// Nonce, gas and fees of the latest transaction. A stuck renewal is
+	// replaced at the same nonce, so only one of TxHash and Replaced can be
+	// mined.
+	Nonce    uint64        `json:"nonce"`
+	GasLimit uint64        `json:"gasLimit"`
+	TipCap   *hexutil.Big  `json:"tipCap,omitempty"`
+	FeeCap   *hexutil.Big  `json:"feeCap,omitempty"`
+	Replaced []common.Hash `json:"replaced,omitempty"`
+
+	// Builder terms the deposit was priced with and the subscription end it
+	// should reach, kept so the outcome can be verified after a restart.
+	MinimalStake              *hexutil.Big `json:"minimalStake"`
+	MinimalSubscriptionPeriod *hexutil.Big `json:"minimalSubscriptionPeriod"`
+	TargetEnd                 *hexutil.Big `json:"targetEnd"`
+
+	// Outcome taken from the StakeUpdated event once mined.
+	MinedAt         uint64       `json:"minedAt,omitempty"`
+	Stake           *hexutil.Big `json:"stake,omitempty"`
+	SubscriptionEnd *hexutil.Big `json:"subscriptionEnd,omitempty"`
+	Error           string       `json:"error,omitempty"`
+}
+
+// State is persisted between restarts.
+type State struct {
+	Spent    *hexutil.Big `json:"spent"`    // Wei paid by renewals mined successfully
+	Pending  []*Renewal   `json:"pending"`  // Signed but not yet mined
+	Finished []*Renewal   `json:"finished"` // Mined or given up on
+}
+
+func loadState(path string) (*State, error) {
+	state := &State{Spent: new(hexutil.Big)}
+	if path == "" {
+		return state, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	if state.Spent == nil {
+		state.Spent = new(hexutil.Big)
+	}
+	return state, nil
+}
+
+// save writes the state atomically so a crash never leaves a torn file.
+func (s *State) save(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,70 @@
+package renewal
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	empty, err := loadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty.Spent.ToInt().Sign() != 0 || len(empty.Pending) != 0 {
+		t.Fatalf("missing file loaded as %+v", empty)
+	}
+
+	state := &State{
+		Spent: (*hexutil.Big)(big.NewInt(30)),
+		Pending: []*Renewal{{
+			Builder:                   common.HexToAddress("0xb1"),
+			Commitment:                common.HexToHash("0x01"),
+			TxHash:                    common.HexToHash("0xaa"),
+			Amount:                    (*hexutil.Big)(big.NewInt(10)),
+			SentAt:                    100,
+			Nonce:                     7,
+			GasLimit:                  90000,
+			TipCap:                    (*hexutil.Big)(big.NewInt(2)),
+			FeeCap:                    (*hexutil.Big)(big.NewInt(20)),
+			Replaced:                  []common.Hash{common.HexToHash("0xa9")},
+			MinimalStake:              (*hexutil.Big)(big.NewInt(10)),
+			MinimalSubscriptionPeriod: (*hexutil.Big)(big.NewInt(100)),
+			TargetEnd:                 (*hexutil.Big)(big.NewInt(200)),
+		}},
+		Finished: []*Renewal{{
+			TxHash:          common.HexToHash("0xbb"),
+			Amount:          (*hexutil.Big)(big.NewInt(30)),
+			MinedAt:         90,
+			Stake:           (*hexutil.Big)(big.NewInt(40)),
+			SubscriptionEnd: (*hexutil.Big)(big.NewInt(150)),
+		}},
+	}
+	if err := state.save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, state) {
+		t.Errorf("loaded %+v, want %+v", loaded, state)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("%d files left next to the state, want only the state", len(entries))
+	}
+
+	// Files without a spent amount start from zero.
+	if err := os.WriteFile(path, []byte(`{"pending":[]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err := loadState(path); err != nil || loaded.Spent.ToInt().Sign() != 0 {
+		t.Errorf("state without spent: %+v, %v", loaded, err)
+	}
+}
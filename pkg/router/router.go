@@ -0,0 +1,227 @@
+// Package router sends a searcher's bundles to every builder the searcher is
+// subscribed to, skipping builders where the searcher's commitment lacks the
+// minimal stake or its subscription has ended.
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/primevprotocol/primev-contracts/pkg/gate"
+	"github.com/primevprotocol/primev-contracts/pkg/proof"
+)
+
+// DefaultTimeout bounds a request to a single builder if Config.Timeout is
+// zero.
+const DefaultTimeout = 5 * time.Second
+
+// maxResponseSize limits the builder responses read by the router.
+const maxResponseSize = 1 << 20
+
+// Reason tells why a builder was skipped.
+type Reason string
+
+const (
+	InsufficientStake   Reason = "insufficient stake"
+	SubscriptionExpired Reason = "subscription expired"
+	CheckFailed         Reason = "stake check failed"
+)
+
+// Config configures a Router.
+type Config struct {
+	Builders map[common.Address]string // Bundle endpoint per builder address
+	Account  common.Address            // Account commitments are derived from, taken from Key if set
+	Key      *ecdsa.PrivateKey         // Signs commitment ownership proofs if set
+	TTL      time.Duration             // How long stake reads are cached, gate.DefaultTTL if zero
+	Timeout  time.Duration             // Per builder request timeout
+}
+
+// Delivery is the outcome of sending to an eligible builder.
+type Delivery struct {
+	Builder    common.Address
+	Endpoint   string
+	Access     *gate.Access
+	StatusCode int             // HTTP status, zero if the request failed
+	Response   json.RawMessage // Response body
+	Err        error           // Transport, HTTP or JSON-RPC error
+}
+
+// Skip is a builder the bundle was not sent to.
+type Skip struct {
+	Builder common.Address
+	Reason  Reason
+	Access  *gate.Access // Nil if the check failed
+	Err     error        // Set if the check failed
+}
+
+// Result reports a fan-out, ordered by builder address.
+type Result struct {
+	Delivered []Delivery
+	Skipped   []Skip
+}
+
+type target struct {
+	endpoint   string
+	commitment common.Hash
+	checker    *gate.ChainChecker
+	client     *http.Client
+}
+
+// Router fans requests out to the builders where the searcher is eligible.
+type Router struct {
+	cfg     Config
+	targets map[common.Address]*target
+}
+
+// New creates a Router checking subscriptions on the BuilderStaking contract
+// at address.
+func New(address common.Address, backend gate.Backend, cfg Config) (*Router, error) {
+	if len(cfg.Builders) == 0 {
+		return nil, errors.New("router: no builders")
+	}
+	if cfg.Key != nil {
+		cfg.Account = crypto.PubkeyToAddress(cfg.Key.PublicKey)
+	}
+	if cfg.Account == (common.Address{}) {
+		return nil, errors.New("router: no account or key")
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	r := &Router{cfg: cfg, targets: make(map[common.Address]*target, len(cfg.Builders))}
+	for builder, endpoint := range cfg.Builders {
+		checker, err := gate.NewChainChecker(address, backend, builder, cfg.TTL)
+		if err != nil {
+			return nil, err
+		}
+		client := &http.Client{Timeout: cfg.Timeout}
+		if cfg.Key != nil {
+			client.Transport = &proof.Transport{Key: cfg.Key, Builder: builder}
+		}
+		r.targets[builder] = &target{
+			endpoint:   endpoint,
+			commitment: proof.Commitment(cfg.Account, builder),
+			checker:    checker,
+			client:     client,
+		}
+	}
+	return r, nil
+}
+
+// Commitment returns the searcher's commitment for builder.
+func (r *Router) Commitment(builder common.Address) common.Hash {
+	return proof.Commitment(r.cfg.Account, builder)
+}
+
+// Call sends a JSON-RPC request for method with params, such as
+// eth_sendBundle and its bundle, to all eligible builders.
+func (r *Router) Call(ctx context.Context, method string, params ...interface{}) (*Result, error) {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.Send(ctx, body), nil
+}
+
+// Send checks every builder and posts body to the eligible ones, all
+// concurrently.
+func (r *Router) Send(ctx context.Context, body []byte) *Result {
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		res = new(Result)
+	)
+	for builder, t := range r.targets {
+		wg.Add(1)
+		go func(builder common.Address, t *target) {
+			defer wg.Done()
+			skip, delivery := r.send(ctx, builder, t, body)
+			mu.Lock()
+			defer mu.Unlock()
+			if skip != nil {
+				res.Skipped = append(res.Skipped, *skip)
+			} else {
+				res.Delivered = append(res.Delivered, *delivery)
+			}
+		}(builder, t)
+	}
+	wg.Wait()
+	sort.Slice(res.Delivered, func(i, j int) bool {
+		return bytes.Compare(res.Delivered[i].Builder[:], res.Delivered[j].Builder[:]) < 0
+	})
+	sort.Slice(res.Skipped, func(i, j int) bool {
+		return bytes.Compare(res.Skipped[i].Builder[:], res.Skipped[j].Builder[:]) < 0
+	})
+	return res
+}
+
+func (r *Router) send(ctx context.Context, builder common.Address, t *target, body []byte) (*Skip, *Delivery) {
+	access, err := t.checker.Check(ctx, t.commitment)
+	switch {
+	case err != nil:
+		log.Warn("Stake check failed", "builder", builder, "err", err)
+		return &Skip{Builder: builder, Reason: CheckFailed, Err: err}, nil
+	case !access.HasMinimalStake:
+		return &Skip{Builder: builder, Reason: InsufficientStake, Access: access}, nil
+	case !access.Active:
+		return &Skip{Builder: builder, Reason: SubscriptionExpired, Access: access}, nil
+	}
+
+	d := &Delivery{Builder: builder, Endpoint: t.endpoint, Access: access}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		d.Err = err
+		return nil, d
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(gate.DefaultCommitmentHeader, t.commitment.Hex())
+	resp, err := t.client.Do(req)
+	if err != nil {
+		d.Err = err
+		return nil, d
+	}
+	defer resp.Body.Close()
+	d.StatusCode = resp.StatusCode
+	d.Response, d.Err = io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if d.Err == nil {
+		d.Err = responseError(resp.StatusCode, d.Response)
+	}
+	if d.Err != nil {
+		log.Debug("Builder rejected request", "builder", builder, "status", resp.StatusCode, "err", d.Err)
+	}
+	return nil, d
+}
+
+// responseError returns the HTTP or JSON-RPC error of a builder response.
+func responseError(status int, body []byte) error {
+	var resp struct {
+		Error *gate.Error `json:"error"`
+	}
+	if json.Unmarshal(body, &resp) == nil && resp.Error != nil {
+		return resp.Error
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("router: builder returned HTTP %d", status)
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/primevprotocol/primev-contracts/pkg/gate"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+	"github.com/primevprotocol/primev-contracts/pkg/proof"
+)
+
+var contractABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(primev.BuilderStakingMetaData.ABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// chain answers builders and stakes calls from maps.
+type chain struct {
+	head    uint64
+	minimal map[common.Address]int64
+	stakes  map[common.Hash][2]int64 // stake, subscriptionEnd
+	failing map[common.Hash]bool     // stakes reads that fail
+}
+
+func (c *chain) CodeAt(ctx context.Context, account common.Address, number *big.Int) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (c *chain) BlockNumber(ctx context.Context) (uint64, error) {
+	return c.head, nil
+}
+
+func (c *chain) CallContract(ctx context.Context, call ethereum.CallMsg, number *big.Int) ([]byte, error) {
+	method, err := contractABI.MethodById(call.Data[:4])
+	if err != nil {
+		return nil, err
+	}
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	switch method.Name {
+	case "builders":
+		return method.Outputs.Pack(big.NewInt(c.minimal[args[0].(common.Address)]), big.NewInt(100))
+	case "stakes":
+		commitment := common.Hash(args[0].([32]byte))
+		if c.failing[commitment] {
+			return nil, errors.New("node unavailable")
+		}
+		s := c.stakes[commitment]
+		return method.Outputs.Pack(big.NewInt(s[1]), big.NewInt(s[0]))
+	}
+	return nil, fmt.Errorf("unexpected call to %s", method.Name)
+}
+
+// mockBuilder records the requests it receives and answers with status and
+// body.
+type mockBuilder struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newMockBuilder(t *testing.T, status int, body string) *mockBuilder {
+	m := new(mockBuilder)
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		m.mu.Lock()
+		m.requests = append(m.requests, r)
+		m.bodies = append(m.bodies, b)
+		m.mu.Unlock()
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(m.Close)
+	return m
+}
+
+func (m *mockBuilder) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.requests)
+}
+
+var (
+	builderA = common.HexToAddress("0x000000000000000000000000000000000000000a")
+	builderB = common.HexToAddress("0x000000000000000000000000000000000000000b")
+	builderC = common.HexToAddress("0x000000000000000000000000000000000000000c")
+	builderD = common.HexToAddress("0x000000000000000000000000000000000000000d")
+	builderE = common.HexToAddress("0x000000000000000000000000000000000000000e")
+)
+
+const okBody = `{"jsonrpc":"2.0","id":1,"result":null}`
+
+func TestSendFanOut(t *testing.T) {
+	account := common.HexToAddress("0x5ea5c4e5")
+	c := &chain{
+		head:    100,
+		minimal: map[common.Address]int64{builderA: 10, builderB: 10, builderC: 10, builderD: 10, builderE: 10},
+		stakes: map[common.Hash][2]int64{
+			proof.Commitment(account, builderA): {10, 200},
+			proof.Commitment(account, builderB): {9, 200},
+			proof.Commitment(account, builderC): {10, 100},
+			proof.Commitment(account, builderE): {50, 101},
+		},
+		failing: map[common.Hash]bool{proof.Commitment(account, builderD): true},
+	}
+	mocks := map[common.Address]*mockBuilder{}
+	endpoints := map[common.Address]string{}
+	for _, b := range []common.Address{builderA, builderB, builderC, builderD, builderE} {
+		mocks[b] = newMockBuilder(t, http.StatusOK, okBody)
+		endpoints[b] = mocks[b].URL
+	}
+	r, err := New(common.Address{}, c, Config{Account: account, Builders: endpoints})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_sendBundle","params":[]}`)
+	res := r.Send(context.Background(), body)
+
+	if len(res.Delivered) != 2 || res.Delivered[0].Builder != builderA || res.Delivered[1].Builder != builderE {
+		t.Fatalf("delivered = %+v, want builders a and e in order", res.Delivered)
+	}
+	for _, d := range res.Delivered {
+		if d.Err != nil || d.StatusCode != http.StatusOK || string(d.Response) != okBody {
+			t.Errorf("delivery to %s = %d %s %v", d.Builder, d.StatusCode, d.Response, d.Err)
+		}
+		m := mocks[d.Builder]
+		if m.count() != 1 || string(m.bodies[0]) != string(body) {
+			t.Errorf("builder %s received %d requests", d.Builder, m.count())
+		}
+		if got := m.requests[0].Header.Get(gate.DefaultCommitmentHeader); got != proof.Commitment(account, d.Builder).Hex() {
+			t.Errorf("builder %s got commitment header %s", d.Builder, got)
+		}
+	}
+
+	want := []struct {
+		builder common.Address
+		reason  Reason
+	}{
+		{builderB, InsufficientStake},
+		{builderC, SubscriptionExpired},
+		{builderD, CheckFailed},
+	}
+	if len(res.Skipped) != len(want) {
+		t.Fatalf("skipped = %+v, want %d", res.Skipped, len(want))
+	}
+	for i, w := range want {
+		s := res.Skipped[i]
+		if s.Builder != w.builder || s.Reason != w.reason {
+			t.Errorf("skipped[%d] = %s %q, want %s %q", i, s.Builder, s.Reason, w.builder, w.reason)
+		}
+		if (s.Reason == CheckFailed) != (s.Err != nil) || (s.Reason == CheckFailed) != (s.Access == nil) {
+			t.Errorf("skipped[%d] has access %v and error %v", i, s.Access, s.Err)
+		}
+		if mocks[s.Builder].count() != 0 {
+			t.Errorf("skipped builder %s received a request", s.Builder)
+		}
+	}
+}
+
+func TestCall(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	c := &chain{
+		head:    100,
+		minimal: map[common.Address]int64{builderA: 10},
+		stakes:  map[common.Hash][2]int64{proof.Commitment(account, builderA): {10, 200}},
+	}
+	m := newMockBuilder(t, http.StatusOK, okBody)
+	r, err := New(common.Address{}, c, Config{Key: key, Builders: map[common.Address]string{builderA: m.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := map[string]interface{}{"txs": []string{"0x01"}, "blockNumber": "0x65"}
+	res, err := r.Call(context.Background(), "eth_sendBundle", bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Delivered) != 1 || res.Delivered[0].Err != nil {
+		t.Fatalf("result = %+v", res)
+	}
+
+	var req struct {
+		JSONRPC string            `json:"jsonrpc"`
+		Method  string            `json:"method"`
+		Params  []json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(m.bodies[0], &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.JSONRPC != "2.0" || req.Method != "eth_sendBundle" || len(req.Params) != 1 {
+		t.Errorf("request = %s", m.bodies[0])
+	}
+	p, err := proof.FromHeaders(m.requests[0].Header)
+	if err != nil {
+		t.Fatalf("request carries no proof: %v", err)
+	}
+	if signer, err := p.Recover(builderA, m.bodies[0]); err != nil || signer != account {
+		t.Errorf("proof recovers %s, %v", signer, err)
+	}
+}
+
+func TestResponseErrors(t *testing.T) {
+	account := common.HexToAddress("0x5ea5c4e5")
+	c := &chain{
+		head:    100,
+		minimal: map[common.Address]int64{builderA: 10, builderB: 10},
+		stakes: map[common.Hash][2]int64{
+			proof.Commitment(account, builderA): {10, 200},
+			proof.Commitment(account, builderB): {10, 200},
+		},
+	}
+	rpcErr := newMockBuilder(t, http.StatusOK, `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"bundle rejected"}}`)
+	httpErr := newMockBuilder(t, http.StatusServiceUnavailable, "busy")
+	r, err := New(common.Address{}, c, Config{Account: account, Builders: map[common.Address]string{builderA: rpcErr.URL, builderB: httpErr.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := r.Call(context.Background(), "eth_sendBundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Delivered) != 2 {
+		t.Fatalf("delivered = %+v", res.Delivered)
+	}
+	var e *gate.Error
+	if d := res.Delivered[0]; !errors.As(d.Err, &e) || e.Code != -32000 || e.Message != "bundle rejected" {
+		t.Errorf("JSON-RPC error = %v", d.Err)
+	}
+	if d := res.Delivered[1]; d.StatusCode != http.StatusServiceUnavailable || d.Err == nil || string(d.Response) != "busy" {
+		t.Errorf("HTTP error = %d %v", d.StatusCode, d.Err)
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   string
+	}{
+		{http.StatusOK, okBody, ""},
+		{http.StatusOK, `[{"jsonrpc":"2.0","id":1,"result":null}]`, ""},
+		{http.StatusOK, `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"invalid params"}}`, "invalid params"},
+		{http.StatusBadRequest, `{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"invalid request"}}`, "invalid request"},
+		{http.StatusBadGateway, "<html>", "router: builder returned HTTP 502"},
+	}
+	for _, tt := range tests {
+		err := responseError(tt.status, []byte(tt.body))
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("responseError(%d, %s) = %q, want %q", tt.status, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestNewRequiresAccount(t *testing.T) {
+	_, err := New(common.Address{}, &chain{}, Config{Builders: map[common.Address]string{builderA: "http://localhost"}})
+	if err == nil {
+		t.Fatal("New accepted a config without account or key")
+	}
+}
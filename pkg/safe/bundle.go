@@ -0,0 +1,152 @@
+package safe
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signature is an owner's ECDSA signature of a safeTxHash.
+type Signature struct {
+	Signer    common.Address `json:"signer"`
+	Signature hexutil.Bytes  `json:"signature"`
+}
+
+// Bundle is a Safe transaction together with the owner signatures collected
+// for it so far. It is passed between offline signers as a JSON file.
+type Bundle struct {
+	Safe        common.Address `json:"safe"`
+	ChainID     *hexutil.Big   `json:"chainId"`
+	Transaction *Transaction   `json:"transaction"`
+	SafeTxHash  common.Hash    `json:"safeTxHash"`
+	Signatures  []Signature    `json:"signatures"`
+}
+
+// NewBundle creates an unsigned bundle for tx on the Safe at safe.
+func NewBundle(chainID *hexutil.Big, safe common.Address, tx *Transaction) *Bundle {
+	return &Bundle{
+		Safe:        safe,
+		ChainID:     chainID,
+		Transaction: tx,
+		SafeTxHash:  tx.Hash(chainID.ToInt(), safe),
+	}
+}
+
+// Verify recomputes the safeTxHash and checks every collected signature.
+func (b *Bundle) Verify() error {
+	if hash := b.Transaction.Hash(b.ChainID.ToInt(), b.Safe); hash != b.SafeTxHash {
+		return fmt.Errorf("safe: bundle hash %s does not match transaction hash %s", b.SafeTxHash, hash)
+	}
+	for _, sig := range b.Signatures {
+		signer, err := recoverSigner(b.SafeTxHash, sig.Signature)
+		if err != nil {
+			return err
+		}
+		if signer != sig.Signer {
+			return fmt.Errorf("safe: signature recovers to %s, expected %s", signer, sig.Signer)
+		}
+	}
+	return nil
+}
+
+// Sign adds the signature of key to the bundle, replacing an earlier
+// signature of the same owner.
+func (b *Bundle) Sign(key *ecdsa.PrivateKey) error {
+	if err := b.Verify(); err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(b.SafeTxHash.Bytes(), key)
+	if err != nil {
+		return err
+	}
+	sig[64] += 27 // Safe expects v to be 27 or 28 for plain ECDSA signatures
+	return b.AddSignature(Signature{Signer: crypto.PubkeyToAddress(key.PublicKey), Signature: sig})
+}
+
+// AddSignature adds a signature produced elsewhere, e.g. by a hardware wallet,
+// after checking that it was made by sig.Signer.
+func (b *Bundle) AddSignature(sig Signature) error {
+	signer, err := recoverSigner(b.SafeTxHash, sig.Signature)
+	if err != nil {
+		return err
+	}
+	if signer != sig.Signer {
+		return fmt.Errorf("safe: signature recovers to %s, expected %s", signer, sig.Signer)
+	}
+	for i := range b.Signatures {
+		if b.Signatures[i].Signer == sig.Signer {
+			b.Signatures[i] = sig
+			return nil
+		}
+	}
+	b.Signatures = append(b.Signatures, sig)
+	return nil
+}
+
+// PackedSignatures returns the signatures concatenated in ascending signer
+// order, as required by execTransaction.
+func (b *Bundle) PackedSignatures() []byte {
+	return pack(b.Signatures)
+}
+
+func pack(signatures []Signature) []byte {
+	sigs := append([]Signature(nil), signatures...)
+	sort.Slice(sigs, func(i, j int) bool {
+		return bytes.Compare(sigs[i].Signer.Bytes(), sigs[j].Signer.Bytes()) < 0
+	})
+	var packed []byte
+	for _, sig := range sigs {
+		packed = append(packed, sig.Signature...)
+	}
+	return packed
+}
+
+// ExecCalldata assembles the execTransaction calldata for the Safe once at
+// least threshold of its owners have signed. Signatures of other accounts
+// would make execTransaction revert, so they are left out.
+func (b *Bundle) ExecCalldata(threshold uint64, owners []common.Address) ([]byte, error) {
+	if err := b.Verify(); err != nil {
+		return nil, err
+	}
+	isOwner := make(map[common.Address]bool, len(owners))
+	for _, o := range owners {
+		isOwner[o] = true
+	}
+	var sigs []Signature
+	for _, sig := range b.Signatures {
+		if isOwner[sig.Signer] {
+			sigs = append(sigs, sig)
+		}
+	}
+	if uint64(len(sigs)) < threshold {
+		return nil, fmt.Errorf("safe: %d of %d required owner signatures collected", len(sigs), threshold)
+	}
+	tx := b.Transaction
+	return safeABI.Pack("execTransaction",
+		tx.To, tx.Value.ToInt(), []byte(tx.Data), uint8(tx.Operation),
+		tx.SafeTxGas.ToInt(), tx.BaseGas.ToInt(), tx.GasPrice.ToInt(),
+		tx.GasToken, tx.RefundReceiver, pack(sigs),
+	)
+}
+
+func recoverSigner(hash common.Hash, sig []byte) (common.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, errors.New("safe: invalid signature length")
+	}
+	if sig[64] < 27 {
+		return common.Address{}, errors.New("safe: invalid signature recovery id")
+	}
+	normalized := append([]byte(nil), sig...)
+	normalized[64] -= 27
+	pub, err := crypto.SigToPub(hash.Bytes(), normalized)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
@@ -0,0 +1,191 @@
+package safe
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+var testSafe = common.HexToAddress("0x5afe5afe5afe5afe5afe5afe5afe5afe5afe5afe")
+
+// typedData is the EIP-712 message of tx as defined by Safe v1.3.0.
+func typedData(chainID int64, safe common.Address, tx *Transaction) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"SafeTx": {
+				{Name: "to", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "data", Type: "bytes"},
+				{Name: "operation", Type: "uint8"},
+				{Name: "safeTxGas", Type: "uint256"},
+				{Name: "baseGas", Type: "uint256"},
+				{Name: "gasPrice", Type: "uint256"},
+				{Name: "gasToken", Type: "address"},
+				{Name: "refundReceiver", Type: "address"},
+				{Name: "nonce", Type: "uint256"},
+			},
+		},
+		PrimaryType: "SafeTx",
+		Domain: apitypes.TypedDataDomain{
+			ChainId:           math.NewHexOrDecimal256(chainID),
+			VerifyingContract: safe.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"to":             tx.To.Hex(),
+			"value":          tx.Value.ToInt().String(),
+			"data":           tx.Data.String(),
+			"operation":      big.NewInt(int64(tx.Operation)).String(),
+			"safeTxGas":      tx.SafeTxGas.ToInt().String(),
+			"baseGas":        tx.BaseGas.ToInt().String(),
+			"gasPrice":       tx.GasPrice.ToInt().String(),
+			"gasToken":       tx.GasToken.Hex(),
+			"refundReceiver": tx.RefundReceiver.Hex(),
+			"nonce":          tx.Nonce.ToInt().String(),
+		},
+	}
+}
+
+func wei(v int64) *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(v))
+}
+
+func TestSafeTxHash(t *testing.T) {
+	contract := common.HexToAddress("0x0000000000000000000000000000000000001234")
+	transfer, err := NewTransaction(contract, common.Big3, "transferOwnership", common.HexToAddress("0x00000000000000000000000000000000000000aa"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		chainID int64
+		tx      *Transaction
+		pinned  string // Expected safeTxHash, guarding against both encoders changing
+	}{
+		{
+			name:    "empty call",
+			chainID: 1,
+			tx: &Transaction{
+				To: contract, Value: wei(0), SafeTxGas: wei(0), BaseGas: wei(0), GasPrice: wei(0), Nonce: wei(0),
+			},
+		},
+		{
+			name:    "transferOwnership",
+			chainID: 5,
+			tx:      transfer,
+			pinned:  "0x72eac5e7d422beead3eafa17eee8a6bc4e347ac31ffe7cbc0f24937c49cd34ab",
+		},
+		{
+			name:    "refund fields and delegate call",
+			chainID: 11155111,
+			tx: &Transaction{
+				To:             contract,
+				Value:          wei(1e18),
+				Data:           hexutil.MustDecode("0xdeadbeef"),
+				Operation:      DelegateCall,
+				SafeTxGas:      wei(50000),
+				BaseGas:        wei(21000),
+				GasPrice:       wei(1e9),
+				GasToken:       common.HexToAddress("0x00000000000000000000000000000000000000cc"),
+				RefundReceiver: common.HexToAddress("0x00000000000000000000000000000000000000dd"),
+				Nonce:          wei(42),
+			},
+		},
+	}
+	for _, tt := range tests {
+		want, _, err := apitypes.TypedDataAndHash(typedData(tt.chainID, testSafe, tt.tx))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := tt.tx.Hash(wei(tt.chainID).ToInt(), testSafe)
+		if !bytes.Equal(got.Bytes(), want) {
+			t.Errorf("%s: safeTxHash = %s, want %x", tt.name, got, want)
+		}
+		if tt.pinned != "" && got != common.HexToHash(tt.pinned) {
+			t.Errorf("%s: safeTxHash = %s, want %s", tt.name, got, tt.pinned)
+		}
+	}
+}
+
+func keys(t *testing.T, n int) []*ecdsa.PrivateKey {
+	keys := make([]*ecdsa.PrivateKey, n)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+func testBundle(t *testing.T) *Bundle {
+	tx, err := NewTransaction(common.HexToAddress("0x1234"), common.Big1, "transferOwnership", common.HexToAddress("0xaa"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewBundle(wei(1), testSafe, tx)
+}
+
+func TestPackedSignaturesOrder(t *testing.T) {
+	b := testBundle(t)
+	var signers []common.Address
+	for _, key := range keys(t, 5) {
+		if err := b.Sign(key); err != nil {
+			t.Fatal(err)
+		}
+		signers = append(signers, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	sort.Slice(signers, func(i, j int) bool { return bytes.Compare(signers[i][:], signers[j][:]) < 0 })
+
+	packed := b.PackedSignatures()
+	if len(packed) != len(signers)*crypto.SignatureLength {
+		t.Fatalf("packed %d bytes for %d signatures", len(packed), len(signers))
+	}
+	for i, want := range signers {
+		sig := packed[i*crypto.SignatureLength : (i+1)*crypto.SignatureLength]
+		if signer, err := recoverSigner(b.SafeTxHash, sig); err != nil || signer != want {
+			t.Errorf("signature %d recovers %s, %v; want %s", i, signer, err, want)
+		}
+	}
+}
+
+func TestExecCalldataCountsOwners(t *testing.T) {
+	b := testBundle(t)
+	ks := keys(t, 3)
+	owners := []common.Address{crypto.PubkeyToAddress(ks[0].PublicKey), crypto.PubkeyToAddress(ks[1].PublicKey)}
+	for _, key := range []*ecdsa.PrivateKey{ks[0], ks[2]} {
+		if err := b.Sign(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := b.ExecCalldata(2, owners); err == nil {
+		t.Fatal("a non-owner signature counted towards the threshold")
+	}
+
+	if err := b.Sign(ks[1]); err != nil {
+		t.Fatal(err)
+	}
+	calldata, err := b.ExecCalldata(2, owners)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := safeABI.Methods["execTransaction"].Inputs.Unpack(calldata[4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(args[9].([]byte), pack([]Signature{b.Signatures[0], b.Signatures[2]})) {
+		t.Error("execTransaction signatures include the non-owner")
+	}
+}
@@ -0,0 +1,81 @@
+package safe
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// safeABIJSON covers the subset of the Safe contract used by this package.
+const safeABIJSON = `[
+{"inputs":[],"name":"nonce","outputs":[{"type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[],"name":"getThreshold","outputs":[{"type":"uint256"}],"stateMutability":"view","type":"function"},
+{"inputs":[],"name":"getOwners","outputs":[{"type":"address[]"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"},{"name":"operation","type":"uint8"},{"name":"safeTxGas","type":"uint256"},{"name":"baseGas","type":"uint256"},{"name":"gasPrice","type":"uint256"},{"name":"gasToken","type":"address"},{"name":"refundReceiver","type":"address"},{"name":"_nonce","type":"uint256"}],"name":"getTransactionHash","outputs":[{"type":"bytes32"}],"stateMutability":"view","type":"function"},
+{"inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"},{"name":"operation","type":"uint8"},{"name":"safeTxGas","type":"uint256"},{"name":"baseGas","type":"uint256"},{"name":"gasPrice","type":"uint256"},{"name":"gasToken","type":"address"},{"name":"refundReceiver","type":"address"},{"name":"signatures","type":"bytes"}],"name":"execTransaction","outputs":[{"type":"bool"}],"stateMutability":"payable","type":"function"}
+]`
+
+var safeABI = mustParseABI(safeABIJSON)
+
+func mustParseABI(s string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(s))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// Safe reads state of a deployed Safe contract.
+type Safe struct {
+	contract *bind.BoundContract
+}
+
+// NewSafe binds a Safe deployed at address.
+func NewSafe(address common.Address, caller bind.ContractCaller) *Safe {
+	return &Safe{contract: bind.NewBoundContract(address, safeABI, caller, nil, nil)}
+}
+
+// Nonce returns the nonce the next Safe transaction has to use.
+func (s *Safe) Nonce(opts *bind.CallOpts) (*big.Int, error) {
+	return s.callBig(opts, "nonce")
+}
+
+// Threshold returns the number of owner signatures required.
+func (s *Safe) Threshold(opts *bind.CallOpts) (*big.Int, error) {
+	return s.callBig(opts, "getThreshold")
+}
+
+// Owners returns the Safe owners.
+func (s *Safe) Owners(opts *bind.CallOpts) ([]common.Address, error) {
+	var out []interface{}
+	if err := s.contract.Call(opts, &out, "getOwners"); err != nil {
+		return nil, err
+	}
+	return *abi.ConvertType(out[0], new([]common.Address)).(*[]common.Address), nil
+}
+
+// TransactionHash asks the Safe to hash tx, which allows checking the locally
+// computed safeTxHash against the deployed contract.
+func (s *Safe) TransactionHash(opts *bind.CallOpts, tx *Transaction) (common.Hash, error) {
+	var out []interface{}
+	err := s.contract.Call(opts, &out, "getTransactionHash",
+		tx.To, tx.Value.ToInt(), []byte(tx.Data), uint8(tx.Operation),
+		tx.SafeTxGas.ToInt(), tx.BaseGas.ToInt(), tx.GasPrice.ToInt(),
+		tx.GasToken, tx.RefundReceiver, tx.Nonce.ToInt(),
+	)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.Hash(*abi.ConvertType(out[0], new([32]byte)).(*[32]byte)), nil
+}
+
+func (s *Safe) callBig(opts *bind.CallOpts, method string) (*big.Int, error) {
+	var out []interface{}
+	if err := s.contract.Call(opts, &out, method); err != nil {
+		return nil, err
+	}
+	return *abi.ConvertType(out[0], new(*big.Int)).(**big.Int), nil
+}
@@ -0,0 +1,107 @@
+// Package safe builds Safe multisig transactions for BuilderStaking calls, so
+// that an owner held by a Safe can propose, collect signatures for and execute
+// admin operations such as TransferOwnership.
+package safe
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// Operation is the Safe call type.
+type Operation uint8
+
+const (
+	Call         Operation = 0
+	DelegateCall Operation = 1
+)
+
+var (
+	domainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(uint256 chainId,address verifyingContract)"))
+	safeTxTypeHash = crypto.Keccak256Hash([]byte("SafeTx(address to,uint256 value,bytes data,uint8 operation,uint256 safeTxGas,uint256 baseGas,uint256 gasPrice,address gasToken,address refundReceiver,uint256 nonce)"))
+)
+
+// Transaction is a Safe transaction as hashed by Safe contracts v1.3.0 and later.
+type Transaction struct {
+	To             common.Address `json:"to"`
+	Value          *hexutil.Big   `json:"value"`
+	Data           hexutil.Bytes  `json:"data"`
+	Operation      Operation      `json:"operation"`
+	SafeTxGas      *hexutil.Big   `json:"safeTxGas"`
+	BaseGas        *hexutil.Big   `json:"baseGas"`
+	GasPrice       *hexutil.Big   `json:"gasPrice"`
+	GasToken       common.Address `json:"gasToken"`
+	RefundReceiver common.Address `json:"refundReceiver"`
+	Nonce          *hexutil.Big   `json:"nonce"`
+}
+
+// NewTransaction builds a Safe transaction calling method on the
+// BuilderStaking contract at address. Gas refund fields are left at zero so
+// the executor pays for gas.
+func NewTransaction(address common.Address, nonce *big.Int, method string, params ...interface{}) (*Transaction, error) {
+	parsed, err := primev.BuilderStakingMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	data, err := parsed.Pack(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{
+		To:        address,
+		Value:     new(hexutil.Big),
+		Data:      data,
+		Operation: Call,
+		SafeTxGas: new(hexutil.Big),
+		BaseGas:   new(hexutil.Big),
+		GasPrice:  new(hexutil.Big),
+		Nonce:     (*hexutil.Big)(new(big.Int).Set(nonce)),
+	}, nil
+}
+
+// DomainSeparator returns the EIP-712 domain separator of the Safe at safe.
+func DomainSeparator(chainID *big.Int, safe common.Address) common.Hash {
+	return crypto.Keccak256Hash(
+		domainTypeHash.Bytes(),
+		math.U256Bytes(new(big.Int).Set(chainID)),
+		common.LeftPadBytes(safe.Bytes(), 32),
+	)
+}
+
+// StructHash returns the EIP-712 struct hash of the transaction.
+func (tx *Transaction) StructHash() common.Hash {
+	return crypto.Keccak256Hash(
+		safeTxTypeHash.Bytes(),
+		common.LeftPadBytes(tx.To.Bytes(), 32),
+		word(tx.Value),
+		crypto.Keccak256(tx.Data),
+		common.LeftPadBytes([]byte{byte(tx.Operation)}, 32),
+		word(tx.SafeTxGas),
+		word(tx.BaseGas),
+		word(tx.GasPrice),
+		common.LeftPadBytes(tx.GasToken.Bytes(), 32),
+		common.LeftPadBytes(tx.RefundReceiver.Bytes(), 32),
+		word(tx.Nonce),
+	)
+}
+
+// Hash returns the safeTxHash signed by the Safe owners.
+func (tx *Transaction) Hash(chainID *big.Int, safe common.Address) common.Hash {
+	return crypto.Keccak256Hash(
+		[]byte{0x19, 0x01},
+		DomainSeparator(chainID, safe).Bytes(),
+		tx.StructHash().Bytes(),
+	)
+}
+
+func word(v *hexutil.Big) []byte {
+	if v == nil {
+		return make([]byte, 32)
+	}
+	return math.U256Bytes(new(big.Int).Set(v.ToInt()))
+}
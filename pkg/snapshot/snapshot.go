@@ -0,0 +1,164 @@
+// Package snapshot reads BuilderStaking state consistently at a single block.
+//
+// Separate calls against "latest" can each see a different block. A Snapshot
+// resolves the block once, pins every call to it and can verify afterwards
+// that the block was not reorged out while reading.
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/primevprotocol/primev-contracts/pkg/heads"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// ErrPending is returned for the "pending" tag, which does not name a block.
+var ErrPending = errors.New("snapshot: pending state cannot be pinned")
+
+// ReorgedError is returned by Verify if the snapshot block is no longer
+// canonical.
+type ReorgedError struct {
+	Number   uint64
+	Expected common.Hash // Hash the snapshot was taken at
+	Actual   common.Hash // Canonical hash at Number now
+}
+
+func (e *ReorgedError) Error() string {
+	return fmt.Sprintf("snapshot: block %d reorged, was %s, now %s", e.Number, e.Expected, e.Actual)
+}
+
+// Backend is the node connection needed by Snapshot.
+type Backend interface {
+	bind.ContractCaller
+	heads.Reader
+}
+
+// ParseTag parses a block number (decimal or 0x-prefixed hex) or one of the
+// tags "latest", "safe", "finalized" and "earliest" into the form accepted by
+// HeaderByNumber: nil for latest and negative values for the other tags.
+func ParseTag(tag string) (*big.Int, error) {
+	if n, err := strconv.ParseUint(tag, 10, 64); err == nil {
+		return new(big.Int).SetUint64(n), nil
+	}
+	var bn rpc.BlockNumber
+	if err := bn.UnmarshalJSON([]byte(strconv.Quote(tag))); err != nil {
+		return nil, fmt.Errorf("snapshot: invalid block %q", tag)
+	}
+	switch bn {
+	case rpc.LatestBlockNumber:
+		return nil, nil
+	case rpc.PendingBlockNumber:
+		return nil, ErrPending
+	case rpc.EarliestBlockNumber:
+		return new(big.Int), nil
+	}
+	return big.NewInt(bn.Int64()), nil
+}
+
+// Snapshot reads the BuilderStaking contract at a single resolved block.
+type Snapshot struct {
+	Number uint64
+	Hash   common.Hash
+	Time   uint64
+
+	ctx     context.Context
+	backend Backend
+	caller  *primev.BuilderStakingCaller
+}
+
+// New resolves tag once, see ParseTag, and returns a Snapshot of the
+// BuilderStaking contract at address pinned to that block. All reads use ctx.
+func New(ctx context.Context, address common.Address, backend Backend, tag string) (*Snapshot, error) {
+	number, err := ParseTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	header, err := backend.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	caller, err := primev.NewBuilderStakingCaller(address, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{
+		Number:  header.Number.Uint64(),
+		Hash:    header.Hash(),
+		Time:    header.Time,
+		ctx:     ctx,
+		backend: backend,
+		caller:  caller,
+	}, nil
+}
+
+// CallOpts returns call options pinned to the snapshot block, for reads not
+// covered by Snapshot's own methods.
+func (s *Snapshot) CallOpts() *bind.CallOpts {
+	return &bind.CallOpts{Context: s.ctx, BlockNumber: new(big.Int).SetUint64(s.Number)}
+}
+
+// Builders returns the terms of builder.
+func (s *Snapshot) Builders(builder common.Address) (struct {
+	MinimalStake              *big.Int
+	MinimalSubscriptionPeriod *big.Int
+}, error) {
+	return s.caller.Builders(s.CallOpts(), builder)
+}
+
+// Stakes returns the stake of commitment.
+func (s *Snapshot) Stakes(commitment [32]byte) (struct {
+	SubscriptionEnd *big.Int
+	Stake           *big.Int
+}, error) {
+	return s.caller.Stakes(s.CallOpts(), commitment)
+}
+
+// HasMinimalStake reports whether commitment holds the minimal stake of builder.
+func (s *Snapshot) HasMinimalStake(builder common.Address, commitment [32]byte) (bool, error) {
+	return s.caller.HasMinimalStake(s.CallOpts(), builder, commitment)
+}
+
+// GetSubscriptionPeriod returns the blocks a deposit of amount buys from builder.
+func (s *Snapshot) GetSubscriptionPeriod(builder common.Address, amount *big.Int) (*big.Int, error) {
+	return s.caller.GetSubscriptionPeriod(s.CallOpts(), builder, amount)
+}
+
+// TimeLocksCount returns the number of time locks of account.
+func (s *Snapshot) TimeLocksCount(account common.Address) (*big.Int, error) {
+	return s.caller.TimeLocksCount(s.CallOpts(), account)
+}
+
+// TimeLocks returns the time lock of account at index.
+func (s *Snapshot) TimeLocks(account common.Address, index *big.Int) (struct {
+	InitialAmount   *big.Int
+	RemainingAmount *big.Int
+	StartTime       *big.Int
+	LockDuration    *big.Int
+}, error) {
+	return s.caller.TimeLocks(s.CallOpts(), account, index)
+}
+
+// Owner returns the contract owner.
+func (s *Snapshot) Owner() (common.Address, error) {
+	return s.caller.Owner(s.CallOpts())
+}
+
+// Verify checks that the snapshot block is still canonical. Call it after
+// the reads; if it fails the results may belong to an abandoned fork.
+func (s *Snapshot) Verify() error {
+	header, err := s.backend.HeaderByNumber(s.ctx, new(big.Int).SetUint64(s.Number))
+	if err != nil {
+		return err
+	}
+	if hash := header.Hash(); hash != s.Hash {
+		return &ReorgedError{Number: s.Number, Expected: s.Hash, Actual: hash}
+	}
+	return nil
+}
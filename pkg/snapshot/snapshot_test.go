@@ -0,0 +1,106 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    *big.Int
+		wantErr error
+		invalid bool
+	}{
+		{tag: "latest"},
+		{tag: "0", want: big.NewInt(0)},
+		{tag: "17000000", want: big.NewInt(17000000)},
+		{tag: "0x10", want: big.NewInt(16)},
+		{tag: "earliest", want: big.NewInt(0)},
+		{tag: "safe", want: big.NewInt(-4)},
+		{tag: "finalized", want: big.NewInt(-3)},
+		{tag: "pending", wantErr: ErrPending},
+		{tag: "", invalid: true},
+		{tag: "-1", invalid: true},
+		{tag: "0xzz", invalid: true},
+		{tag: "newest", invalid: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTag(tt.tag)
+		switch {
+		case tt.invalid:
+			if err == nil {
+				t.Errorf("%q: parsed as %v, want error", tt.tag, got)
+			}
+		case err != tt.wantErr:
+			t.Errorf("%q: err %v, want %v", tt.tag, err, tt.wantErr)
+		case (got == nil) != (tt.want == nil) || got != nil && got.Cmp(tt.want) != 0:
+			t.Errorf("%q: %v, want %v", tt.tag, got, tt.want)
+		}
+	}
+}
+
+// chain serves headers, with the canonical block at a number replaceable to
+// simulate a reorg. Methods Snapshot does not use are left to the nil
+// embedded Backend.
+type chain struct {
+	Backend
+	head     uint64
+	headers  map[uint64]*types.Header
+	requests []*big.Int
+}
+
+func (c *chain) header(number uint64, extra string) {
+	c.headers[number] = &types.Header{Number: new(big.Int).SetUint64(number), Time: number * 12, Extra: []byte(extra)}
+}
+
+func (c *chain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	c.requests = append(c.requests, number)
+	n := c.head
+	if number != nil {
+		n = number.Uint64()
+	}
+	h, ok := c.headers[n]
+	if !ok {
+		return nil, errors.New("header not found")
+	}
+	return h, nil
+}
+
+func TestVerify(t *testing.T) {
+	c := &chain{head: 11, headers: make(map[uint64]*types.Header)}
+	c.header(10, "a")
+	c.header(11, "a")
+	s, err := New(context.Background(), common.Address{}, c, "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Number != 11 || s.Time != 132 || s.Hash != c.headers[11].Hash() {
+		t.Errorf("snapshot at %d (%d, %s), want 11", s.Number, s.Time, s.Hash)
+	}
+	if c.requests[0] != nil {
+		t.Errorf("latest resolved with %v, want nil", c.requests[0])
+	}
+	if opts := s.CallOpts(); opts.BlockNumber.Uint64() != 11 {
+		t.Errorf("calls pinned to %v, want 11", opts.BlockNumber)
+	}
+	// A new head does not move the snapshot.
+	c.header(12, "a")
+	c.head = 12
+	if err := s.Verify(); err != nil {
+		t.Errorf("verify after new head: %v", err)
+	}
+	c.header(11, "b")
+	var reorged *ReorgedError
+	if err := s.Verify(); !errors.As(err, &reorged) || reorged.Number != 11 || reorged.Actual != c.headers[11].Hash() {
+		t.Errorf("verify after reorg: %v", err)
+	}
+	if _, err := New(context.Background(), common.Address{}, c, "pending"); err != ErrPending {
+		t.Errorf("pending snapshot: %v, want %v", err, ErrPending)
+	}
+}
@@ -0,0 +1,93 @@
+// Package stakemath reproduces the integer arithmetic of BuilderStaking.sol so
+// that off-chain tools get exactly the values the contract computes,
+// including its rounding.
+package stakemath
+
+import "math/big"
+
+// BaseDivisor mirrors BASE_DIVISOR in BuilderStaking.sol.
+var BaseDivisor = big.NewInt(1e18)
+
+var big1 = big.NewInt(1)
+
+// BuilderSharePercent is the part of every deposit locked for the builder.
+const BuilderSharePercent = 80
+
+// PeriodPerStake mirrors the first step of _getSubscriptionPeriod: subscription
+// blocks per wei of stake, scaled by BaseDivisor and truncated.
+func PeriodPerStake(minimalStake, minimalSubscriptionPeriod *big.Int) *big.Int {
+	if minimalStake.Sign() == 0 {
+		return new(big.Int)
+	}
+	r := new(big.Int).Mul(minimalSubscriptionPeriod, BaseDivisor)
+	return r.Div(r, minimalStake)
+}
+
+// SubscriptionPeriod mirrors _getSubscriptionPeriod: the number of blocks a
+// deposit of amount buys under the given builder terms.
+func SubscriptionPeriod(minimalStake, minimalSubscriptionPeriod, amount *big.Int) *big.Int {
+	r := new(big.Int).Mul(PeriodPerStake(minimalStake, minimalSubscriptionPeriod), amount)
+	return r.Div(r, BaseDivisor)
+}
+
+// BuilderAmount mirrors the builder share computed by deposit.
+func BuilderAmount(value *big.Int) *big.Int {
+	r := new(big.Int).Mul(value, BaseDivisor)
+	r.Div(r, big.NewInt(100))
+	r.Mul(r, big.NewInt(BuilderSharePercent))
+	return r.Div(r, BaseDivisor)
+}
+
+// OwnerAmount is the remainder of a deposit forwarded to the contract owner.
+func OwnerAmount(value *big.Int) *big.Int {
+	return new(big.Int).Sub(value, BuilderAmount(value))
+}
+
+// StakeForPeriod returns the smallest deposit that buys at least blocks
+// subscription blocks under the given builder terms, never less than the
+// minimal stake. It returns nil if the terms buy zero blocks for any deposit.
+func StakeForPeriod(minimalStake, minimalSubscriptionPeriod, blocks *big.Int) *big.Int {
+	perStake := PeriodPerStake(minimalStake, minimalSubscriptionPeriod)
+	if perStake.Sign() == 0 {
+		return nil
+	}
+	// Smallest amount with perStake * amount / BaseDivisor >= blocks.
+	r := new(big.Int).Mul(blocks, BaseDivisor)
+	r.Add(r, new(big.Int).Sub(perStake, big1))
+	r.Div(r, perStake)
+	if r.Cmp(minimalStake) < 0 {
+		r.Set(minimalStake)
+	}
+	return r
+}
+
+// TimeLock mirrors the TimeLock struct of BuilderStaking.sol.
+type TimeLock struct {
+	InitialAmount   *big.Int
+	RemainingAmount *big.Int
+	StartTime       *big.Int
+	LockDuration    *big.Int
+}
+
+// Releasable mirrors the per lock computation of withdraw and
+// withdrawableAmount at timestamp. Note that the vested amount is computed from
+// the initial amount and only capped by the remaining one, so it is not
+// reduced by earlier withdrawals.
+func Releasable(lock TimeLock, timestamp *big.Int) *big.Int {
+	if lock.LockDuration.Sign() == 0 {
+		return new(big.Int)
+	}
+	elapsed := new(big.Int).Sub(timestamp, lock.StartTime)
+	if elapsed.Sign() < 0 {
+		elapsed.SetUint64(0)
+	}
+	if elapsed.Cmp(lock.LockDuration) > 0 {
+		elapsed.Set(lock.LockDuration)
+	}
+	r := elapsed.Mul(elapsed, lock.InitialAmount)
+	r.Div(r, lock.LockDuration)
+	if r.Cmp(lock.RemainingAmount) > 0 {
+		r.Set(lock.RemainingAmount)
+	}
+	return r
+}
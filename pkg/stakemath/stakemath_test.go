@@ -0,0 +1,93 @@
+package stakemath
+
+import (
+	"math/big"
+	"testing"
+)
+
+func bi(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid number " + s)
+	}
+	return v
+}
+
+// The expected values follow _getSubscriptionPeriod step by step:
+// periodPerStake = period * 1e18 / minimalStake, then periodPerStake *
+// amount / 1e18, both truncated.
+func TestSubscriptionPeriod(t *testing.T) {
+	tests := []struct {
+		minimalStake, period, amount string
+		perStake, blocks             string
+	}{
+		{"1000000000000000000", "100", "1000000000000000000", "100", "100"},
+		// 10e18 / 3 truncates, so a minimal deposit buys 9 of 10 blocks.
+		{"3", "10", "3", "3333333333333333333", "9"},
+		{"3", "10", "4", "3333333333333333333", "13"},
+		{"7", "1000", "7000000", "142857142857142857142", "999999999"},
+		{"10000000000000000", "7200", "30000000000000001", "720000", "21600"},
+		// periodPerStake truncates to zero, no deposit buys a block.
+		{"2000000000000000000", "1", "2000000000000000000", "0", "0"},
+		// The second division truncates a fraction of a block to zero.
+		{"1000000000000000000", "1", "500000000000000000", "1", "0"},
+	}
+	for _, tt := range tests {
+		ms, period, amount := bi(tt.minimalStake), bi(tt.period), bi(tt.amount)
+		if got := PeriodPerStake(ms, period); got.Cmp(bi(tt.perStake)) != 0 {
+			t.Errorf("PeriodPerStake(%s, %s) = %v, want %s", tt.minimalStake, tt.period, got, tt.perStake)
+		}
+		if got := SubscriptionPeriod(ms, period, amount); got.Cmp(bi(tt.blocks)) != 0 {
+			t.Errorf("SubscriptionPeriod(%s, %s, %s) = %v, want %s", tt.minimalStake, tt.period, tt.amount, got, tt.blocks)
+		}
+	}
+}
+
+func TestBuilderAmount(t *testing.T) {
+	tests := []struct{ value, builder, owner string }{
+		{"1", "0", "1"},
+		{"2", "1", "1"},
+		{"3", "2", "1"},
+		{"99", "79", "20"},
+		{"100", "80", "20"},
+		{"101", "80", "21"},
+		{"1000000000000000001", "800000000000000000", "200000000000000001"},
+	}
+	for _, tt := range tests {
+		if got := BuilderAmount(bi(tt.value)); got.Cmp(bi(tt.builder)) != 0 {
+			t.Errorf("BuilderAmount(%s) = %v, want %s", tt.value, got, tt.builder)
+		}
+		if got := OwnerAmount(bi(tt.value)); got.Cmp(bi(tt.owner)) != 0 {
+			t.Errorf("OwnerAmount(%s) = %v, want %s", tt.value, got, tt.owner)
+		}
+	}
+}
+
+func TestStakeForPeriod(t *testing.T) {
+	tests := []struct{ minimalStake, period, blocks string }{
+		{"1000000000000000000", "100", "250"},
+		{"3", "10", "10"},
+		{"3", "10", "1000"},
+		{"7", "1000", "999999999"},
+		{"7", "1000", "1000000000"},
+		{"10000000000000000", "7200", "1"},
+	}
+	for _, tt := range tests {
+		ms, period, blocks := bi(tt.minimalStake), bi(tt.period), bi(tt.blocks)
+		amount := StakeForPeriod(ms, period, blocks)
+		if amount == nil || amount.Cmp(ms) < 0 {
+			t.Errorf("StakeForPeriod(%s, %s, %s) = %v", tt.minimalStake, tt.period, tt.blocks, amount)
+			continue
+		}
+		if got := SubscriptionPeriod(ms, period, amount); got.Cmp(blocks) < 0 {
+			t.Errorf("%v wei buys %v blocks, want at least %s", amount, got, tt.blocks)
+		}
+		less := new(big.Int).Sub(amount, big1)
+		if less.Cmp(ms) >= 0 && SubscriptionPeriod(ms, period, less).Cmp(blocks) >= 0 {
+			t.Errorf("%v wei is not the smallest deposit buying %s blocks", amount, tt.blocks)
+		}
+	}
+	if amount := StakeForPeriod(bi("2000000000000000000"), bi("1"), bi("1")); amount != nil {
+		t.Errorf("StakeForPeriod with zero period per stake = %v, want nil", amount)
+	}
+}
@@ -0,0 +1,111 @@
+package stakeview
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/primevprotocol/primev-contracts/pkg/events"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// state is the contract state after block number. A state that has been
+// published to readers is only modified under View.mu.
+type state struct {
+	number   uint64
+	hash     common.Hash
+	stakes   map[common.Hash]Stake
+	builders map[common.Address]Terms
+}
+
+func newState(number uint64, hash common.Hash) *state {
+	return &state{
+		number:   number,
+		hash:     hash,
+		stakes:   make(map[common.Hash]Stake),
+		builders: make(map[common.Address]Terms),
+	}
+}
+
+func (s *state) clone() *state {
+	c := newState(s.number, s.hash)
+	for k, v := range s.stakes {
+		c.stakes[k] = v
+	}
+	for k, v := range s.builders {
+		c.builders[k] = v
+	}
+	return c
+}
+
+// apply applies updates in order. Both events carry absolute values, so the
+// last update of a key determines its state.
+func (s *state) apply(updates []update) {
+	for _, u := range updates {
+		if u.stake != nil {
+			s.stakes[u.stake.Commitment] = *u.stake
+		} else {
+			s.builders[u.terms.Builder] = *u.terms
+		}
+	}
+}
+
+// update is one StakeUpdated or BuilderUpdated event.
+type update struct {
+	block uint64
+	hash  common.Hash // Block hash the event was read from
+	stake *Stake
+	terms *Terms
+}
+
+// fetch reads the updates of blocks from to to, in block order per kind.
+func fetch(ctx context.Context, f *primev.BuilderStakingFilterer, from, to, step uint64) ([]update, error) {
+	if from > to {
+		return nil, nil
+	}
+	var updates []update
+	rng := events.Range{From: from, To: to, Step: step}
+	err := events.StakeUpdated(ctx, f, rng, func(e *primev.BuilderStakingStakeUpdated) error {
+		updates = append(updates, update{block: e.Raw.BlockNumber, hash: e.Raw.BlockHash, stake: &Stake{
+			Block:           e.Raw.BlockNumber,
+			Builder:         e.Builder,
+			Commitment:      common.Hash(e.Commitment),
+			Stake:           e.Stake,
+			SubscriptionEnd: e.SubscriptionEnd,
+		}})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = events.BuilderUpdated(ctx, f, rng, func(e *primev.BuilderStakingBuilderUpdated) error {
+		updates = append(updates, update{block: e.Raw.BlockNumber, hash: e.Raw.BlockHash, terms: &Terms{
+			Block:                     e.Raw.BlockNumber,
+			Builder:                   e.Builder,
+			MinimalStake:              e.MinimalStake,
+			MinimalSubscriptionPeriod: e.MinimalSubsriptionPeriod,
+		}})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+// split returns the updates up to and including block and the rest.
+func split(updates []update, block uint64) (done, rest []update) {
+	for _, u := range updates {
+		if u.block <= block {
+			done = append(done, u)
+		} else {
+			rest = append(rest, u)
+		}
+	}
+	return done, rest
+}
+
+// active reports whether subscriptionEnd lies after block.
+func active(subscriptionEnd *big.Int, block uint64) bool {
+	return subscriptionEnd.Cmp(new(big.Int).SetUint64(block)) > 0
+}
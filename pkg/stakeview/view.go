@@ -0,0 +1,334 @@
+// Package stakeview keeps the complete stakes and builders state of a
+// BuilderStaking contract in memory, so gating services can answer whether a
+// commitment is active for a builder without a node round trip per request.
+//
+// The view is bootstrapped by replaying StakeUpdated and BuilderUpdated events,
+// which carry absolute values, and follows new heads from there. Subscription
+// expiry is evaluated against the view's head block. The state Depth blocks
+// behind the head is kept as a checkpoint; a reorg rebuilds the view from the
+// checkpoint, or from FromBlock if the checkpoint itself was reorged, and
+// swaps the result in at once, so readers never see a mix of two forks.
+package stakeview
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/primevprotocol/primev-contracts/pkg/gate"
+	"github.com/primevprotocol/primev-contracts/pkg/heads"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+// DefaultDepth is the number of blocks the checkpoint trails the head by if
+// Config.Depth is zero.
+const DefaultDepth = 64
+
+// ErrNotReady is returned by reads before the view has been built.
+var ErrNotReady = errors.New("stakeview: view not built yet")
+
+// Backend is the node connection needed by View.
+type Backend interface {
+	bind.ContractFilterer
+	heads.Reader
+}
+
+// Config configures a View.
+type Config struct {
+	FromBlock    uint64        // Block event replay starts at, must not be after the deployment block
+	Step         uint64        // Blocks per log query, events.DefaultStep if zero
+	Depth        uint64        // Deepest reorg handled without a full rebuild, DefaultDepth if zero
+	PollInterval time.Duration // Head polling interval if the node has no subscriptions
+}
+
+// Stake is a commitment's stake as of the last StakeUpdated event.
+type Stake struct {
+	Block           uint64 // Block of the last update
+	Builder         common.Address
+	Commitment      common.Hash
+	Stake           *big.Int
+	SubscriptionEnd *big.Int
+}
+
+// Terms are a builder's terms as of the last BuilderUpdated event.
+type Terms struct {
+	Block                     uint64 // Block of the last update
+	Builder                   common.Address
+	MinimalStake              *big.Int
+	MinimalSubscriptionPeriod *big.Int
+}
+
+// View is a materialized view of a BuilderStaking contract. Reads are safe
+// for concurrent use; Run must be called once to build and update it.
+type View struct {
+	cfg      Config
+	backend  Backend
+	filterer *primev.BuilderStakingFilterer
+
+	mu    sync.RWMutex
+	live  *state
+	ready chan struct{}
+
+	// Only used by Run.
+	base    *state   // Checkpoint, trails live by Depth to 2*Depth blocks
+	pending []update // Updates between base and live
+}
+
+// New creates a View of the BuilderStaking contract at address.
+func New(address common.Address, backend Backend, cfg Config) (*View, error) {
+	if cfg.Depth == 0 {
+		cfg.Depth = DefaultDepth
+	}
+	filterer, err := primev.NewBuilderStakingFilterer(address, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &View{cfg: cfg, backend: backend, filterer: filterer, ready: make(chan struct{})}, nil
+}
+
+// Ready is closed once the view has been built.
+func (v *View) Ready() <-chan struct{} {
+	return v.ready
+}
+
+// Head returns the block the view reflects, or zero values before it is
+// built.
+func (v *View) Head() (uint64, common.Hash) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.live == nil {
+		return 0, common.Hash{}
+	}
+	return v.live.number, v.live.hash
+}
+
+// Stake returns the stake of commitment. It reports false for commitments
+// that never had a deposit.
+func (v *View) Stake(commitment common.Hash) (Stake, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.live == nil {
+		return Stake{}, false
+	}
+	s, ok := v.live.stakes[commitment]
+	return s, ok
+}
+
+// Terms returns the terms of builder. It reports false for addresses that
+// never registered as a builder.
+func (v *View) Terms(builder common.Address) (Terms, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.live == nil {
+		return Terms{}, false
+	}
+	t, ok := v.live.builders[builder]
+	return t, ok
+}
+
+// Access evaluates commitment against builder's terms at the view's head,
+// using the same rules as gate.ChainChecker.
+func (v *View) Access(builder common.Address, commitment common.Hash) (*gate.Access, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.live == nil {
+		return nil, ErrNotReady
+	}
+	access := &gate.Access{
+		Commitment:      commitment,
+		Block:           v.live.number,
+		Stake:           new(big.Int),
+		SubscriptionEnd: new(big.Int),
+		MinimalStake:    new(big.Int),
+	}
+	if s, ok := v.live.stakes[commitment]; ok {
+		access.Stake, access.SubscriptionEnd = s.Stake, s.SubscriptionEnd
+	}
+	if t, ok := v.live.builders[builder]; ok {
+		access.MinimalStake = t.MinimalStake
+	}
+	access.HasMinimalStake = access.MinimalStake.Sign() > 0 && access.Stake.Cmp(access.MinimalStake) >= 0
+	access.Active = active(access.SubscriptionEnd, v.live.number)
+	return access, nil
+}
+
+// Active reports whether commitment holds builder's minimal stake and an
+// active subscription at the view's head.
+func (v *View) Active(builder common.Address, commitment common.Hash) bool {
+	access, err := v.Access(builder, commitment)
+	return err == nil && access.Allowed()
+}
+
+// Checker returns a gate.StakeChecker for builder backed by the view.
+func (v *View) Checker(builder common.Address) gate.StakeChecker {
+	return checker{view: v, builder: builder}
+}
+
+type checker struct {
+	view    *View
+	builder common.Address
+}
+
+func (c checker) Check(ctx context.Context, commitment common.Hash) (*gate.Access, error) {
+	return c.view.Access(c.builder, commitment)
+}
+
+// Run builds the view and keeps it at the chain head until ctx is done.
+func (v *View) Run(ctx context.Context) error {
+	return heads.Watch(ctx, v.backend, v.cfg.PollInterval, func(head *types.Header) error {
+		if err := v.sync(ctx, head); err != nil {
+			log.Warn("Failed to update stake view", "block", head.Number, "err", err)
+		}
+		return nil
+	})
+}
+
+// sync brings the view to head, applying the new blocks if head extends the
+// view's chain and rebuilding it otherwise.
+func (v *View) sync(ctx context.Context, head *types.Header) error {
+	number, hash := head.Number.Uint64(), head.Hash()
+	live := v.live // Only Run writes live, so reading it here needs no lock.
+	switch {
+	case live == nil:
+		return v.rebuild(ctx, head)
+	case number == live.number && hash == live.hash:
+		return nil
+	case number <= live.number:
+		return v.rebuild(ctx, head)
+	}
+
+	parent := head.ParentHash
+	if number > live.number+1 {
+		h, err := v.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(live.number))
+		if err != nil {
+			return err
+		}
+		parent = h.Hash()
+	}
+	if parent != live.hash {
+		return v.rebuild(ctx, head)
+	}
+	updates, err := fetch(ctx, v.filterer, live.number+1, number, v.cfg.Step)
+	if err != nil {
+		return err
+	}
+	if !matches(updates, number, hash) {
+		return v.rebuild(ctx, head)
+	}
+	v.mu.Lock()
+	live.apply(updates)
+	live.number, live.hash = number, hash
+	v.mu.Unlock()
+	v.pending = append(v.pending, updates...)
+	return v.checkpoint(ctx)
+}
+
+// rebuild replaces the view with the state at head, replaying from the
+// checkpoint if it is still canonical and from FromBlock otherwise.
+func (v *View) rebuild(ctx context.Context, head *types.Header) error {
+	number, hash := head.Number.Uint64(), head.Hash()
+	base := v.base
+	if base != nil && base.number < number {
+		h, err := v.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(base.number))
+		if err != nil {
+			return err
+		}
+		if h.Hash() != base.hash {
+			log.Warn("Stake view checkpoint reorged, replaying from the start", "checkpoint", base.number)
+			base = nil
+		}
+	} else {
+		base = nil
+	}
+	if base == nil {
+		var err error
+		if base, err = v.bootstrap(ctx, number); err != nil {
+			return err
+		}
+	}
+	updates, err := fetch(ctx, v.filterer, base.number+1, number, v.cfg.Step)
+	if err != nil {
+		return err
+	}
+	if !matches(updates, number, hash) {
+		return errors.New("stakeview: head changed while rebuilding")
+	}
+	live := base.clone()
+	live.apply(updates)
+	live.number, live.hash = number, hash
+	v.base, v.pending = base, updates
+
+	v.mu.Lock()
+	first := v.live == nil
+	v.live = live
+	v.mu.Unlock()
+	if first {
+		close(v.ready)
+	}
+	log.Info("Rebuilt stake view", "block", number, "checkpoint", base.number, "commitments", len(live.stakes), "builders", len(live.builders))
+	return nil
+}
+
+// bootstrap replays all events up to Depth blocks behind head.
+func (v *View) bootstrap(ctx context.Context, head uint64) (*state, error) {
+	number := v.cfg.FromBlock
+	if head >= number+v.cfg.Depth {
+		number = head - v.cfg.Depth
+	}
+	if number > head {
+		number = head
+	}
+	h, err := v.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return nil, err
+	}
+	updates, err := fetch(ctx, v.filterer, v.cfg.FromBlock, number, v.cfg.Step)
+	if err != nil {
+		return nil, err
+	}
+	if !matches(updates, number, h.Hash()) {
+		return nil, errors.New("stakeview: checkpoint changed while replaying")
+	}
+	base := newState(number, h.Hash())
+	base.apply(updates)
+	return base, nil
+}
+
+// checkpoint moves the checkpoint to Depth blocks behind the head once it
+// trails by 2*Depth, keeping rebuilds short.
+func (v *View) checkpoint(ctx context.Context) error {
+	if v.live.number < v.base.number+2*v.cfg.Depth {
+		return nil
+	}
+	number := v.live.number - v.cfg.Depth
+	h, err := v.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return err
+	}
+	done, rest := split(v.pending, number)
+	if !matches(done, number, h.Hash()) {
+		// The node moved to another fork; the next head rebuilds the view.
+		return nil
+	}
+	v.base.apply(done)
+	v.base.number, v.base.hash = number, h.Hash()
+	v.pending = rest
+	return nil
+}
+
+// matches reports whether all updates of block number were read from the
+// block with hash.
+func matches(updates []update, number uint64, hash common.Hash) bool {
+	for _, u := range updates {
+		if u.block == number && u.hash != hash {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,193 @@
+package stakeview
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+var contractABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(primev.BuilderStakingMetaData.ABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// chain is a forkable chain of headers with contract logs. Logs are served
+// with the hash of the canonical block at their number.
+type chain struct {
+	headers []*types.Header
+	logs    []types.Log
+	from    []uint64 // FromBlock of every log query
+}
+
+// extend adds blocks up to number on fork.
+func (c *chain) extend(number uint64, fork string) {
+	for n := uint64(len(c.headers)); n <= number; n++ {
+		h := &types.Header{Number: new(big.Int).SetUint64(n), Extra: []byte(fork)}
+		if n > 0 {
+			h.ParentHash = c.headers[n-1].Hash()
+		}
+		c.headers = append(c.headers, h)
+	}
+}
+
+// reorg replaces the blocks from number on with a fork up to head.
+func (c *chain) reorg(number, head uint64, fork string) {
+	c.headers = c.headers[:number]
+	var logs []types.Log
+	for _, l := range c.logs {
+		if l.BlockNumber < number {
+			logs = append(logs, l)
+		}
+	}
+	c.logs = logs
+	c.extend(head, fork)
+}
+
+func (c *chain) head() *types.Header {
+	return c.headers[len(c.headers)-1]
+}
+
+func (c *chain) emit(block uint64, name string, args ...interface{}) {
+	ev := contractABI.Events[name]
+	data, err := ev.Inputs.Pack(args...)
+	if err != nil {
+		panic(err)
+	}
+	c.logs = append(c.logs, types.Log{
+		Topics:      []common.Hash{ev.ID},
+		Data:        data,
+		BlockNumber: block,
+		Index:       uint(len(c.logs)),
+	})
+}
+
+func (c *chain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	c.from = append(c.from, q.FromBlock.Uint64())
+	var logs []types.Log
+	for _, l := range c.logs {
+		if l.Topics[0] != q.Topics[0][0] || l.BlockNumber < q.FromBlock.Uint64() || l.BlockNumber > q.ToBlock.Uint64() {
+			continue
+		}
+		l.BlockHash = c.headers[l.BlockNumber].Hash()
+		logs = append(logs, l)
+	}
+	return logs, nil
+}
+
+func (c *chain) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	}), nil
+}
+
+func (c *chain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number == nil {
+		return c.head(), nil
+	}
+	if number.Uint64() >= uint64(len(c.headers)) {
+		return nil, errors.New("header not found")
+	}
+	return c.headers[number.Uint64()], nil
+}
+
+// replayedFrom returns the lowest block queried since the last call.
+func (c *chain) replayedFrom() uint64 {
+	min := ^uint64(0)
+	for _, n := range c.from {
+		if n < min {
+			min = n
+		}
+	}
+	c.from = nil
+	return min
+}
+
+var (
+	builder    = common.HexToAddress("0xb")
+	commitment = common.HexToHash("0x01")
+)
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	c := &chain{}
+	c.extend(10, "a")
+	c.emit(1, "BuilderUpdated", builder, big.NewInt(10), big.NewInt(100))
+	c.emit(2, "StakeUpdated", builder, commitment, big.NewInt(10), big.NewInt(100))
+
+	v, err := New(common.Address{}, c, Config{Step: 3, Depth: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Access(builder, commitment); err != ErrNotReady {
+		t.Errorf("access before build: %v, want %v", err, ErrNotReady)
+	}
+
+	// check syncs to the chain head and compares the view with the
+	// expectations.
+	check := func(step string, stake int64, active bool, checkpoint, replayedFrom uint64) {
+		t.Helper()
+		if err := v.sync(ctx, c.head()); err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+		if number, hash := v.Head(); number != c.head().Number.Uint64() || hash != c.head().Hash() {
+			t.Errorf("%s: view at %d %s, want head %d", step, number, hash, c.head().Number)
+		}
+		if s, ok := v.Stake(commitment); !ok || s.Stake.Int64() != stake {
+			t.Errorf("%s: stake %v, want %d", step, s.Stake, stake)
+		}
+		if v.Active(builder, commitment) != active {
+			t.Errorf("%s: active %v, want %v", step, !active, active)
+		}
+		if v.base.number != checkpoint || v.base.hash != c.headers[checkpoint].Hash() {
+			t.Errorf("%s: checkpoint %d, want %d", step, v.base.number, checkpoint)
+		}
+		if from := c.replayedFrom(); from != replayedFrom {
+			t.Errorf("%s: replayed from %d, want %d", step, from, replayedFrom)
+		}
+	}
+
+	check("bootstrap", 10, true, 6, 0)
+	select {
+	case <-v.Ready():
+	default:
+		t.Error("not ready after bootstrap")
+	}
+
+	// Extending the chain applies only the new blocks.
+	c.extend(11, "a")
+	c.emit(11, "StakeUpdated", builder, commitment, big.NewInt(5), big.NewInt(100))
+	check("extension", 5, false, 6, 11)
+
+	// Skipping blocks still applies them, and moves the checkpoint once it
+	// trails by twice the depth.
+	c.extend(14, "a")
+	c.emit(13, "StakeUpdated", builder, commitment, big.NewInt(15), big.NewInt(100))
+	check("skipped blocks", 15, true, 10, 12)
+
+	// A reorg above the checkpoint replays from there.
+	c.reorg(13, 14, "b")
+	c.emit(14, "StakeUpdated", builder, commitment, big.NewInt(20), big.NewInt(100))
+	check("short reorg", 20, true, 10, 11)
+
+	// A reorg below the checkpoint replays from the start, dropping the
+	// updates of the abandoned fork.
+	c.reorg(8, 16, "c")
+	c.emit(9, "StakeUpdated", builder, commitment, big.NewInt(30), big.NewInt(12))
+	check("deep reorg", 30, false, 12, 0)
+	if s, _ := v.Stake(commitment); s.Block != 9 {
+		t.Errorf("deep reorg: stake from block %d, want 9", s.Block)
+	}
+}
@@ -0,0 +1,152 @@
+// Package terms validates builder terms before they are set with
+// UpdateBuilder, since the contract accepts any values.
+package terms
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/primevprotocol/primev-contracts/pkg/stakemath"
+)
+
+// Severity classifies an Issue.
+type Severity int
+
+const (
+	// Warning marks terms that work but lose value to rounding.
+	Warning Severity = iota
+	// Error marks terms that make deposits revert or buy nothing.
+	Error
+)
+
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// DefaultSampleMultiples are the deposit sizes, as multiples of the minimal
+// stake, reported when no explicit samples are given.
+var DefaultSampleMultiples = []int64{1, 2, 5, 10}
+
+// MaxRoundingLoss is the fraction of paid blocks lost to rounding above which
+// a sample is reported as a warning.
+var MaxRoundingLoss = big.NewRat(1, 100)
+
+// Terms are the values passed to UpdateBuilder.
+type Terms struct {
+	MinimalStake              *big.Int
+	MinimalSubscriptionPeriod *big.Int
+}
+
+// Issue is a single problem found in Terms.
+type Issue struct {
+	Severity Severity
+	Message  string
+}
+
+func (i Issue) String() string {
+	return i.Severity.String() + ": " + i.Message
+}
+
+// Sample shows what a deposit of a given size buys under Terms.
+type Sample struct {
+	Deposit     *big.Int // Deposit in wei
+	Blocks      *big.Int // Blocks bought, as computed by the contract
+	ExactBlocks *big.Rat // Blocks the deposit would buy without rounding
+	LostBlocks  *big.Rat // ExactBlocks minus Blocks
+	LostWei     *big.Rat // Value of LostBlocks at the nominal price
+	PricePaid   *big.Rat // Wei per block actually paid, nil if Blocks is zero
+}
+
+// Report is the result of validating Terms.
+type Report struct {
+	Terms          Terms
+	PricePerBlock  *big.Rat // Nominal wei per block, nil if the period is zero
+	PeriodPerStake *big.Int // Contract's scaled blocks per wei of stake
+	Samples        []Sample
+	Issues         []Issue
+}
+
+// HasErrors reports whether any issue has Error severity.
+func (r *Report) HasErrors() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Report) add(severity Severity, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, Issue{Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks terms for the pathologies of the contract arithmetic and
+// computes what deposits of the given sizes would buy. Deposits smaller than
+// the minimal stake are ignored; DefaultSampleMultiples are used if none are
+// given.
+func Validate(terms Terms, deposits ...*big.Int) *Report {
+	r := &Report{Terms: terms}
+	stake, period := terms.MinimalStake, terms.MinimalSubscriptionPeriod
+	if stake == nil || stake.Sign() <= 0 {
+		r.add(Error, "minimal stake is zero, every deposit reverts with \"Builder minimal stake is not set\"")
+	}
+	if period == nil || period.Sign() <= 0 {
+		r.add(Error, "minimal subscription period is zero, every deposit reverts with \"Builder minimal subscription period is not set\"")
+	}
+	if r.HasErrors() {
+		return r
+	}
+
+	r.PricePerBlock = new(big.Rat).SetFrac(stake, period)
+	r.PeriodPerStake = stakemath.PeriodPerStake(stake, period)
+	if r.PeriodPerStake.Sign() == 0 {
+		r.add(Error, "minimal subscription period * 1e18 < minimal stake, deposits buy zero blocks")
+	}
+	if stakemath.BuilderAmount(stake).Sign() == 0 {
+		r.add(Error, "builder share of a minimal stake deposit is zero, deposits revert with \"Amount should be positive\"")
+	}
+
+	if len(deposits) == 0 {
+		for _, m := range DefaultSampleMultiples {
+			deposits = append(deposits, new(big.Int).Mul(stake, big.NewInt(m)))
+		}
+	}
+	for _, deposit := range deposits {
+		if deposit.Cmp(stake) < 0 {
+			continue
+		}
+		s := sample(terms, r.PricePerBlock, deposit)
+		r.Samples = append(r.Samples, s)
+		if s.Blocks.Sign() == 0 {
+			if r.PeriodPerStake.Sign() != 0 {
+				r.add(Error, "deposit of %v wei buys zero blocks", deposit)
+			}
+			continue
+		}
+		if new(big.Rat).Quo(s.LostBlocks, s.ExactBlocks).Cmp(MaxRoundingLoss) > 0 {
+			r.add(Warning, "deposit of %v wei loses %s of %s blocks (%s wei) to rounding",
+				deposit, s.LostBlocks.FloatString(2), s.ExactBlocks.FloatString(2), s.LostWei.FloatString(0))
+		}
+	}
+	return r
+}
+
+func sample(terms Terms, price *big.Rat, deposit *big.Int) Sample {
+	blocks := stakemath.SubscriptionPeriod(terms.MinimalStake, terms.MinimalSubscriptionPeriod, deposit)
+	exact := new(big.Rat).SetFrac(new(big.Int).Mul(deposit, terms.MinimalSubscriptionPeriod), terms.MinimalStake)
+	lost := new(big.Rat).Sub(exact, new(big.Rat).SetInt(blocks))
+	s := Sample{
+		Deposit:     deposit,
+		Blocks:      blocks,
+		ExactBlocks: exact,
+		LostBlocks:  lost,
+		LostWei:     new(big.Rat).Mul(lost, price),
+	}
+	if blocks.Sign() > 0 {
+		s.PricePaid = new(big.Rat).SetFrac(deposit, blocks)
+	}
+	return s
+}
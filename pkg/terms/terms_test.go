@@ -0,0 +1,68 @@
+package terms
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		stake, period int64
+		errors        []string // Substrings of the expected errors
+		warnings      int
+	}{
+		{name: "round terms", stake: 1e18, period: 100},
+		{name: "zero stake", stake: 0, period: 100, errors: []string{"minimal stake is zero"}},
+		{name: "zero period", stake: 1e18, period: 0, errors: []string{"minimal subscription period is zero"}},
+		{name: "zero period per stake", stake: 2e18, period: 1, errors: []string{"buy zero blocks"}},
+		{name: "zero builder share", stake: 1, period: 100, errors: []string{"builder share"}},
+		// 10e18 / 3 truncates: 3 wei buy 9 of 10 blocks, 6 wei 19 of 20,
+		// 15 wei 49 of 50 and 30 wei 99 of 100.
+		{name: "rounding loss", stake: 3, period: 10, warnings: 3},
+	}
+	for _, tt := range tests {
+		r := Validate(Terms{MinimalStake: big.NewInt(tt.stake), MinimalSubscriptionPeriod: big.NewInt(tt.period)})
+		var errs []string
+		warnings := 0
+		for _, issue := range r.Issues {
+			if issue.Severity == Error {
+				errs = append(errs, issue.Message)
+			} else {
+				warnings++
+			}
+		}
+		if len(errs) != len(tt.errors) || warnings != tt.warnings {
+			t.Errorf("%s: issues %v, want %d errors and %d warnings", tt.name, r.Issues, len(tt.errors), tt.warnings)
+			continue
+		}
+		for i, want := range tt.errors {
+			if !strings.Contains(errs[i], want) {
+				t.Errorf("%s: error %q, want %q", tt.name, errs[i], want)
+			}
+		}
+	}
+}
+
+func TestValidateSamples(t *testing.T) {
+	r := Validate(Terms{MinimalStake: big.NewInt(3), MinimalSubscriptionPeriod: big.NewInt(10)}, big.NewInt(2), big.NewInt(3), big.NewInt(4))
+	want := []struct {
+		deposit, blocks int64
+		lost            string
+	}{
+		// The 2 wei sample is below the minimal stake and skipped.
+		{3, 9, "1"},
+		{4, 13, "1/3"},
+	}
+	if len(r.Samples) != len(want) {
+		t.Fatalf("samples = %+v", r.Samples)
+	}
+	for i, w := range want {
+		s := r.Samples[i]
+		if s.Deposit.Int64() != w.deposit || s.Blocks.Int64() != w.blocks || s.LostBlocks.RatString() != w.lost {
+			t.Errorf("sample %d: %v wei buys %v blocks losing %s, want %d, %d, %s",
+				i, s.Deposit, s.Blocks, s.LostBlocks.RatString(), w.deposit, w.blocks, w.lost)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package terms
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BuilderUpdater sends UpdateBuilder transactions. It is implemented by the
+// generated BuilderStakingTransactor and by fees.Transactor.
+type BuilderUpdater interface {
+	UpdateBuilder(opts *bind.TransactOpts, minimalStake *big.Int, minimalSubscriptionPeriod *big.Int) (*types.Transaction, error)
+}
+
+// InvalidTermsError is returned by UpdateBuilder when validation fails.
+type InvalidTermsError struct {
+	Report *Report
+}
+
+func (e *InvalidTermsError) Error() string {
+	for _, issue := range e.Report.Issues {
+		if issue.Severity == Error {
+			return fmt.Sprintf("terms: invalid builder terms: %s", issue.Message)
+		}
+	}
+	return "terms: invalid builder terms"
+}
+
+// UpdateBuilder validates terms and only sends the transaction if no issue
+// has Error severity. The report is returned in either case so callers can
+// show prices and rounding losses.
+func UpdateBuilder(updater BuilderUpdater, opts *bind.TransactOpts, terms Terms, deposits ...*big.Int) (*types.Transaction, *Report, error) {
+	report := Validate(terms, deposits...)
+	if report.HasErrors() {
+		return nil, report, &InvalidTermsError{Report: report}
+	}
+	tx, err := updater.UpdateBuilder(opts, terms.MinimalStake, terms.MinimalSubscriptionPeriod)
+	return tx, report, err
+}
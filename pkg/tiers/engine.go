@@ -0,0 +1,203 @@
+// Package tiers maps a commitment's stake to service levels: request rate
+// limits, maximum bundle size and simulation priority, configured in YAML as
+// multiples of the builder's minimal stake.
+package tiers
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/primevprotocol/primev-contracts/pkg/events"
+	"github.com/primevprotocol/primev-contracts/pkg/gate"
+	"github.com/primevprotocol/primev-contracts/pkg/heads"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+	"golang.org/x/time/rate"
+)
+
+// idleTimeout is how long an unused commitment's limiter is kept.
+const idleTimeout = 10 * time.Minute
+
+type entry struct {
+	stake           *big.Int
+	subscriptionEnd *big.Int
+	tier            *Tier
+	limiter         *rate.Limiter
+	lastUsed        time.Time
+}
+
+// Engine evaluates commitments against a Policy and keeps a token-bucket
+// limiter per commitment. Limiters keep their tokens when a commitment moves
+// between tiers; only rate and burst change.
+type Engine struct {
+	policy  *Policy
+	builder common.Address
+	checker gate.StakeChecker
+
+	mu           sync.Mutex
+	head         uint64
+	minimalStake *big.Int
+	entries      map[common.Hash]*entry
+}
+
+// NewEngine creates an Engine for builder, looking up unknown commitments
+// with checker.
+func NewEngine(policy *Policy, builder common.Address, checker gate.StakeChecker) *Engine {
+	return &Engine{
+		policy:  policy,
+		builder: builder,
+		checker: checker,
+		entries: make(map[common.Hash]*entry),
+	}
+}
+
+// Tier returns the current tier of commitment.
+func (e *Engine) Tier(ctx context.Context, commitment common.Hash) (*Tier, error) {
+	tier, _, err := e.entry(ctx, commitment)
+	return tier, err
+}
+
+// Limiter returns the rate limiter of commitment.
+func (e *Engine) Limiter(ctx context.Context, commitment common.Hash) (*rate.Limiter, error) {
+	_, limiter, err := e.entry(ctx, commitment)
+	return limiter, err
+}
+
+// Allow takes a token from commitment's limiter and returns its tier.
+func (e *Engine) Allow(ctx context.Context, commitment common.Hash) (bool, *Tier, error) {
+	tier, limiter, err := e.entry(ctx, commitment)
+	if err != nil {
+		return false, nil, err
+	}
+	return limiter.Allow(), tier, nil
+}
+
+// entry returns the tier and limiter of commitment, looking it up first if it
+// is unknown. Both are read under e.mu since evaluate may change them.
+func (e *Engine) entry(ctx context.Context, commitment common.Hash) (*Tier, *rate.Limiter, error) {
+	e.mu.Lock()
+	if en, ok := e.entries[commitment]; ok {
+		en.lastUsed = time.Now()
+		defer e.mu.Unlock()
+		return en.tier, en.limiter, nil
+	}
+	e.mu.Unlock()
+
+	access, err := e.checker.Check(ctx, commitment)
+	if err != nil {
+		return nil, nil, err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if en, ok := e.entries[commitment]; ok {
+		return en.tier, en.limiter, nil
+	}
+	if access.Block > e.head {
+		e.head = access.Block
+	}
+	if e.minimalStake == nil {
+		e.minimalStake = access.MinimalStake
+	}
+	en := &entry{
+		stake:           access.Stake,
+		subscriptionEnd: access.SubscriptionEnd,
+		lastUsed:        time.Now(),
+	}
+	en.tier = e.policy.Select(en.stake, e.minimalStake, e.active(en))
+	en.limiter = rate.NewLimiter(rate.Limit(en.tier.Rate), en.tier.Burst)
+	e.entries[commitment] = en
+	return en.tier, en.limiter, nil
+}
+
+// evaluate selects the tier of en and adjusts its limiter.
+func (e *Engine) evaluate(commitment common.Hash, en *entry) {
+	tier := e.policy.Select(en.stake, e.minimalStake, e.active(en))
+	if tier == en.tier {
+		return
+	}
+	if en.tier != nil {
+		log.Debug("Commitment changed tier", "commitment", commitment, "from", en.tier.Name, "to", tier.Name)
+	}
+	en.tier = tier
+	en.limiter.SetLimit(rate.Limit(tier.Rate))
+	en.limiter.SetBurst(tier.Burst)
+}
+
+func (e *Engine) active(en *entry) bool {
+	return en.subscriptionEnd.Cmp(new(big.Int).SetUint64(e.head)) > 0
+}
+
+// UpdateStake applies a StakeUpdated event.
+func (e *Engine) UpdateStake(commitment common.Hash, stake, subscriptionEnd *big.Int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if en, ok := e.entries[commitment]; ok {
+		en.stake, en.subscriptionEnd = stake, subscriptionEnd
+		e.evaluate(commitment, en)
+	}
+}
+
+// UpdateTerms applies a BuilderUpdated event of the engine's builder.
+func (e *Engine) UpdateTerms(minimalStake *big.Int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.minimalStake = minimalStake
+	for c, en := range e.entries {
+		e.evaluate(c, en)
+	}
+}
+
+// SetHead re-evaluates every commitment at block, downgrading expired
+// subscriptions, and drops limiters that have not been used for a while.
+func (e *Engine) SetHead(block uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if block > e.head {
+		e.head = block
+	}
+	now := time.Now()
+	for c, en := range e.entries {
+		if now.Sub(en.lastUsed) > idleTimeout {
+			delete(e.entries, c)
+			continue
+		}
+		e.evaluate(c, en)
+	}
+}
+
+// Follow applies StakeUpdated and BuilderUpdated events and new heads until
+// ctx is done.
+func (e *Engine) Follow(ctx context.Context, reader heads.Reader, filterer *primev.BuilderStakingFilterer, interval time.Duration) error {
+	var last uint64
+	return heads.Watch(ctx, reader, interval, func(head *types.Header) error {
+		number := head.Number.Uint64()
+		from := last + 1
+		if last == 0 || from > number {
+			from = number
+		}
+		rng := events.Range{From: from, To: number}
+		err := events.BuilderUpdated(ctx, filterer, rng, func(ev *primev.BuilderStakingBuilderUpdated) error {
+			if ev.Builder == e.builder {
+				e.UpdateTerms(ev.MinimalStake)
+			}
+			return nil
+		})
+		if err == nil {
+			err = events.StakeUpdated(ctx, filterer, rng, func(ev *primev.BuilderStakingStakeUpdated) error {
+				e.UpdateStake(ev.Commitment, ev.Stake, ev.SubscriptionEnd)
+				return nil
+			})
+		}
+		if err != nil {
+			log.Warn("Failed to read stake updates", "block", number, "err", err)
+			return nil
+		}
+		e.SetHead(number)
+		last = number
+		return nil
+	})
+}
@@ -0,0 +1,112 @@
+package tiers
+
+import (
+	"context"
+	"math/big"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/primevprotocol/primev-contracts/pkg/gate"
+)
+
+const testPolicy = `
+default:
+  name: none
+tiers:
+  - name: pro
+    minMultiple: 10
+    rate: 20
+    burst: 40
+  - name: basic
+    minMultiple: 1
+    rate: 1
+    burst: 2
+`
+
+type fixedChecker struct{ stake, end int64 }
+
+func (c fixedChecker) Check(ctx context.Context, commitment common.Hash) (*gate.Access, error) {
+	return &gate.Access{
+		Commitment:      commitment,
+		Block:           100,
+		Stake:           big.NewInt(c.stake),
+		SubscriptionEnd: big.NewInt(c.end),
+		MinimalStake:    big.NewInt(10),
+	}, nil
+}
+
+func newTestEngine(t *testing.T, stake, end int64) *Engine {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewEngine(policy, common.Address{}, fixedChecker{stake, end})
+}
+
+func TestEngineTierChanges(t *testing.T) {
+	ctx := context.Background()
+	c := common.HexToHash("0x01")
+	e := newTestEngine(t, 10, 200)
+
+	for i := 0; i < 2; i++ {
+		if ok, tier, err := e.Allow(ctx, c); err != nil || !ok || tier.Name != "basic" {
+			t.Fatalf("request %d: allowed %v in tier %v, %v", i, ok, tier, err)
+		}
+	}
+	if ok, _, _ := e.Allow(ctx, c); ok {
+		t.Fatal("basic tier allowed more than its burst")
+	}
+
+	e.UpdateStake(c, big.NewInt(100), big.NewInt(200))
+	if tier, _ := e.Tier(ctx, c); tier.Name != "pro" {
+		t.Fatalf("tier after deposit = %s", tier.Name)
+	}
+	e.UpdateTerms(big.NewInt(50))
+	if tier, _ := e.Tier(ctx, c); tier.Name != "basic" {
+		t.Fatalf("tier after raised minimal stake = %s", tier.Name)
+	}
+	e.SetHead(200)
+	if tier, _ := e.Tier(ctx, c); tier.Name != "none" {
+		t.Fatalf("tier after expiry = %s", tier.Name)
+	}
+	if ok, _, _ := e.Allow(ctx, c); ok {
+		t.Fatal("expired commitment was allowed")
+	}
+}
+
+// TestEngineConcurrentUpdates is meant for go test -race: Allow reads the
+// tier and limiter while SetHead and UpdateStake change them.
+func TestEngineConcurrentUpdates(t *testing.T) {
+	ctx := context.Background()
+	commitments := []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02"), common.HexToHash("0x03")}
+	e := newTestEngine(t, 100, 1000)
+	for _, c := range commitments {
+		e.Tier(ctx, c)
+	}
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for _, c := range commitments {
+		wg.Add(1)
+		go func(c common.Hash) {
+			defer wg.Done()
+			<-start
+			for i := 0; i < 300; i++ {
+				if _, tier, err := e.Allow(ctx, c); err != nil || tier == nil {
+					t.Errorf("Allow: %v, %v", tier, err)
+					return
+				}
+				runtime.Gosched()
+			}
+		}(c)
+	}
+	close(start)
+	for i := 0; i < 300; i++ {
+		e.SetHead(uint64(100 + i))
+		e.UpdateStake(commitments[i%len(commitments)], big.NewInt(int64(10+90*(i%2))), big.NewInt(1000))
+		runtime.Gosched()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,109 @@
+package tiers
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Tier is a service level.
+type Tier struct {
+	Name               string  `yaml:"name"`
+	MinMultiple        float64 `yaml:"minMultiple"`        // Lowest stake for the tier, in multiples of the builder's minimal stake
+	Rate               float64 `yaml:"rate"`               // Requests per second
+	Burst              int     `yaml:"burst"`              // Requests allowed at once
+	MaxBundleSize      int     `yaml:"maxBundleSize"`      // Transactions per bundle
+	SimulationPriority int     `yaml:"simulationPriority"` // Higher simulates first
+}
+
+// Policy maps stake ranges to tiers. A commitment gets the tier with the
+// highest MinMultiple its stake reaches while its subscription is active,
+// and Default otherwise.
+//
+//	default:
+//	  name: none
+//	tiers:
+//	  - name: basic
+//	    minMultiple: 1
+//	    rate: 2
+//	    burst: 5
+//	    maxBundleSize: 4
+//	    simulationPriority: 1
+//	  - name: pro
+//	    minMultiple: 10
+//	    rate: 20
+//	    burst: 40
+//	    maxBundleSize: 16
+//	    simulationPriority: 5
+type Policy struct {
+	Default Tier   `yaml:"default"`
+	Tiers   []Tier `yaml:"tiers"`
+}
+
+// LoadPolicy reads a YAML policy from path.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePolicy(data)
+}
+
+// ParsePolicy parses and validates a YAML policy.
+func ParsePolicy(data []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate checks p and sorts its tiers by MinMultiple.
+func (p *Policy) Validate() error {
+	if len(p.Tiers) == 0 {
+		return errors.New("tiers: policy has no tiers")
+	}
+	names := map[string]bool{p.Default.Name: true}
+	for _, t := range append([]Tier{p.Default}, p.Tiers...) {
+		if t.Rate < 0 || t.Burst < 0 || t.MaxBundleSize < 0 {
+			return fmt.Errorf("tiers: tier %q has negative limits", t.Name)
+		}
+		if t.Rate > 0 && t.Burst == 0 {
+			return fmt.Errorf("tiers: tier %q has a rate but no burst", t.Name)
+		}
+	}
+	for _, t := range p.Tiers {
+		if t.Name == "" || names[t.Name] {
+			return fmt.Errorf("tiers: tier name %q is empty or duplicate", t.Name)
+		}
+		names[t.Name] = true
+		if t.MinMultiple <= 0 {
+			return fmt.Errorf("tiers: tier %q needs a positive minMultiple", t.Name)
+		}
+	}
+	sort.Slice(p.Tiers, func(i, j int) bool { return p.Tiers[i].MinMultiple < p.Tiers[j].MinMultiple })
+	return nil
+}
+
+// Select returns the tier for stake under the builder's minimalStake.
+func (p *Policy) Select(stake, minimalStake *big.Int, active bool) *Tier {
+	if !active || minimalStake.Sign() == 0 {
+		return &p.Default
+	}
+	selected := &p.Default
+	for i := range p.Tiers {
+		threshold := new(big.Rat).Mul(new(big.Rat).SetInt(minimalStake), new(big.Rat).SetFloat64(p.Tiers[i].MinMultiple))
+		if new(big.Rat).SetInt(stake).Cmp(threshold) < 0 {
+			break
+		}
+		selected = &p.Tiers[i]
+	}
+	return selected
+}
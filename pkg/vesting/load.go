@@ -0,0 +1,28 @@
+package vesting
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+	"github.com/primevprotocol/primev-contracts/pkg/stakemath"
+)
+
+// LoadTimeLocks reads all time locks of account in array order. Set
+// opts.BlockNumber to read a consistent set.
+func LoadTimeLocks(opts *bind.CallOpts, caller *primev.BuilderStakingCaller, account common.Address) ([]stakemath.TimeLock, error) {
+	count, err := caller.TimeLocksCount(opts, account)
+	if err != nil {
+		return nil, err
+	}
+	locks := make([]stakemath.TimeLock, 0, count.Uint64())
+	for i := uint64(0); i < count.Uint64(); i++ {
+		lock, err := caller.TimeLocks(opts, account, new(big.Int).SetUint64(i))
+		if err != nil {
+			return nil, err
+		}
+		locks = append(locks, stakemath.TimeLock(lock))
+	}
+	return locks, nil
+}
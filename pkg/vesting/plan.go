@@ -0,0 +1,193 @@
+// Package vesting predicts what Withdraw pays out.
+//
+// withdraw removes emptied time locks by moving the last lock into the
+// current index and popping the array, after which the loop moves on to the
+// next index. The lock moved into the current slot is therefore skipped for
+// the rest of that call, which is why a single withdraw can pay less than
+// WithdrawableAmount reported.
+//
+// In addition, the releasable amount of a lock is computed from its initial
+// amount and only capped by what remains, so it does not account for earlier
+// withdrawals from the same lock.
+package vesting
+
+import (
+	"math/big"
+
+	"github.com/primevprotocol/primev-contracts/pkg/stakemath"
+)
+
+// Action is what a withdraw call does to a time lock.
+type Action int
+
+const (
+	// Idle means the lock was visited but nothing was releasable.
+	Idle Action = iota
+	// Partial means part of the lock was released and it stays in the array.
+	Partial
+	// Drained means the lock was emptied and removed from the array.
+	Drained
+	// Skipped means the lock was moved into an already visited index and
+	// not looked at in this call.
+	Skipped
+)
+
+func (a Action) String() string {
+	switch a {
+	case Partial:
+		return "partial"
+	case Drained:
+		return "drained"
+	case Skipped:
+		return "skipped"
+	default:
+		return "idle"
+	}
+}
+
+// Outcome describes what a single withdraw call does to one time lock.
+type Outcome struct {
+	ID        int // Index of the lock when planning started
+	Action    Action
+	Released  *big.Int // Amount paid out from this lock
+	Remaining *big.Int // Remaining amount after the call
+}
+
+// Call is the predicted effect of a single withdraw call.
+type Call struct {
+	Total    *big.Int  // Amount paid out, zero means the call reverts with "Nothing to withdraw"
+	Outcomes []Outcome // One entry per lock present before the call, in array order
+	After    []int     // IDs of the locks left, in array order
+}
+
+// Reverts reports whether the call reverts with "Nothing to withdraw".
+func (c *Call) Reverts() bool {
+	return c.Total.Sign() == 0
+}
+
+// Plan is the sequence of withdraw calls needed to collect everything vested
+// at a timestamp.
+type Plan struct {
+	Timestamp    *big.Int
+	Withdrawable *big.Int // What WithdrawableAmount reports at Timestamp
+	Calls        []Call   // Calls that pay out, in order
+	Total        *big.Int // Sum paid by Calls
+}
+
+type entry struct {
+	id   int
+	lock stakemath.TimeLock
+}
+
+// Simulate predicts a single withdraw call at timestamp on locks, which are
+// not modified. IDs in the result are indexes into locks.
+func Simulate(locks []stakemath.TimeLock, timestamp *big.Int) Call {
+	entries := make([]entry, len(locks))
+	for i, l := range locks {
+		entries[i] = entry{id: i, lock: copyLock(l)}
+	}
+	call, _ := simulate(entries, timestamp)
+	return call
+}
+
+// Withdrawable mirrors withdrawableAmount: the sum of what every lock could
+// release at timestamp, ignoring the removal quirk.
+func Withdrawable(locks []stakemath.TimeLock, timestamp *big.Int) *big.Int {
+	total := new(big.Int)
+	for _, l := range locks {
+		total.Add(total, stakemath.Releasable(l, timestamp))
+	}
+	return total
+}
+
+// NewPlan predicts the withdraw calls, all executed at timestamp, needed until
+// every lock has been visited once, i.e. until everything WithdrawableAmount
+// reported has been collected. Later calls happen at later timestamps in
+// practice and may pay slightly more.
+//
+// Releasable amounts are computed from the initial amount, so a lock left
+// partially vested by one call releases its vested amount again in the next
+// one; Total can therefore exceed Withdrawable.
+func NewPlan(locks []stakemath.TimeLock, timestamp *big.Int) *Plan {
+	plan := &Plan{
+		Timestamp:    new(big.Int).Set(timestamp),
+		Withdrawable: Withdrawable(locks, timestamp),
+		Total:        new(big.Int),
+	}
+	entries := make([]entry, len(locks))
+	unvisited := make(map[int]bool, len(locks))
+	for i, l := range locks {
+		entries[i] = entry{id: i, lock: copyLock(l)}
+		unvisited[i] = true
+	}
+	for len(unvisited) > 0 {
+		call, next := simulate(entries, timestamp)
+		if call.Reverts() {
+			break
+		}
+		plan.Calls = append(plan.Calls, call)
+		plan.Total.Add(plan.Total, call.Total)
+		for _, out := range call.Outcomes {
+			if out.Action != Skipped {
+				delete(unvisited, out.ID)
+			}
+		}
+		entries = next
+	}
+	return plan
+}
+
+// simulate runs the withdraw loop over entries exactly as the contract does
+// and returns the resulting array.
+func simulate(entries []entry, timestamp *big.Int) (Call, []entry) {
+	locks := append([]entry(nil), entries...)
+	outcomes := make(map[int]*Outcome, len(locks))
+	for _, e := range locks {
+		outcomes[e.id] = &Outcome{ID: e.id, Action: Skipped, Released: new(big.Int), Remaining: e.lock.RemainingAmount}
+	}
+
+	total := new(big.Int)
+	for i := 0; i < len(locks); i++ {
+		e := &locks[i]
+		out := outcomes[e.id]
+		released := stakemath.Releasable(e.lock, timestamp)
+		out.Action = Idle
+		if released.Sign() > 0 {
+			e.lock.RemainingAmount = new(big.Int).Sub(e.lock.RemainingAmount, released)
+			total.Add(total, released)
+			out.Released = released
+			out.Action = Partial
+		}
+		out.Remaining = e.lock.RemainingAmount
+		if e.lock.RemainingAmount.Sign() == 0 {
+			out.Action = Drained
+			locks[i] = locks[len(locks)-1]
+			locks = locks[:len(locks)-1]
+		}
+	}
+
+	call := Call{Total: total}
+	for _, e := range entries {
+		call.Outcomes = append(call.Outcomes, *outcomes[e.id])
+	}
+	if total.Sign() == 0 {
+		// The contract reverts, so no state change happens.
+		for i := range call.Outcomes {
+			call.Outcomes[i].Remaining = entries[i].lock.RemainingAmount
+		}
+		locks = entries
+	}
+	for _, e := range locks {
+		call.After = append(call.After, e.id)
+	}
+	return call, locks
+}
+
+func copyLock(l stakemath.TimeLock) stakemath.TimeLock {
+	return stakemath.TimeLock{
+		InitialAmount:   new(big.Int).Set(l.InitialAmount),
+		RemainingAmount: new(big.Int).Set(l.RemainingAmount),
+		StartTime:       new(big.Int).Set(l.StartTime),
+		LockDuration:    new(big.Int).Set(l.LockDuration),
+	}
+}
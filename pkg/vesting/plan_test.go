@@ -0,0 +1,128 @@
+package vesting
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/primevprotocol/primev-contracts/pkg/stakemath"
+)
+
+// lock builds a time lock of initial wei with remaining wei left, vesting over
+// duration seconds from start.
+func lock(initial, remaining, start, duration int64) stakemath.TimeLock {
+	return stakemath.TimeLock{
+		InitialAmount:   big.NewInt(initial),
+		RemainingAmount: big.NewInt(remaining),
+		StartTime:       big.NewInt(start),
+		LockDuration:    big.NewInt(duration),
+	}
+}
+
+type expectedCall struct {
+	total   int64
+	actions []Action // Per lock present before the call, in array order
+	after   []int
+}
+
+// The expectations follow withdraw: an emptied lock at index i is replaced
+// by the last lock, which the loop then skips by moving on to i+1.
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name         string
+		locks        []stakemath.TimeLock
+		withdrawable int64
+		calls        []expectedCall
+	}{
+		{
+			name:         "drained locks skip the moved ones",
+			locks:        []stakemath.TimeLock{lock(100, 100, 0, 10), lock(200, 200, 0, 10), lock(300, 300, 0, 10)},
+			withdrawable: 600,
+			calls: []expectedCall{
+				// Lock 2 moves to index 0 after lock 0 drains.
+				{300, []Action{Drained, Drained, Skipped}, []int{2}},
+				{300, []Action{Drained}, nil},
+			},
+		},
+		{
+			name:         "partially vested lock",
+			locks:        []stakemath.TimeLock{lock(100, 100, 0, 10), lock(200, 200, 15, 10), lock(300, 300, 0, 10)},
+			withdrawable: 500,
+			calls: []expectedCall{
+				{200, []Action{Drained, Partial, Skipped}, []int{2, 1}},
+				// Lock 1 moves to index 0 once lock 2 drains and is
+				// skipped. Every lock has been visited, so the plan ends.
+				{300, []Action{Drained, Skipped}, []int{1}},
+			},
+		},
+		{
+			name:         "lock not started yet",
+			locks:        []stakemath.TimeLock{lock(100, 100, 30, 10), lock(50, 50, 0, 10)},
+			withdrawable: 50,
+			calls: []expectedCall{
+				{50, []Action{Idle, Drained}, []int{0}},
+			},
+		},
+		{
+			// Vested is computed from the initial amount: 50 of 100 are
+			// vested, but only the remaining 40 are paid.
+			name:         "release capped by remaining",
+			locks:        []stakemath.TimeLock{lock(100, 40, 15, 10)},
+			withdrawable: 40,
+			calls: []expectedCall{
+				{40, []Action{Drained}, nil},
+			},
+		},
+		{
+			name:         "nothing vested",
+			locks:        []stakemath.TimeLock{lock(100, 100, 20, 10)},
+			withdrawable: 0,
+		},
+	}
+	now := big.NewInt(20)
+	for _, tt := range tests {
+		plan := NewPlan(tt.locks, now)
+		if plan.Withdrawable.Int64() != tt.withdrawable {
+			t.Errorf("%s: withdrawable %v, want %d", tt.name, plan.Withdrawable, tt.withdrawable)
+		}
+		if len(plan.Calls) != len(tt.calls) {
+			t.Errorf("%s: %d calls, want %d", tt.name, len(plan.Calls), len(tt.calls))
+			continue
+		}
+		var total int64
+		for i, want := range tt.calls {
+			call := plan.Calls[i]
+			var actions []Action
+			for _, out := range call.Outcomes {
+				actions = append(actions, out.Action)
+			}
+			if call.Total.Int64() != want.total || !reflect.DeepEqual(actions, want.actions) || !reflect.DeepEqual(call.After, want.after) {
+				t.Errorf("%s: call %d pays %v with %v leaving %v, want %d with %v leaving %v",
+					tt.name, i, call.Total, actions, call.After, want.total, want.actions, want.after)
+			}
+			total += want.total
+		}
+		if plan.Total.Int64() != total {
+			t.Errorf("%s: plan total %v, want %d", tt.name, plan.Total, total)
+		}
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	locks := []stakemath.TimeLock{lock(100, 100, 0, 10), lock(200, 200, 15, 10), lock(300, 300, 0, 10)}
+	call := Simulate(locks, big.NewInt(20))
+	// A single call pays less than WithdrawableAmount reports.
+	if call.Total.Int64() != 200 || Withdrawable(locks, big.NewInt(20)).Int64() != 500 {
+		t.Fatalf("call pays %v of %v", call.Total, Withdrawable(locks, big.NewInt(20)))
+	}
+	for i, l := range locks {
+		if l.RemainingAmount.Cmp(l.InitialAmount) != 0 {
+			t.Errorf("Simulate modified lock %d", i)
+		}
+	}
+
+	reverting := Simulate([]stakemath.TimeLock{lock(100, 100, 20, 10)}, big.NewInt(20))
+	if !reverting.Reverts() || !reflect.DeepEqual(reverting.After, []int{0}) {
+		t.Errorf("call without vested funds = %+v", reverting)
+	}
+}
@@ -0,0 +1,109 @@
+package vesting
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/primevprotocol/primev-contracts/pkg/heads"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+	"github.com/primevprotocol/primev-contracts/pkg/stakemath"
+	"github.com/primevprotocol/primev-contracts/pkg/terms"
+)
+
+// UnitsAnalysis compares how long a deposit's builder share takes to vest with
+// how long the subscription it buys lasts.
+//
+// deposit locks the builder share for minimalSubscriptionPeriod, a number of
+// blocks, but withdraw measures elapsed time with block.timestamp, so the
+// duration is effectively interpreted as seconds.
+type UnitsAnalysis struct {
+	Builder            common.Address
+	Terms              terms.Terms
+	BlockTime          time.Duration // Observed average block time
+	Deposit            *big.Int      // Deposit size analysed
+	SubscriptionBlocks *big.Int      // Blocks the deposit buys
+	SubscriptionTime   time.Duration // Wall-clock time the subscription lasts
+	VestingTime        time.Duration // Wall-clock time until the lock is fully vested
+	IntendedVesting    time.Duration // Vesting time if the duration were counted in blocks
+	Speedup            float64       // SubscriptionTime / VestingTime
+	Outruns            bool          // Builder share vests before the service period ends
+}
+
+// AnalyzeUnits analyses a deposit of the given size under t.
+func AnalyzeUnits(t terms.Terms, deposit *big.Int, blockTime time.Duration) UnitsAnalysis {
+	blocks := stakemath.SubscriptionPeriod(t.MinimalStake, t.MinimalSubscriptionPeriod, deposit)
+	a := UnitsAnalysis{
+		Terms:              t,
+		BlockTime:          blockTime,
+		Deposit:            deposit,
+		SubscriptionBlocks: blocks,
+		SubscriptionTime:   scaleDuration(blocks, blockTime),
+		VestingTime:        scaleDuration(t.MinimalSubscriptionPeriod, time.Second),
+		IntendedVesting:    scaleDuration(t.MinimalSubscriptionPeriod, blockTime),
+	}
+	if a.VestingTime > 0 {
+		a.Speedup = float64(a.SubscriptionTime) / float64(a.VestingTime)
+	}
+	a.Outruns = a.VestingTime < a.SubscriptionTime
+	return a
+}
+
+// ObservedBlockTime returns the average block time over the last span blocks.
+func ObservedBlockTime(ctx context.Context, reader heads.Reader, span uint64) (time.Duration, error) {
+	head, err := reader.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	if span == 0 || head.Number.Uint64() < span {
+		return 0, errors.New("vesting: not enough blocks to observe block time")
+	}
+	past, err := reader.HeaderByNumber(ctx, new(big.Int).Sub(head.Number, new(big.Int).SetUint64(span)))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(head.Time-past.Time) * time.Second / time.Duration(span), nil
+}
+
+// UnitsReport analyses the current terms of each builder for deposits of the
+// given multiples of its minimal stake, using the block time observed over
+// the last span blocks. Builders without terms are skipped.
+func UnitsReport(ctx context.Context, caller *primev.BuilderStakingCaller, reader heads.Reader, builders []common.Address, span uint64, multiples []int64) ([]UnitsAnalysis, error) {
+	blockTime, err := ObservedBlockTime(ctx, reader, span)
+	if err != nil {
+		return nil, err
+	}
+	if len(multiples) == 0 {
+		multiples = []int64{1}
+	}
+	var report []UnitsAnalysis
+	for _, builder := range builders {
+		info, err := caller.Builders(&bind.CallOpts{Context: ctx}, builder)
+		if err != nil {
+			return nil, err
+		}
+		if info.MinimalStake.Sign() == 0 || info.MinimalSubscriptionPeriod.Sign() == 0 {
+			continue
+		}
+		t := terms.Terms{MinimalStake: info.MinimalStake, MinimalSubscriptionPeriod: info.MinimalSubscriptionPeriod}
+		for _, m := range multiples {
+			a := AnalyzeUnits(t, new(big.Int).Mul(t.MinimalStake, big.NewInt(m)), blockTime)
+			a.Builder = builder
+			report = append(report, a)
+		}
+	}
+	return report, nil
+}
+
+// scaleDuration returns n*unit, saturating instead of overflowing.
+func scaleDuration(n *big.Int, unit time.Duration) time.Duration {
+	d := new(big.Int).Mul(n, big.NewInt(int64(unit)))
+	if !d.IsInt64() {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(d.Int64())
+}
@@ -0,0 +1,88 @@
+package vesting
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/primevprotocol/primev-contracts/pkg/terms"
+)
+
+func TestAnalyzeUnits(t *testing.T) {
+	ether := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+	daily := terms.Terms{MinimalStake: ether, MinimalSubscriptionPeriod: big.NewInt(7200)}
+	tests := []struct {
+		name      string
+		terms     terms.Terms
+		deposit   *big.Int
+		blockTime time.Duration
+
+		blocks       int64
+		subscription time.Duration
+		vesting      time.Duration
+		intended     time.Duration
+		speedup      float64
+		outruns      bool
+	}{
+		{
+			// 7200 blocks are a day at 12s, but the lock treats them as 7200
+			// seconds and releases the builder share after two hours.
+			name: "minimal deposit", terms: daily, deposit: ether, blockTime: 12 * time.Second,
+			blocks: 7200, subscription: 24 * time.Hour, vesting: 2 * time.Hour, intended: 24 * time.Hour,
+			speedup: 12, outruns: true,
+		},
+		{
+			// Larger deposits buy more blocks but lock for the same period.
+			name: "triple deposit", terms: daily, deposit: new(big.Int).Mul(ether, big.NewInt(3)), blockTime: 12 * time.Second,
+			blocks: 21600, subscription: 72 * time.Hour, vesting: 2 * time.Hour, intended: 24 * time.Hour,
+			speedup: 36, outruns: true,
+		},
+		{
+			name: "one second blocks", terms: daily, deposit: ether, blockTime: time.Second,
+			blocks: 7200, subscription: 2 * time.Hour, vesting: 2 * time.Hour, intended: 2 * time.Hour,
+			speedup: 1,
+		},
+		{
+			name: "sub-second blocks", terms: daily, deposit: ether, blockTime: 500 * time.Millisecond,
+			blocks: 7200, subscription: time.Hour, vesting: 2 * time.Hour, intended: time.Hour,
+			speedup: 0.5,
+		},
+		{
+			// The contract truncates the period per wei, so the deposit
+			// buys 9 blocks instead of 10.
+			name: "truncated period", terms: terms.Terms{MinimalStake: big.NewInt(3), MinimalSubscriptionPeriod: big.NewInt(10)},
+			deposit: big.NewInt(3), blockTime: 12 * time.Second,
+			blocks: 9, subscription: 108 * time.Second, vesting: 10 * time.Second, intended: 120 * time.Second,
+			speedup: 10.8, outruns: true,
+		},
+		{
+			name: "saturating durations", terms: terms.Terms{MinimalStake: big.NewInt(1), MinimalSubscriptionPeriod: new(big.Int).Lsh(big.NewInt(1), 62)},
+			deposit: big.NewInt(1), blockTime: 12 * time.Second,
+			blocks: 1 << 62, subscription: math.MaxInt64, vesting: math.MaxInt64, intended: math.MaxInt64,
+			speedup: 1,
+		},
+	}
+	for _, tt := range tests {
+		a := AnalyzeUnits(tt.terms, tt.deposit, tt.blockTime)
+		if a.SubscriptionBlocks.Cmp(big.NewInt(tt.blocks)) != 0 {
+			t.Errorf("%s: %v subscription blocks, want %d", tt.name, a.SubscriptionBlocks, tt.blocks)
+		}
+		if a.SubscriptionTime != tt.subscription || a.VestingTime != tt.vesting || a.IntendedVesting != tt.intended {
+			t.Errorf("%s: subscription %v, vesting %v, intended %v; want %v, %v, %v", tt.name,
+				a.SubscriptionTime, a.VestingTime, a.IntendedVesting, tt.subscription, tt.vesting, tt.intended)
+		}
+		// The error of reading blocks as seconds is the period times the
+		// block time minus one second.
+		want := tt.intended - tt.vesting
+		if tt.intended != math.MaxInt64 {
+			want = time.Duration(tt.terms.MinimalSubscriptionPeriod.Int64()) * (tt.blockTime - time.Second)
+		}
+		if got := a.IntendedVesting - a.VestingTime; got != want {
+			t.Errorf("%s: vesting error %v, want %v", tt.name, got, want)
+		}
+		if math.Abs(a.Speedup-tt.speedup) > 1e-9 || a.Outruns != tt.outruns {
+			t.Errorf("%s: speedup %v, outruns %v; want %v, %v", tt.name, a.Speedup, a.Outruns, tt.speedup, tt.outruns)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+// Package withdrawer periodically withdraws a builder's vested funds once they
+// are worth noticeably more than the gas it costs to withdraw them.
+package withdrawer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/primevprotocol/primev-contracts/pkg/fees"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+	"github.com/primevprotocol/primev-contracts/pkg/vesting"
+)
+
+const (
+	// DefaultInterval is used when Config.Interval is zero.
+	DefaultInterval = time.Minute
+	// DefaultGasMultiple is used when Config.GasMultiple is zero.
+	DefaultGasMultiple = 10
+	// DefaultReceiptTimeout is used when Config.ReceiptTimeout is zero.
+	DefaultReceiptTimeout = 3 * time.Minute
+)
+
+// feeBump is the minimum fee increase in percent nodes accept for replacing
+// a pending transaction.
+const feeBump = 10
+
+// receiptPoll is how often receipts of a pending withdrawal are polled.
+var receiptPoll = time.Second
+
+// Revert reasons of withdraw and withdrawableAmount that mean there is
+// nothing to do rather than that something is broken.
+var idleReasons = []string{"Nothing to withdraw", "No locked funds"}
+
+// Backend is the node connection needed by Withdrawer.
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+}
+
+// Config configures a Withdrawer.
+type Config struct {
+	Interval       time.Duration // How often to check, DefaultInterval if zero
+	GasMultiple    uint64        // Withdraw once the payout exceeds this multiple of the gas cost
+	ExportPath     string        // File withdrawals are appended to as JSON lines, none if empty
+	ReceiptTimeout time.Duration // Wait before replacing an unmined withdrawal, DefaultReceiptTimeout if zero
+}
+
+// Withdrawal records a single executed withdrawal.
+type Withdrawal struct {
+	Time     time.Time      `json:"time"`
+	Builder  common.Address `json:"builder"`
+	TxHash   common.Hash    `json:"txHash"`
+	Block    uint64         `json:"block"`
+	Amount   *hexutil.Big   `json:"amount"`
+	Expected *hexutil.Big   `json:"expected"`           // Simulated payout before sending
+	Replaced []common.Hash  `json:"replaced,omitempty"` // Replaced transactions at the same nonce
+	GasCost  *hexutil.Big   `json:"gasCost"`
+}
+
+// Withdrawer withdraws vested funds of the account behind opts.
+type Withdrawer struct {
+	cfg        Config
+	backend    Backend
+	contract   *primev.BuilderStaking
+	transactor *fees.Transactor
+	opts       *bind.TransactOpts
+	total      *big.Int
+	pending    *pending
+}
+
+// pending is a sent withdrawal that has not been mined. Replacements reuse
+// its nonce, so at most one of txs is mined.
+type pending struct {
+	txs    []*types.Transaction // Latest last
+	payout *big.Int
+}
+
+// New creates a Withdrawer for the BuilderStaking contract at address.
+func New(address common.Address, backend Backend, transactor *fees.Transactor, opts *bind.TransactOpts, cfg Config) (*Withdrawer, error) {
+	if cfg.Interval == 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.GasMultiple == 0 {
+		cfg.GasMultiple = DefaultGasMultiple
+	}
+	if cfg.ReceiptTimeout == 0 {
+		cfg.ReceiptTimeout = DefaultReceiptTimeout
+	}
+	contract, err := primev.NewBuilderStaking(address, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &Withdrawer{
+		cfg:        cfg,
+		backend:    backend,
+		contract:   contract,
+		transactor: transactor,
+		opts:       opts,
+		total:      new(big.Int),
+	}, nil
+}
+
+// Total returns the amount withdrawn since the Withdrawer was created.
+func (w *Withdrawer) Total() *big.Int {
+	return new(big.Int).Set(w.total)
+}
+
+// Run checks for withdrawable funds every interval until ctx is done.
+func (w *Withdrawer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.Check(ctx); err != nil {
+			log.Warn("Withdrawal check failed", "builder", w.opts.From, "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check withdraws if a withdraw call would pay more than the configured
+// multiple of the gas cost. The payout is simulated on the time locks in
+// contract order, since WithdrawableAmount overstates what a single call
+// pays. It returns nil without error when nothing was withdrawn.
+//
+// A withdrawal is waited for at most ReceiptTimeout per check. If it is still
+// unmined by then it is replaced at the same nonce with higher fees, and
+// later checks wait for it instead of sending another one.
+func (w *Withdrawer) Check(ctx context.Context) (*Withdrawal, error) {
+	if w.pending != nil {
+		return w.wait(ctx)
+	}
+	builder := w.opts.From
+	vested, err := w.contract.WithdrawableAmount(&bind.CallOpts{Context: ctx, From: builder})
+	if isIdle(err) || (err == nil && vested.Sign() == 0) {
+		log.Debug("Nothing to withdraw", "builder", builder)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	head, err := w.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	locks, err := vesting.LoadTimeLocks(&bind.CallOpts{Context: ctx, BlockNumber: head.Number}, &w.contract.BuilderStakingCaller, builder)
+	if err != nil {
+		return nil, err
+	}
+	// The call executes in a later block, so the payout can only grow.
+	payout := vesting.Simulate(locks, new(big.Int).SetUint64(head.Time))
+	if payout.Reverts() {
+		log.Debug("Nothing to withdraw", "builder", builder, "vested", vested)
+		return nil, nil
+	}
+	amount := payout.Total
+
+	opts := *w.opts
+	opts.Context = ctx
+	gas, err := w.transactor.EstimateGas(&opts, "withdraw")
+	if isIdle(err) {
+		log.Debug("Nothing to withdraw", "builder", builder, "vested", vested)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cost, err := w.gasCost(ctx, head, gas)
+	if err != nil {
+		return nil, err
+	}
+	threshold := new(big.Int).Mul(cost, new(big.Int).SetUint64(w.cfg.GasMultiple))
+	if amount.Cmp(threshold) <= 0 {
+		log.Debug("Payout below threshold", "builder", builder, "vested", vested, "payout", amount, "threshold", threshold)
+		return nil, nil
+	}
+
+	opts.GasLimit = gas
+	tx, err := w.transactor.Withdraw(&opts)
+	if isIdle(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Sent withdrawal", "builder", builder, "tx", tx.Hash(), "vested", vested, "payout", amount, "gasCost", cost)
+	w.pending = &pending{txs: []*types.Transaction{tx}, payout: amount}
+	return w.wait(ctx)
+}
+
+// wait waits up to ReceiptTimeout for the pending withdrawal and replaces it
+// if it is not mined by then. It returns nil without error while the
+// withdrawal stays pending.
+func (w *Withdrawer) wait(ctx context.Context) (*Withdrawal, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, w.cfg.ReceiptTimeout)
+	defer cancel()
+	receipt, tx, err := w.receipt(waitCtx)
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return nil, w.replace(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := w.pending
+	w.pending = nil
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("withdrawer: withdraw transaction %s reverted", tx.Hash())
+	}
+
+	builder := w.opts.From
+	record := &Withdrawal{
+		Time:     time.Now().UTC(),
+		Builder:  builder,
+		TxHash:   tx.Hash(),
+		Block:    receipt.BlockNumber.Uint64(),
+		Amount:   new(hexutil.Big),
+		Expected: (*hexutil.Big)(p.payout),
+		GasCost:  (*hexutil.Big)(new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)),
+	}
+	for _, t := range p.txs {
+		if t.Hash() != tx.Hash() {
+			record.Replaced = append(record.Replaced, t.Hash())
+		}
+	}
+	for _, l := range receipt.Logs {
+		if event, err := w.contract.ParseWithdrawal(*l); err == nil && event.Builder == builder {
+			record.Amount = (*hexutil.Big)(event.Amount)
+		}
+	}
+	w.total.Add(w.total, record.Amount.ToInt())
+	log.Info("Withdrawal mined", "builder", builder, "tx", tx.Hash(), "amount", record.Amount, "total", w.total)
+	if err := w.export(record); err != nil {
+		log.Error("Failed to export withdrawal", "err", err)
+	}
+	return record, nil
+}
+
+// receipt polls the receipts of all transactions of the pending withdrawal
+// until one is mined or ctx is done.
+func (w *Withdrawer) receipt(ctx context.Context) (*types.Receipt, *types.Transaction, error) {
+	ticker := time.NewTicker(receiptPoll)
+	defer ticker.Stop()
+	for {
+		for _, tx := range w.pending.txs {
+			receipt, err := w.backend.TransactionReceipt(ctx, tx.Hash())
+			if err == nil {
+				return receipt, tx, nil
+			}
+			if !errors.Is(err, ethereum.NotFound) && ctx.Err() == nil {
+				log.Debug("Failed to fetch withdrawal receipt", "tx", tx.Hash(), "err", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// replace sends the pending withdrawal again at its nonce with fees raised by
+// at least feeBump percent, or to the current suggestion if that is higher,
+// unless the payout no longer covers the gas multiple at those fees.
+func (w *Withdrawer) replace(ctx context.Context) error {
+	last := w.pending.txs[len(w.pending.txs)-1]
+	tip, feeCap := bump(last.GasTipCap()), bump(last.GasFeeCap())
+	if current, err := w.transactor.Fees(ctx); err == nil {
+		if current.TipCap.Cmp(tip) > 0 {
+			tip = current.TipCap
+		}
+		if current.FeeCap.Cmp(feeCap) > 0 {
+			feeCap = current.FeeCap
+		}
+	}
+	cost := new(big.Int).Mul(feeCap, new(big.Int).SetUint64(last.Gas()))
+	threshold := cost.Mul(cost, new(big.Int).SetUint64(w.cfg.GasMultiple))
+	if w.pending.payout.Cmp(threshold) <= 0 {
+		return fmt.Errorf("withdrawer: withdrawal %s unmined, replacing it at fee cap %v would exceed the gas multiple", last.Hash(), feeCap)
+	}
+
+	opts := *w.opts
+	opts.Context = ctx
+	opts.Nonce = new(big.Int).SetUint64(last.Nonce())
+	opts.GasLimit = last.Gas()
+	opts.GasTipCap = tip
+	opts.GasFeeCap = feeCap
+	tx, err := w.transactor.Withdraw(&opts)
+	if err != nil {
+		// A nonce too low error means one of the transactions was mined,
+		// which the next check finds.
+		return fmt.Errorf("withdrawer: replace withdrawal %s: %w", last.Hash(), err)
+	}
+	w.pending.txs = append(w.pending.txs, tx)
+	log.Info("Replaced stuck withdrawal", "builder", w.opts.From, "tx", tx.Hash(), "replaced", last.Hash(),
+		"nonce", tx.Nonce(), "tipCap", tip, "feeCap", feeCap)
+	return nil
+}
+
+func bump(v *big.Int) *big.Int {
+	b := new(big.Int).Mul(v, big.NewInt(100+feeBump))
+	b.Div(b, big.NewInt(100))
+	return b.Add(b, common.Big1)
+}
+
+// gasCost estimates the cost of gas units at head's base fee plus the
+// suggested tip.
+func (w *Withdrawer) gasCost(ctx context.Context, head *types.Header, gas uint64) (*big.Int, error) {
+	suggested, err := w.transactor.Fees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	price := new(big.Int).Set(suggested.TipCap)
+	if head.BaseFee != nil {
+		price.Add(price, head.BaseFee)
+	}
+	if price.Cmp(suggested.FeeCap) > 0 {
+		price.Set(suggested.FeeCap)
+	}
+	return price.Mul(price, new(big.Int).SetUint64(gas)), nil
+}
+
+func (w *Withdrawer) export(record *Withdrawal) error {
+	if w.cfg.ExportPath == "" {
+		return nil
+	}
+	f, err := os.OpenFile(w.cfg.ExportPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(record); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func isIdle(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, reason := range idleReasons {
+		if strings.Contains(err.Error(), reason) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package withdrawer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/primevprotocol/primev-contracts/pkg/fees"
+	"github.com/primevprotocol/primev-contracts/pkg/primev"
+)
+
+var contractABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(primev.BuilderStakingMetaData.ABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// lock is a time lock of initial wei with remaining wei left, vesting over
+// duration seconds from start.
+type lock struct{ initial, remaining, start, duration int64 }
+
+// chain answers the builder's time lock reads at timestamp 20 with a base
+// fee of 1 wei and a withdraw costing 30 gas. Methods the withdrawer does
+// not use are left to the nil embedded Backend.
+type chain struct {
+	Backend
+	locks    []lock
+	autoMine bool // Mine sent transactions at once, paying every remaining amount
+	sent     []*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+}
+
+func (c *chain) CallContract(ctx context.Context, call ethereum.CallMsg, number *big.Int) ([]byte, error) {
+	method, err := contractABI.MethodById(call.Data[:4])
+	if err != nil {
+		return nil, err
+	}
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	switch method.Name {
+	case "withdrawableAmount":
+		// Not what a call pays, see vesting.Withdrawable.
+		total := int64(0)
+		for _, l := range c.locks {
+			total += l.remaining
+		}
+		return method.Outputs.Pack(big.NewInt(total))
+	case "timeLocksCount":
+		return method.Outputs.Pack(big.NewInt(int64(len(c.locks))))
+	case "timeLocks":
+		l := c.locks[args[1].(*big.Int).Int64()]
+		return method.Outputs.Pack(big.NewInt(l.initial), big.NewInt(l.remaining), big.NewInt(l.start), big.NewInt(l.duration))
+	}
+	return nil, fmt.Errorf("unexpected call to %s", method.Name)
+}
+
+func (c *chain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(100), Time: 20, BaseFee: big.NewInt(1)}, nil
+}
+
+func (c *chain) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	return 30, nil
+}
+
+func (c *chain) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return 3, nil
+}
+
+func (c *chain) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	c.sent = append(c.sent, tx)
+	if c.autoMine {
+		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil {
+			return err
+		}
+		total := int64(0)
+		for _, l := range c.locks {
+			total += l.remaining
+		}
+		c.mine(tx, from, total)
+	}
+	return nil
+}
+
+func (c *chain) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	if receipt, ok := c.receipts[hash]; ok {
+		return receipt, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+// mine makes tx mined, paying amount to builder.
+func (c *chain) mine(tx *types.Transaction, builder common.Address, amount int64) {
+	ev := contractABI.Events["Withdrawal"]
+	data, err := ev.Inputs.Pack(builder, big.NewInt(amount))
+	if err != nil {
+		panic(err)
+	}
+	c.receipts[tx.Hash()] = &types.Receipt{
+		TxHash:            tx.Hash(),
+		Status:            types.ReceiptStatusSuccessful,
+		BlockNumber:       big.NewInt(101),
+		GasUsed:           30,
+		EffectiveGasPrice: big.NewInt(2),
+		Logs:              []*types.Log{{Topics: []common.Hash{ev.ID}, Data: data}},
+	}
+}
+
+func newWithdrawer(t *testing.T, c *chain, feeCap int64) *Withdrawer {
+	key, _ := crypto.GenerateKey()
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactor, err := fees.NewTransactor(common.Address{}, c, fees.Policy{Strategy: &fees.Fixed{TipCap: big.NewInt(0), FeeCap: big.NewInt(feeCap)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// At a base fee of 1 wei a withdraw costs 30 wei, so the payout must
+	// exceed 300 wei.
+	w, err := New(common.Address{}, c, transactor, opts, Config{ReceiptTimeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestCheckThreshold(t *testing.T) {
+	tests := []struct {
+		name  string
+		locks []lock
+		sent  bool
+	}{
+		// WithdrawableAmount reports 500, but the moved lock is skipped
+		// and a call pays only 200.
+		{name: "skipped lock", locks: []lock{{100, 100, 0, 10}, {200, 200, 15, 10}, {300, 300, 0, 10}}},
+		{name: "all vested", locks: []lock{{400, 400, 0, 10}}, sent: true},
+		{name: "payout at threshold", locks: []lock{{300, 300, 0, 10}}},
+		{name: "nothing vested", locks: []lock{{500, 500, 30, 10}}},
+	}
+	for _, tt := range tests {
+		c := &chain{locks: tt.locks, autoMine: true, receipts: make(map[common.Hash]*types.Receipt)}
+		w := newWithdrawer(t, c, 10)
+		if _, err := w.Check(context.Background()); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if sent := len(c.sent) > 0; sent != tt.sent {
+			t.Errorf("%s: withdrawal sent %v, want %v", tt.name, sent, tt.sent)
+		}
+	}
+}
+
+func TestCheckReplacesStuckWithdrawal(t *testing.T) {
+	c := &chain{locks: []lock{{1000, 1000, 0, 10}}, receipts: make(map[common.Hash]*types.Receipt)}
+	w := newWithdrawer(t, c, 2)
+	ctx := context.Background()
+	if record, err := w.Check(ctx); record != nil || err != nil {
+		t.Fatalf("unmined withdrawal: %+v, %v", record, err)
+	}
+	if len(c.sent) != 2 {
+		t.Fatalf("%d transactions sent, want the withdrawal and its replacement", len(c.sent))
+	}
+	first, replacement := c.sent[0], c.sent[1]
+	if replacement.Nonce() != first.Nonce() || replacement.Gas() != first.Gas() || replacement.GasFeeCap().Cmp(bump(first.GasFeeCap())) < 0 {
+		t.Errorf("replacement nonce %d gas %d fee cap %v, want %d, %d and at least %v",
+			replacement.Nonce(), replacement.Gas(), replacement.GasFeeCap(), first.Nonce(), first.Gas(), bump(first.GasFeeCap()))
+	}
+
+	// The original is mined after all; the next check picks it up instead
+	// of sending another withdrawal.
+	c.mine(first, w.opts.From, 1000)
+	record, err := w.Check(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || record.TxHash != first.Hash() || len(record.Replaced) != 1 || record.Replaced[0] != replacement.Hash() ||
+		record.Amount.ToInt().Int64() != 1000 || record.Expected.ToInt().Int64() != 1000 {
+		t.Fatalf("record %+v", record)
+	}
+	if len(c.sent) != 2 || w.Total().Int64() != 1000 {
+		t.Errorf("%d transactions sent, total %v", len(c.sent), w.Total())
+	}
+}
+
+func TestCheckKeepsWithdrawalWithinGasMultiple(t *testing.T) {
+	// A payout of 400 wei covers 10 times 30 gas at 1 wei, but not at the
+	// bumped fee cap of 2 wei.
+	c := &chain{locks: []lock{{400, 400, 0, 10}}, receipts: make(map[common.Hash]*types.Receipt)}
+	w := newWithdrawer(t, c, 1)
+	if _, err := w.Check(context.Background()); err == nil || !strings.Contains(err.Error(), "gas multiple") {
+		t.Fatalf("err = %v", err)
+	}
+	if len(c.sent) != 1 || w.pending == nil {
+		t.Fatalf("%d transactions sent, pending %v", len(c.sent), w.pending)
+	}
+}